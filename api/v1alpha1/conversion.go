@@ -0,0 +1,680 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
+)
+
+// ConvertTo converts this HarvesterCluster to the Hub version (v1alpha2).
+func (src *HarvesterCluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.HarvesterCluster)
+
+	dst.ObjectMeta = src.ObjectMeta
+	convertHarvesterClusterSpecToHub(&src.Spec, &dst.Spec)
+	convertHarvesterClusterStatusToHub(&src.Status, &dst.Status)
+
+	// Manually restore data.
+	restored := &infrav1.HarvesterCluster{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		restoreHubIPAMConfig(&dst.Spec.LoadBalancerConfig.IPAM, &restored.Spec.LoadBalancerConfig.IPAM)
+		restoreHubLoadBalancerConfig(&dst.Spec.LoadBalancerConfig, &restored.Spec.LoadBalancerConfig)
+		restoreHubHarvesterClusterStatus(&dst.Status, &restored.Status)
+	}
+
+	// Preserve Spoke data on up-conversion.
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this HarvesterCluster.
+func (dst *HarvesterCluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.HarvesterCluster)
+
+	dst.ObjectMeta = src.ObjectMeta
+	convertHarvesterClusterSpecFromHub(&src.Spec, &dst.Spec)
+	convertHarvesterClusterStatusFromHub(&src.Status, &dst.Status)
+
+	// Manually restore data.
+	restored := &HarvesterCluster{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		restoreSpokeLoadBalancerConfig(&dst.Spec.LoadBalancerConfig, &restored.Spec.LoadBalancerConfig)
+	}
+
+	// Preserve Hub data on down-conversion.
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertTo converts this HarvesterClusterList to the Hub version (v1alpha2).
+func (src *HarvesterClusterList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.HarvesterClusterList)
+
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]infrav1.HarvesterCluster, len(src.Items))
+
+	for i := range src.Items {
+		if err := src.Items[i].ConvertTo(&dst.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this HarvesterClusterList.
+func (dst *HarvesterClusterList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.HarvesterClusterList)
+
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]HarvesterCluster, len(src.Items))
+
+	for i := range src.Items {
+		if err := dst.Items[i].ConvertFrom(&src.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConvertTo converts this HarvesterClusterTemplate to the Hub version (v1alpha2).
+func (src *HarvesterClusterTemplate) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.HarvesterClusterTemplate)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec.Template.ObjectMeta = src.Spec.Template.ObjectMeta
+	convertHarvesterClusterSpecToHub(&src.Spec.Template.Spec, &dst.Spec.Template.Spec)
+
+	// Manually restore data.
+	restored := &infrav1.HarvesterClusterTemplate{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		restoreHubIPAMConfig(&dst.Spec.Template.Spec.LoadBalancerConfig.IPAM, &restored.Spec.Template.Spec.LoadBalancerConfig.IPAM)
+		restoreHubLoadBalancerConfig(&dst.Spec.Template.Spec.LoadBalancerConfig, &restored.Spec.Template.Spec.LoadBalancerConfig)
+	}
+
+	// Preserve Spoke data on up-conversion.
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this HarvesterClusterTemplate.
+func (dst *HarvesterClusterTemplate) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.HarvesterClusterTemplate)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec.Template.ObjectMeta = src.Spec.Template.ObjectMeta
+	convertHarvesterClusterSpecFromHub(&src.Spec.Template.Spec, &dst.Spec.Template.Spec)
+
+	// Manually restore data.
+	restored := &HarvesterClusterTemplate{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		restoreSpokeLoadBalancerConfig(&dst.Spec.Template.Spec.LoadBalancerConfig, &restored.Spec.Template.Spec.LoadBalancerConfig)
+	}
+
+	// Preserve Hub data on down-conversion.
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertTo converts this HarvesterClusterTemplateList to the Hub version (v1alpha2).
+func (src *HarvesterClusterTemplateList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.HarvesterClusterTemplateList)
+
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]infrav1.HarvesterClusterTemplate, len(src.Items))
+
+	for i := range src.Items {
+		if err := src.Items[i].ConvertTo(&dst.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this HarvesterClusterTemplateList.
+func (dst *HarvesterClusterTemplateList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.HarvesterClusterTemplateList)
+
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]HarvesterClusterTemplate, len(src.Items))
+
+	for i := range src.Items {
+		if err := dst.Items[i].ConvertFrom(&src.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConvertTo converts this HarvesterMachine to the Hub version (v1alpha2).
+func (src *HarvesterMachine) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.HarvesterMachine)
+
+	dst.ObjectMeta = src.ObjectMeta
+	if err := convertHarvesterMachineSpecToHub(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+
+	convertHarvesterMachineStatusToHub(&src.Status, &dst.Status)
+
+	// Manually restore data.
+	restored := &infrav1.HarvesterMachine{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		restoreHubCPU(&dst.Spec.CPU, &restored.Spec.CPU)
+		restoreHubNetworks(dst.Spec.Networks, restored.Spec.Networks)
+		dst.Status.StaticAddresses = restored.Status.StaticAddresses
+		dst.Status.VMState = restored.Status.VMState
+	}
+
+	// Preserve Spoke data on up-conversion.
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this HarvesterMachine.
+func (dst *HarvesterMachine) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.HarvesterMachine)
+
+	dst.ObjectMeta = src.ObjectMeta
+	convertHarvesterMachineSpecFromHub(&src.Spec, &dst.Spec)
+	convertHarvesterMachineStatusFromHub(&src.Status, &dst.Status)
+
+	// Manually restore data.
+	restored := &HarvesterMachine{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		restoreSpokeMemory(&dst.Spec.Memory, src.Spec.Memory, restored.Spec.Memory)
+	}
+
+	// Preserve Hub data on down-conversion.
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertTo converts this HarvesterMachineList to the Hub version (v1alpha2).
+func (src *HarvesterMachineList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.HarvesterMachineList)
+
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]infrav1.HarvesterMachine, len(src.Items))
+
+	for i := range src.Items {
+		if err := src.Items[i].ConvertTo(&dst.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this HarvesterMachineList.
+func (dst *HarvesterMachineList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.HarvesterMachineList)
+
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]HarvesterMachine, len(src.Items))
+
+	for i := range src.Items {
+		if err := dst.Items[i].ConvertFrom(&src.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConvertTo converts this HarvesterMachineTemplate to the Hub version (v1alpha2).
+func (src *HarvesterMachineTemplate) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.HarvesterMachineTemplate)
+
+	dst.ObjectMeta = src.ObjectMeta
+	if err := convertHarvesterMachineSpecToHub(&src.Spec.Template.Spec, &dst.Spec.Template.Spec); err != nil {
+		return err
+	}
+
+	// Manually restore data.
+	restored := &infrav1.HarvesterMachineTemplate{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		restoreHubCPU(&dst.Spec.Template.Spec.CPU, &restored.Spec.Template.Spec.CPU)
+		restoreHubNetworks(dst.Spec.Template.Spec.Networks, restored.Spec.Template.Spec.Networks)
+	}
+
+	// Preserve Spoke data on up-conversion.
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this HarvesterMachineTemplate.
+func (dst *HarvesterMachineTemplate) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.HarvesterMachineTemplate)
+
+	dst.ObjectMeta = src.ObjectMeta
+	convertHarvesterMachineSpecFromHub(&src.Spec.Template.Spec, &dst.Spec.Template.Spec)
+
+	// Manually restore data.
+	restored := &HarvesterMachineTemplate{}
+	ok, err := utilconversion.UnmarshalData(src, restored)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		restoreSpokeMemory(&dst.Spec.Template.Spec.Memory, src.Spec.Template.Spec.Memory, restored.Spec.Template.Spec.Memory)
+	}
+
+	// Preserve Hub data on down-conversion.
+	return utilconversion.MarshalData(src, dst)
+}
+
+// ConvertTo converts this HarvesterMachineTemplateList to the Hub version (v1alpha2).
+func (src *HarvesterMachineTemplateList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*infrav1.HarvesterMachineTemplateList)
+
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]infrav1.HarvesterMachineTemplate, len(src.Items))
+
+	for i := range src.Items {
+		if err := src.Items[i].ConvertTo(&dst.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this HarvesterMachineTemplateList.
+func (dst *HarvesterMachineTemplateList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*infrav1.HarvesterMachineTemplateList)
+
+	dst.ListMeta = src.ListMeta
+	dst.Items = make([]HarvesterMachineTemplate, len(src.Items))
+
+	for i := range src.Items {
+		if err := dst.Items[i].ConvertFrom(&src.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func convertHarvesterClusterSpecToHub(src *HarvesterClusterSpec, dst *infrav1.HarvesterClusterSpec) {
+	dst.Server = src.Server
+	dst.IdentitySecret = infrav1.SecretKey(src.IdentitySecret)
+	dst.ControlPlaneEndpoint = src.ControlPlaneEndpoint
+	dst.TargetNamespace = src.TargetNamespace
+	dst.UpdateCloudProviderConfig = infrav1.UpdateCloudProviderConfig(src.UpdateCloudProviderConfig)
+
+	convertLoadBalancerConfigToHub(&src.LoadBalancerConfig, &dst.LoadBalancerConfig)
+}
+
+func convertHarvesterClusterSpecFromHub(src *infrav1.HarvesterClusterSpec, dst *HarvesterClusterSpec) {
+	dst.Server = src.Server
+	dst.IdentitySecret = SecretKey(src.IdentitySecret)
+	dst.ControlPlaneEndpoint = src.ControlPlaneEndpoint
+	dst.TargetNamespace = src.TargetNamespace
+	dst.UpdateCloudProviderConfig = UpdateCloudProviderConfig(src.UpdateCloudProviderConfig)
+
+	convertLoadBalancerConfigFromHub(&src.LoadBalancerConfig, &dst.LoadBalancerConfig)
+}
+
+// convertLoadBalancerConfigToHub maps the "dhcp" and "pool" IPAM types to the v1alpha2 IPAM union.
+// A "pool" load balancer with an IpPool definition uses a new IP Pool, any IpPoolRef is ignored
+// because it was only set by the controller to the name of the IP Pool it created. It is kept in the conversion data.
+func convertLoadBalancerConfigToHub(src *LoadBalancerConfig, dst *infrav1.LoadBalancerConfig) {
+	dst.Description = src.Description
+	dst.Listeners = nil
+
+	if src.Listeners != nil {
+		dst.Listeners = make([]infrav1.Listener, len(src.Listeners))
+		for i := range src.Listeners {
+			dst.Listeners[i] = infrav1.Listener(src.Listeners[i])
+		}
+	}
+
+	dst.IPAM = infrav1.IPAMConfig{}
+
+	switch src.IPAMType {
+	case DHCP:
+		dst.IPAM.Type = infrav1.IPAMTypeDHCP
+	case POOL:
+		if src.IpPool != (IpPool{}) {
+			dst.IPAM.Type = infrav1.IPAMTypeIPPool
+			dst.IPAM.IPPool = &infrav1.IPPool{
+				VMNetwork:  objectReferenceFromString(src.IpPool.VMNetwork),
+				Subnet:     src.IpPool.Subnet,
+				Gateway:    src.IpPool.Gateway,
+				RangeStart: src.IpPool.RangeStart,
+				RangeEnd:   src.IpPool.RangeEnd,
+			}
+		} else {
+			dst.IPAM.Type = infrav1.IPAMTypeIPPoolRef
+			dst.IPAM.IPPoolRef = &infrav1.IPPoolReference{Name: src.IpPoolRef}
+		}
+	default:
+		dst.IPAM.Type = infrav1.IPAMType(src.IPAMType)
+	}
+}
+
+func convertLoadBalancerConfigFromHub(src *infrav1.LoadBalancerConfig, dst *LoadBalancerConfig) {
+	dst.Description = src.Description
+	dst.Listeners = nil
+
+	if src.Listeners != nil {
+		dst.Listeners = make([]Listener, len(src.Listeners))
+		for i := range src.Listeners {
+			dst.Listeners[i] = Listener(src.Listeners[i])
+		}
+	}
+
+	dst.IpPool = IpPool{}
+	dst.IpPoolRef = ""
+
+	switch src.IPAM.Type {
+	case infrav1.IPAMTypeDHCP:
+		dst.IPAMType = DHCP
+	case infrav1.IPAMTypeIPPool, infrav1.IPAMTypeIPPoolRef, infrav1.IPAMTypeIPAddressClaim:
+		dst.IPAMType = POOL
+	default:
+		dst.IPAMType = IPAMType(src.IPAM.Type)
+	}
+
+	if src.IPAM.IPPool != nil {
+		dst.IpPool = IpPool{
+			VMNetwork:  src.IPAM.IPPool.VMNetwork.String(),
+			Subnet:     src.IPAM.IPPool.Subnet,
+			Gateway:    src.IPAM.IPPool.Gateway,
+			RangeStart: src.IPAM.IPPool.RangeStart,
+			RangeEnd:   src.IPAM.IPPool.RangeEnd,
+		}
+	}
+
+	if src.IPAM.IPPoolRef != nil {
+		dst.IpPoolRef = src.IPAM.IPPoolRef.Name
+	}
+}
+
+func convertHarvesterClusterStatusToHub(src *HarvesterClusterStatus, dst *infrav1.HarvesterClusterStatus) {
+	dst.Ready = src.Ready
+	dst.FailureReason = src.FailureReason
+	dst.FailureMessage = src.FailureMessage
+	dst.Conditions = src.Conditions
+}
+
+func convertHarvesterClusterStatusFromHub(src *infrav1.HarvesterClusterStatus, dst *HarvesterClusterStatus) {
+	dst.Ready = src.Ready
+	dst.FailureReason = src.FailureReason
+	dst.FailureMessage = src.FailureMessage
+	dst.Conditions = src.Conditions
+}
+
+func convertHarvesterMachineSpecToHub(src *HarvesterMachineSpec, dst *infrav1.HarvesterMachineSpec) error {
+	dst.ProviderID = src.ProviderID
+	dst.FailureDomain = src.FailureDomain
+	// The legacy CPU field is the number of vCPUs, which maps to a topology of a single socket with one thread per core.
+	dst.CPU = infrav1.CPU{Cores: int32(src.CPU), Sockets: 1, Threads: 1}
+	dst.SSHUser = src.SSHUser
+	dst.SSHKeyPair = objectReferenceFromString(src.SSHKeyPair)
+	dst.NodeAffinity = src.NodeAffinity
+	dst.WorkloadAffinity = src.WorkloadAffinity
+
+	dst.Memory = resource.Quantity{}
+
+	if src.Memory != "" {
+		memory, err := resource.ParseQuantity(src.Memory)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse memory %q", src.Memory)
+		}
+
+		dst.Memory = memory
+	}
+
+	dst.Volumes = nil
+
+	if src.Volumes != nil {
+		dst.Volumes = make([]infrav1.Volume, len(src.Volumes))
+		for i, volume := range src.Volumes {
+			dst.Volumes[i] = infrav1.Volume{
+				VolumeType:   infrav1.VolumeType(volume.VolumeType),
+				StorageClass: volume.StorageClass,
+				VolumeSize:   volume.VolumeSize,
+				BootOrder:    volume.BootOrder,
+			}
+
+			if volume.ImageName != "" {
+				image := objectReferenceFromString(volume.ImageName)
+				dst.Volumes[i].Image = &image
+			}
+		}
+	}
+
+	dst.Networks = nil
+
+	if src.Networks != nil {
+		dst.Networks = make([]infrav1.Network, len(src.Networks))
+		for i, network := range src.Networks {
+			dst.Networks[i] = infrav1.Network{}
+
+			if network != "" {
+				vmNetwork := objectReferenceFromString(network)
+				dst.Networks[i].VMNetwork = &vmNetwork
+			}
+		}
+	}
+
+	return nil
+}
+
+// restoreHubIPAMConfig restores an IPAddressClaim IPAM config, which is converted to a "pool" load balancer without any IP Pool,
+// unless an IP Pool was set since it was saved.
+func restoreHubIPAMConfig(dst, restored *infrav1.IPAMConfig) {
+	if restored.Type != infrav1.IPAMTypeIPAddressClaim {
+		return
+	}
+
+	if dst.Type == infrav1.IPAMTypeIPPoolRef && dst.IPPoolRef != nil && dst.IPPoolRef.Name == "" {
+		*dst = *restored
+	}
+}
+
+// restoreHubIPPool restores the additional ranges, the exclusions and the scope of an IP Pool definition, which do not exist in v1alpha1.
+func restoreHubIPPool(dst, restored *infrav1.IPAMConfig) {
+	if dst.IPPool != nil && restored.IPPool != nil {
+		dst.IPPool.AdditionalRanges = restored.IPPool.AdditionalRanges
+		dst.IPPool.Exclude = restored.IPPool.Exclude
+		dst.IPPool.Scope = restored.IPPool.Scope
+	}
+}
+
+// restoreHubLoadBalancerConfig restores the type, the API server ports, the health check, the kube-vip configuration
+// and the IP families of the load balancer, which do not exist in v1alpha1.
+func restoreHubLoadBalancerConfig(dst, restored *infrav1.LoadBalancerConfig) {
+	dst.Type = restored.Type
+	dst.KubeVIP = restored.KubeVIP
+	dst.APIServerPort = restored.APIServerPort
+	dst.BackendPort = restored.BackendPort
+	dst.HealthCheck = restored.HealthCheck
+	dst.IPFamilies = restored.IPFamilies
+	dst.IPFamilyPolicy = restored.IPFamilyPolicy
+
+	restoreHubIPPool(&dst.IPAM, &restored.IPAM)
+}
+
+// restoreHubHarvesterClusterStatus restores the fields of the HarvesterCluster status which do not exist in v1alpha1.
+func restoreHubHarvesterClusterStatus(dst, restored *infrav1.HarvesterClusterStatus) {
+	dst.LoadBalancerAddresses = restored.LoadBalancerAddresses
+	dst.AllocatedAddress = restored.AllocatedAddress
+	dst.LoadBalancer = restored.LoadBalancer
+	dst.PlaceholderService = restored.PlaceholderService
+	dst.IPPool = restored.IPPool
+	dst.CloudProvider = restored.CloudProvider
+	dst.HarvesterVersion = restored.HarvesterVersion
+	dst.ObservedGeneration = restored.ObservedGeneration
+}
+
+// restoreHubCPU restores the CPU topology of the hub, unless the legacy number of vCPUs was changed since it was saved.
+func restoreHubCPU(dst, restored *infrav1.CPU) {
+	if dst.VCPUs() == restored.VCPUs() {
+		*dst = *restored
+
+		return
+	}
+
+	dst.DedicatedCPUPlacement = restored.DedicatedCPUPlacement
+	dst.Model = restored.Model
+}
+
+// restoreHubNetworks restores the interface settings of the hub, unless networks were added or removed since they were saved.
+func restoreHubNetworks(dst, restored []infrav1.Network) {
+	if len(dst) != len(restored) {
+		return
+	}
+
+	for i := range dst {
+		dst[i].Binding = restored[i].Binding
+		dst[i].Model = restored[i].Model
+		dst[i].MACAddress = restored[i].MACAddress
+		dst[i].Primary = restored[i].Primary
+		dst[i].IPAM = restored[i].IPAM
+	}
+}
+
+// restoreSpokeLoadBalancerConfig restores the IpPoolRef of a "pool" load balancer which also defines an IpPool,
+// unless the IpPool was changed since it was saved.
+func restoreSpokeLoadBalancerConfig(dst, restored *LoadBalancerConfig) {
+	if dst.IPAMType == POOL && restored.IPAMType == POOL && dst.IpPoolRef == "" &&
+		dst.IpPool != (IpPool{}) && dst.IpPool == restored.IpPool {
+		dst.IpPoolRef = restored.IpPoolRef
+	}
+}
+
+// restoreSpokeMemory restores the memory as it was written, e.g. "8192Mi" instead of "8Gi", unless it was changed since it was saved.
+func restoreSpokeMemory(dst *string, memory resource.Quantity, restored string) {
+	restoredMemory, err := resource.ParseQuantity(restored)
+	if err == nil && restoredMemory.Cmp(memory) == 0 {
+		*dst = restored
+	}
+}
+
+func convertHarvesterMachineSpecFromHub(src *infrav1.HarvesterMachineSpec, dst *HarvesterMachineSpec) {
+	dst.ProviderID = src.ProviderID
+	dst.FailureDomain = src.FailureDomain
+	dst.CPU = int(src.CPU.VCPUs())
+	dst.SSHUser = src.SSHUser
+	dst.SSHKeyPair = src.SSHKeyPair.String()
+	dst.NodeAffinity = src.NodeAffinity
+	dst.WorkloadAffinity = src.WorkloadAffinity
+
+	// The memory comes back in the canonical form of the quantity, e.g. "4096Mi" becomes "4Gi" and "1500000000" becomes "1500M".
+	// The memory as it was written is restored from the conversion data.
+	dst.Memory = ""
+	if !src.Memory.IsZero() {
+		dst.Memory = src.Memory.String()
+	}
+
+	dst.Volumes = nil
+
+	if src.Volumes != nil {
+		dst.Volumes = make([]Volume, len(src.Volumes))
+		for i, volume := range src.Volumes {
+			dst.Volumes[i] = Volume{
+				VolumeType:   VolumeType(volume.VolumeType),
+				StorageClass: volume.StorageClass,
+				VolumeSize:   volume.VolumeSize,
+				BootOrder:    volume.BootOrder,
+			}
+
+			if volume.Image != nil {
+				dst.Volumes[i].ImageName = volume.Image.String()
+			}
+		}
+	}
+
+	dst.Networks = nil
+
+	if src.Networks != nil {
+		dst.Networks = make([]string, len(src.Networks))
+		for i, network := range src.Networks {
+			if network.VMNetwork != nil {
+				dst.Networks[i] = network.VMNetwork.String()
+			}
+		}
+	}
+}
+
+func convertHarvesterMachineStatusToHub(src *HarvesterMachineStatus, dst *infrav1.HarvesterMachineStatus) {
+	dst.Ready = src.Ready
+	dst.Conditions = src.Conditions
+	dst.FailureReason = src.FailureReason
+	dst.FailureMessage = src.FailureMessage
+	dst.Addresses = src.Addresses
+}
+
+func convertHarvesterMachineStatusFromHub(src *infrav1.HarvesterMachineStatus, dst *HarvesterMachineStatus) {
+	dst.Ready = src.Ready
+	dst.Conditions = src.Conditions
+	dst.FailureReason = src.FailureReason
+	dst.FailureMessage = src.FailureMessage
+	dst.Addresses = src.Addresses
+}
+
+// objectReferenceFromString parses a reference with the format "namespace/name" or just "name".
+func objectReferenceFromString(ref string) infrav1.ObjectReference {
+	if i := strings.Index(ref, "/"); i > 0 {
+		return infrav1.ObjectReference{Namespace: ref[:i], Name: ref[i+1:]}
+	}
+
+	return infrav1.ObjectReference{Name: ref}
+}
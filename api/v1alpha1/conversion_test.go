@@ -0,0 +1,171 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
+)
+
+func TestFuzzyConversion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("for HarvesterCluster", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme:           scheme,
+		Hub:              &infrav1.HarvesterCluster{},
+		HubAfterMutation: deleteSpokeData,
+		Spoke:            &HarvesterCluster{},
+		FuzzerFuncs:      []fuzzer.FuzzerFuncs{fuzzFuncs},
+	}))
+
+	t.Run("for HarvesterClusterTemplate", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme:           scheme,
+		Hub:              &infrav1.HarvesterClusterTemplate{},
+		HubAfterMutation: deleteSpokeData,
+		Spoke:            &HarvesterClusterTemplate{},
+		FuzzerFuncs:      []fuzzer.FuzzerFuncs{fuzzFuncs},
+	}))
+
+	t.Run("for HarvesterMachine", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme:           scheme,
+		Hub:              &infrav1.HarvesterMachine{},
+		HubAfterMutation: deleteSpokeData,
+		Spoke:            &HarvesterMachine{},
+		FuzzerFuncs:      []fuzzer.FuzzerFuncs{fuzzFuncs},
+	}))
+
+	t.Run("for HarvesterMachineTemplate", utilconversion.FuzzTestFunc(utilconversion.FuzzTestFuncInput{
+		Scheme:           scheme,
+		Hub:              &infrav1.HarvesterMachineTemplate{},
+		HubAfterMutation: deleteSpokeData,
+		Spoke:            &HarvesterMachineTemplate{},
+		FuzzerFuncs:      []fuzzer.FuzzerFuncs{fuzzFuncs},
+	}))
+}
+
+// deleteSpokeData removes the v1alpha1 data preserved in the hub on up-conversion.
+func deleteSpokeData(hub conversion.Hub) {
+	delete(hub.(metav1.Object).GetAnnotations(), utilconversion.DataAnnotation)
+}
+
+func fuzzFuncs(_ runtimeserializer.CodecFactory) []interface{} {
+	return []interface{}{
+		quantityFuzzer,
+		spokeHarvesterMachineSpecFuzzer,
+		spokeLoadBalancerConfigFuzzer,
+		hubObjectReferenceFuzzer,
+		hubIPAMConfigFuzzer,
+		hubCPUFuzzer,
+	}
+}
+
+func quantityFuzzer(in *resource.Quantity, c fuzz.Continue) {
+	*in = *resource.NewQuantity(c.Int63n(1<<40), resource.BinarySI)
+}
+
+func spokeHarvesterMachineSpecFuzzer(in *HarvesterMachineSpec, c fuzz.Continue) {
+	c.FuzzNoCustom(in)
+
+	// v1alpha2 stores the CPU as an int32 and the memory as a quantity, so the memory must be valid.
+	in.CPU = int(c.Int31())
+
+	in.Memory = ""
+	if c.RandBool() {
+		suffixes := []string{"", "k", "M", "G", "Ki", "Mi", "Gi"}
+		in.Memory = fmt.Sprintf("%d%s", c.Int63n(1<<20), suffixes[c.Intn(len(suffixes))])
+	}
+}
+
+func spokeLoadBalancerConfigFuzzer(in *LoadBalancerConfig, c fuzz.Continue) {
+	c.FuzzNoCustom(in)
+
+	// Only the valid combinations of IPAM fields can be represented in v1alpha2, the IpPoolRef of an IpPool is kept in the conversion data.
+	switch c.Intn(3) {
+	case 0:
+		in.IPAMType = DHCP
+		in.IpPoolRef = ""
+		in.IpPool = IpPool{}
+	case 1:
+		in.IPAMType = POOL
+	default:
+		in.IPAMType = POOL
+		in.IpPool = IpPool{}
+	}
+}
+
+func hubObjectReferenceFuzzer(in *infrav1.ObjectReference, c fuzz.Continue) {
+	// v1alpha1 references are strings with the format "namespace/name" or just "name".
+	in.Namespace = ""
+	if c.RandBool() {
+		in.Namespace = fmt.Sprintf("namespace-%d", c.Uint32())
+	}
+
+	in.Name = fmt.Sprintf("name-%d", c.Uint32())
+}
+
+func hubIPAMConfigFuzzer(in *infrav1.IPAMConfig, c fuzz.Continue) {
+	c.FuzzNoCustom(in)
+
+	// Only the member matching the type can be set.
+	switch c.Intn(4) {
+	case 0:
+		in.Type = infrav1.IPAMTypeDHCP
+		in.IPPool = nil
+		in.IPPoolRef = nil
+		in.PoolRef = nil
+	case 1:
+		in.Type = infrav1.IPAMTypeIPPool
+		in.IPPoolRef = nil
+		in.PoolRef = nil
+
+		if in.IPPool == nil {
+			in.IPPool = &infrav1.IPPool{}
+			c.Fuzz(in.IPPool)
+		}
+	case 2:
+		in.Type = infrav1.IPAMTypeIPPoolRef
+		in.IPPool = nil
+		in.PoolRef = nil
+
+		if in.IPPoolRef == nil {
+			in.IPPoolRef = &infrav1.IPPoolReference{}
+			c.Fuzz(in.IPPoolRef)
+		}
+	default:
+		in.Type = infrav1.IPAMTypeIPAddressClaim
+		in.IPPool = nil
+		in.IPPoolRef = nil
+
+		if in.PoolRef == nil {
+			in.PoolRef = &corev1.TypedLocalObjectReference{}
+			c.Fuzz(in.PoolRef)
+		}
+	}
+}
+
+func hubCPUFuzzer(in *infrav1.CPU, c fuzz.Continue) {
+	c.FuzzNoCustom(in)
+
+	// Keep the topology small enough for the number of vCPUs to fit in the v1alpha1 field.
+	in.Cores = c.Int31n(64) + 1
+	in.Sockets = c.Int31n(4)
+	in.Threads = c.Int31n(4)
+}
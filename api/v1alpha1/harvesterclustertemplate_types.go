@@ -0,0 +1,64 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// HarvesterClusterTemplateSpec defines the desired state of HarvesterClusterTemplate.
+type HarvesterClusterTemplateSpec struct {
+	// Template is the HarvesterClusterTemplate template
+	Template HarvesterClusterTemplateResource `json:"template"`
+}
+
+// HarvesterClusterTemplateResource describes the data needed to create a HarvesterCluster from a template.
+type HarvesterClusterTemplateResource struct {
+	// Standard object's metadata.
+	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata.
+	// +optional
+	ObjectMeta clusterv1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the specification of the desired behavior of the cluster.
+	Spec HarvesterClusterSpec `json:"spec"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=harvesterclustertemplates,scope=Namespaced,categories=cluster-api
+
+// HarvesterClusterTemplate is the Schema for the harvesterclustertemplates API.
+type HarvesterClusterTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HarvesterClusterTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// HarvesterClusterTemplateList contains a list of HarvesterClusterTemplate.
+type HarvesterClusterTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HarvesterClusterTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HarvesterClusterTemplate{}, &HarvesterClusterTemplateList{})
+}
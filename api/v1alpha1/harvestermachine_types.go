@@ -29,6 +29,9 @@ const (
 	// MachineFinalizer allows ReconcileHarvesterMachine to clean up resources associated with HarvesterMachine before.
 	// removing it from the apiserver.
 	MachineFinalizer = "harvestermachine.infrastructure.cluster.x-k8s.io"
+
+	// DefaultVolumeSize is the size given to volumes which do not define a VolumeSize.
+	DefaultVolumeSize = "40Gi"
 )
 
 const (
@@ -105,9 +108,16 @@ type Volume struct {
 }
 
 // VolumeType is an enum string. It can only take the values: "storageClass" or "image".
-// +kubebuilder:Validation:Enum:=storageClass,image
+// +kubebuilder:validation:Enum:=storageClass;image
 type VolumeType string
 
+const (
+	// VolumeTypeStorageClass is a volume backed by a PVC using a StorageClass in Harvester.
+	VolumeTypeStorageClass VolumeType = "storageClass"
+	// VolumeTypeImage is a volume backed by a PVC created from a VM image in Harvester.
+	VolumeTypeImage VolumeType = "image"
+)
+
 // HarvesterMachineStatus defines the observed state of HarvesterMachine.
 type HarvesterMachineStatus struct {
 	// Ready is true when the provider resource is ready.
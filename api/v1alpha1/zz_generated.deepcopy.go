@@ -26,21 +26,6 @@ import (
 	"sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *UpdateCloudProviderConfig) DeepCopyInto(out *UpdateCloudProviderConfig) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GenerateCloudProviderConfig.
-func (in *UpdateCloudProviderConfig) DeepCopy() *UpdateCloudProviderConfig {
-	if in == nil {
-		return nil
-	}
-	out := new(UpdateCloudProviderConfig)
-	in.DeepCopyInto(out)
-	return out
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HarvesterCluster) DeepCopyInto(out *HarvesterCluster) {
 	*out = *in
@@ -191,6 +176,14 @@ func (in *HarvesterClusterTemplateList) DeepCopy() *HarvesterClusterTemplateList
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HarvesterClusterTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HarvesterClusterTemplateResource) DeepCopyInto(out *HarvesterClusterTemplateResource) {
 	*out = *in
@@ -503,6 +496,21 @@ func (in *SecretKey) DeepCopy() *SecretKey {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateCloudProviderConfig) DeepCopyInto(out *UpdateCloudProviderConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateCloudProviderConfig.
+func (in *UpdateCloudProviderConfig) DeepCopy() *UpdateCloudProviderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateCloudProviderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Volume) DeepCopyInto(out *Volume) {
 	*out = *in
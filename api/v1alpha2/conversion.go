@@ -0,0 +1,41 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+// Hub marks HarvesterCluster as a conversion hub.
+func (*HarvesterCluster) Hub() {}
+
+// Hub marks HarvesterClusterList as a conversion hub.
+func (*HarvesterClusterList) Hub() {}
+
+// Hub marks HarvesterClusterTemplate as a conversion hub.
+func (*HarvesterClusterTemplate) Hub() {}
+
+// Hub marks HarvesterClusterTemplateList as a conversion hub.
+func (*HarvesterClusterTemplateList) Hub() {}
+
+// Hub marks HarvesterMachine as a conversion hub.
+func (*HarvesterMachine) Hub() {}
+
+// Hub marks HarvesterMachineList as a conversion hub.
+func (*HarvesterMachineList) Hub() {}
+
+// Hub marks HarvesterMachineTemplate as a conversion hub.
+func (*HarvesterMachineTemplate) Hub() {}
+
+// Hub marks HarvesterMachineTemplateList as a conversion hub.
+func (*HarvesterMachineTemplateList) Hub() {}
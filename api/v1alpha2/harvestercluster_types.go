@@ -0,0 +1,562 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// ClusterFinalizer allows ReconcileHarvesterCluster to clean up resources associated with HarvesterCluster before.
+	ClusterFinalizer = "harvester.infrastructure.cluster.x-k8s.io"
+)
+
+const (
+	// LoadBalancerReadyCondition documents the status of the load balancer in Harvester.
+	LoadBalancerReadyCondition clusterv1.ConditionType = "LoadBalancerReady"
+	// LoadBalancerNotReadyReason documents the reason why the load balancer is not ready.
+	LoadBalancerNotReadyReason = "The Load Balancer is not ready"
+	// LoadBalancerNoBackendMachineReason documents that there are no machines matching the load balancer configuration.
+	LoadBalancerNoBackendMachineReason = "There are no machines matching the load balancer configuration"
+	// LoadBalancerHealthcheckFailedReason documents the reason why the load balancer is not ready.
+	LoadBalancerHealthcheckFailedReason = "The healthcheck for the load balancer failed"
+	// LoadBalancerAddressMismatchReason documents that the load balancer did not get the address reserved for it in the IP Pool.
+	LoadBalancerAddressMismatchReason = "The Load Balancer did not get the address reserved in the IP Pool"
+	// LoadBalancerSpecInSyncCondition documents if the spec of the load balancer in Harvester matches the HarvesterCluster.
+	LoadBalancerSpecInSyncCondition clusterv1.ConditionType = "LoadBalancerSpecInSync"
+	// LoadBalancerSpecDriftedReason documents that the spec of the load balancer in Harvester differs and could not be updated.
+	LoadBalancerSpecDriftedReason = "The Load Balancer spec in Harvester differs from the desired one"
+	// LoadBalancerIPAMDriftedReason documents that the IPAM of the load balancer in Harvester differs, which cannot be changed.
+	LoadBalancerIPAMDriftedReason = "The Load Balancer IPAM in Harvester differs from the desired one and cannot be changed"
+	// CustomIPPoolCreatedCondition documents if a custom IP Pool was created in Harvester.
+	CustomIPPoolCreatedCondition clusterv1.ConditionType = "CustomIPPoolCreated"
+	// CustomPoolCreationInHarvesterFailedReason documents the reason why a custom pool was unable to be created.
+	CustomPoolCreationInHarvesterFailedReason = "The custom Pool creation in Harvester failed"
+	// CustomIPPoolCreatedSuccessfullyReason documents the reason why Custom IP Pool was created.
+	CustomIPPoolCreatedSuccessfullyReason = "Custom IP Pool was successfully created"
+
+	// KubeVIPAddressReservedCondition documents if the virtual IP announced by kube-vip was reserved for the control plane endpoint.
+	KubeVIPAddressReservedCondition clusterv1.ConditionType = "KubeVIPAddressReserved"
+	// KubeVIPAddressReservationFailedReason documents the reason why the virtual IP could not be reserved.
+	KubeVIPAddressReservationFailedReason = "The virtual IP for kube-vip could not be reserved"
+
+	// ControlPlaneEndpointReachableCondition documents if the external control plane endpoint accepts connections.
+	ControlPlaneEndpointReachableCondition clusterv1.ConditionType = "ControlPlaneEndpointReachable"
+	// ControlPlaneEndpointUnreachableReason documents the reason why the external control plane endpoint is not reachable.
+	ControlPlaneEndpointUnreachableReason = "The control plane endpoint is not reachable"
+	// InvalidControlPlaneEndpointReason documents that the external control plane endpoint is not set or is invalid.
+	InvalidControlPlaneEndpointReason = "The control plane endpoint is not set or is invalid"
+
+	// CloudProviderConfigReadyCondition documents the status of the cloud provider configuration in Harvester.
+	CloudProviderConfigReadyCondition clusterv1.ConditionType = "CloudProviderConfigReady"
+	// CloudProviderConfigNotReadyReason documents the reason why the cloud provider configuration is not ready.
+	CloudProviderConfigNotReadyReason = "The Cloud Provider configuration is not ready"
+	// CloudProviderConfigGenerationFailedReason documents the reason why the cloud provider configuration generation failed.
+	CloudProviderConfigGenerationFailedReason = "The Cloud Provider configuration generation failed"
+	// CloudProviderConfigGeneratedSuccessfullyReason documents the reason why the cloud provider configuration was generated.
+	CloudProviderConfigGeneratedSuccessfullyReason = "The Cloud Provider configuration was generated successfully"
+)
+
+const (
+	// DefaultAPIServerPort is the default port of the API server, on the load balancer and on the control plane machines.
+	DefaultAPIServerPort = 6443
+	// DefaultHealthCheckSuccessThreshold is the default number of successful checks for a machine to be considered healthy.
+	DefaultHealthCheckSuccessThreshold = 1
+	// DefaultHealthCheckFailureThreshold is the default number of failed checks for a machine to be considered unhealthy.
+	DefaultHealthCheckFailureThreshold = 3
+	// DefaultHealthCheckPeriodSeconds is the default number of seconds between two health checks.
+	DefaultHealthCheckPeriodSeconds = 30
+	// DefaultHealthCheckTimeoutSeconds is the default number of seconds after which a health check times out.
+	DefaultHealthCheckTimeoutSeconds = 60
+	// DefaultKubeVIPImage is the default image of kube-vip.
+	DefaultKubeVIPImage = "ghcr.io/kube-vip/kube-vip:v0.8.7"
+	// DefaultKubeVIPManifestPath is the default path of the kube-vip static pod manifest, in the manifests directory of kubeadm.
+	DefaultKubeVIPManifestPath = "/etc/kubernetes/manifests/kube-vip.yaml"
+	// DefaultKubeVIPKubeconfigPath is the default path of the kubeconfig used by kube-vip, the admin kubeconfig of kubeadm.
+	DefaultKubeVIPKubeconfigPath = "/etc/kubernetes/admin.conf"
+)
+
+const (
+	// InitMachineCreatedCondition documents the status of the init machine in Harvester.
+	InitMachineCreatedCondition clusterv1.ConditionType = "InitMachineCreated"
+	// InitMachineNotYetCreatedReason documents the reason why the init machine is not ready.
+	InitMachineNotYetCreatedReason = "Init Machine not yet created"
+)
+
+// HarvesterClusterSpec defines the desired state of HarvesterCluster.
+type HarvesterClusterSpec struct {
+	// Server is the url to connect to Harvester.
+	// +optional
+	Server string `json:"server,omitempty"`
+
+	// IdentitySecret is the name of the Secret containing HarvesterKubeConfig file.
+	IdentitySecret SecretKey `json:"identitySecret"`
+
+	// LoadBalancerConfig describes how the load balancer should be created in Harvester.
+	LoadBalancerConfig LoadBalancerConfig `json:"loadBalancerConfig"`
+
+	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane.
+	// It is set by the controller, unless the load balancer type is External, where it must be set beforehand.
+	// +optional
+	ControlPlaneEndpoint clusterv1.APIEndpoint `json:"controlPlaneEndpoint,omitempty"`
+
+	// TargetNamespace is the namespace on the Harvester cluster where VMs, Load Balancers, etc. should be created.
+	TargetNamespace string `json:"targetNamespace"`
+
+	// UpdateCloudProviderConfig if not empty, will trigger the generation of the cloud provider configuration.
+	// It needs a reference to a ConfigMap containing the cloud provider deployment manifests, that are used by a ClusterResourceSet.
+	// +optional
+	UpdateCloudProviderConfig UpdateCloudProviderConfig `json:"updateCloudProviderConfig,omitempty"`
+}
+
+// SecretKey is a reference to a Secret which stores Identity information for the Target Harvester Cluster.
+type SecretKey struct {
+	// Namespace is the namespace in which the required Identity Secret should be found.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the required Identity Secret.
+	Name string `json:"name"`
+}
+
+// LoadBalancerConfig describes how the load balancer should be created in Harvester.
+type LoadBalancerConfig struct {
+	// Type is the way the control plane endpoint is provided.
+	// Defaults to Harvester.
+	// +optional
+	Type LoadBalancerType `json:"type,omitempty"`
+
+	// IPAM is the configuration of IP addressing for the control plane load balancer.
+	IPAM IPAMConfig `json:"ipam"`
+
+	// Listeners is a list of listeners that should be created on the load balancer.
+	// +optional
+	Listeners []Listener `json:"listeners,omitempty"`
+
+	// Description is a description of the load balancer that should be created.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// APIServerPort is the port the load balancer listens on for the API server, which is also the port of the control plane endpoint.
+	// Defaults to 6443.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	APIServerPort int32 `json:"apiServerPort,omitempty"`
+
+	// BackendPort is the port the API server listens on in the control plane machines.
+	// Defaults to 6443.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	BackendPort int32 `json:"backendPort,omitempty"`
+
+	// HealthCheck describes how the load balancer checks the health of the control plane machines.
+	// +optional
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+
+	// KubeVIP is the configuration of the kube-vip static pod announcing the control plane endpoint.
+	// It can only be set if Type is KubeVIP.
+	// +optional
+	KubeVIP *KubeVIPConfig `json:"kubeVIP,omitempty"`
+
+	// IPFamilies are the IP families of the control plane load balancer, IPv4 and/or IPv6.
+	// The first one is the primary IP family: its address is allocated according to the IPAM configuration,
+	// and is used as the control plane endpoint. Defaults to IPv4.
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+
+	// IPFamilyPolicy is the IP family policy of the load balancer Service in Harvester.
+	// Defaults to SingleStack with one IP family, and to PreferDualStack with two.
+	// +kubebuilder:validation:Enum:=SingleStack;PreferDualStack;RequireDualStack
+	// +optional
+	IPFamilyPolicy corev1.IPFamilyPolicy `json:"ipFamilyPolicy,omitempty"`
+}
+
+// LoadBalancerType describes the way the control plane endpoint is provided.
+// +kubebuilder:validation:Enum:=Harvester;KubeVIP;External
+type LoadBalancerType string
+
+const (
+	// LoadBalancerTypeHarvester provides the control plane endpoint with a load balancer in Harvester.
+	LoadBalancerTypeHarvester LoadBalancerType = "Harvester"
+	// LoadBalancerTypeKubeVIP provides the control plane endpoint with a virtual IP announced by kube-vip on the control plane machines.
+	// It does not need the load balancer of Harvester, listeners and health checks are not supported.
+	LoadBalancerTypeKubeVIP LoadBalancerType = "KubeVIP"
+	// LoadBalancerTypeExternal uses the control plane endpoint set in the HarvesterCluster, e.g. a DNS name or the VIP of
+	// a load balancer outside of Harvester. Nothing is created in Harvester for it, listeners and health checks are not supported.
+	LoadBalancerTypeExternal LoadBalancerType = "External"
+)
+
+// KubeVIPConfig is the configuration of the kube-vip static pod running on the control plane machines.
+// The virtual IP is reserved according to the IPAM configuration. With DHCP, kube-vip gets the virtual IP from the DHCP server itself
+// and registers Hostname with dynamic DNS, Hostname is then used as the control plane endpoint.
+type KubeVIPConfig struct {
+	// Image is the image of kube-vip.
+	// Defaults to ghcr.io/kube-vip/kube-vip:v0.8.7.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Interface is the interface of the control plane machines the virtual IP is announced on.
+	// If empty, kube-vip uses the interface of the default route.
+	// +optional
+	Interface string `json:"interface,omitempty"`
+
+	// Hostname is the hostname registered with dynamic DNS when the virtual IP is obtained with DHCP.
+	// It must be set if, and only if, the IPAM type is DHCP.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// ManifestPath is the path of the static pod manifest on the control plane machines.
+	// Defaults to /etc/kubernetes/manifests/kube-vip.yaml, RKE2 uses /var/lib/rancher/rke2/agent/pod-manifests/kube-vip.yaml.
+	// +optional
+	ManifestPath string `json:"manifestPath,omitempty"`
+
+	// KubeconfigPath is the path of the kubeconfig kube-vip uses for leader election on the control plane machines.
+	// Defaults to /etc/kubernetes/admin.conf, RKE2 uses /etc/rancher/rke2/rke2.yaml.
+	// +optional
+	KubeconfigPath string `json:"kubeconfigPath,omitempty"`
+}
+
+// HealthCheck describes how the load balancer checks the health of the control plane machines.
+type HealthCheck struct {
+	// Port is the port checked on the control plane machines. Defaults to the backend port of the API server.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// SuccessThreshold is the number of consecutive successful checks for a machine to be considered healthy.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SuccessThreshold int32 `json:"successThreshold,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed checks for a machine to be considered unhealthy.
+	// Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+
+	// PeriodSeconds is the number of seconds between two checks.
+	// Defaults to 30.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+
+	// TimeoutSeconds is the number of seconds after which a check times out.
+	// Defaults to 60.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// IPAMType describes the way the load balancer IP should be obtained.
+// +kubebuilder:validation:Enum:=DHCP;IPPool;IPPoolRef;IPAddressClaim
+type IPAMType string
+
+const (
+	// IPAMTypeDHCP gets the load balancer IP from a DHCP server on the VM network.
+	IPAMTypeDHCP IPAMType = "DHCP"
+	// IPAMTypeIPPool gets the load balancer IP from a new IP Pool created in Harvester for the cluster.
+	IPAMTypeIPPool IPAMType = "IPPool"
+	// IPAMTypeIPPoolRef gets the load balancer IP from an existing IP Pool in Harvester.
+	IPAMTypeIPPoolRef IPAMType = "IPPoolRef"
+	// IPAMTypeIPAddressClaim gets the load balancer IP from a Cluster API IPAM provider through an IPAddressClaim.
+	IPAMTypeIPAddressClaim IPAMType = "IPAddressClaim"
+)
+
+// IPAMConfig is the configuration of IP addressing for the control plane load balancer.
+// Only the member matching the Type can be set.
+// +union
+type IPAMConfig struct {
+	// Type is the way the load balancer IP should be obtained.
+	// +unionDiscriminator
+	Type IPAMType `json:"type"`
+
+	// IPPool defines a new IP Pool that will be created in Harvester.
+	// It must be set if, and only if, Type is IPPool.
+	// +optional
+	IPPool *IPPool `json:"ipPool,omitempty"`
+
+	// IPPoolRef is a reference to an existing IP Pool in Harvester.
+	// It must be set if, and only if, Type is IPPoolRef.
+	// +optional
+	IPPoolRef *IPPoolReference `json:"ipPoolRef,omitempty"`
+
+	// PoolRef is a reference to the pool of a Cluster API IPAM provider, e.g. an InClusterIPPool,
+	// an IPAddressClaim is created against.
+	// It must be set if, and only if, Type is IPAddressClaim.
+	// +optional
+	PoolRef *corev1.TypedLocalObjectReference `json:"poolRef,omitempty"`
+}
+
+// IPPoolReference is a reference to an IP Pool in Harvester. IP Pools are cluster-scoped.
+type IPPoolReference struct {
+	// Name is the name of the IP Pool in Harvester.
+	Name string `json:"name"`
+}
+
+// IPPool is a description of a new IP Pool to be created in Harvester.
+type IPPool struct {
+	// VMNetwork is a reference to an existing VM Network in Harvester where the IP Pool should exist.
+	VMNetwork ObjectReference `json:"vmNetwork"`
+
+	// Subnet is a string describing the subnet that should be used by the IP Pool, it should have the CIDR Format of an IPv4 or IPv6 Address.
+	// e.g. 172.17.1.0/24 or fd00:172:17:1::/64.
+	Subnet string `json:"subnet"`
+
+	// Gateway is the IP Address that should be used by the Gateway on the Subnet. It should be a valid address inside the subnet.
+	// e.g. 172.17.1.1.
+	Gateway string `json:"gateway"`
+
+	// RangeStart is the first IP Address that should be used by the IP Pool.
+	// +optional
+	RangeStart string `json:"rangeStart,omitempty"`
+
+	// RangeEnd is the last IP Address that should be used by the IP Pool.
+	// +optional
+	RangeEnd string `json:"rangeEnd,omitempty"`
+
+	// AdditionalRanges are ranges of addresses of the IP Pool besides the one of Subnet, e.g. the IPv6 range of a dual-stack network.
+	// +optional
+	AdditionalRanges []IPPoolRange `json:"additionalRanges,omitempty"`
+
+	// Exclude is a list of IP addresses or CIDRs inside the ranges of the IP Pool which must not be allocated.
+	// The ranges are split around them when the IP Pool is created in Harvester.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Scope restricts the projects, namespaces and guest clusters of Harvester which can select the IP Pool.
+	// If empty, the IP Pool can be selected by any load balancer on the VM network.
+	// +optional
+	Scope []IPPoolScope `json:"scope,omitempty"`
+}
+
+// IPPoolScope is a set of projects, namespaces and guest clusters of Harvester which can select an IP Pool.
+// An empty field matches everything.
+type IPPoolScope struct {
+	// Project is the ID of a project in Rancher, e.g. local:p-abcde.
+	// +optional
+	Project string `json:"project,omitempty"`
+
+	// Namespace is a namespace in Harvester.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// GuestCluster is the name of a guest cluster running on Harvester.
+	// +optional
+	GuestCluster string `json:"guestCluster,omitempty"`
+}
+
+// IPPoolRange is a range of addresses of an IP Pool in Harvester.
+type IPPoolRange struct {
+	// Subnet is the CIDR of the range, e.g. 172.17.2.0/24 or fd00:172:17:1::/64.
+	Subnet string `json:"subnet"`
+
+	// Gateway is the IP Address that should be used by the Gateway on the Subnet. It should be a valid address inside the subnet.
+	Gateway string `json:"gateway"`
+
+	// RangeStart is the first IP Address of the range.
+	// +optional
+	RangeStart string `json:"rangeStart,omitempty"`
+
+	// RangeEnd is the last IP Address of the range.
+	// +optional
+	RangeEnd string `json:"rangeEnd,omitempty"`
+}
+
+// Listener is a description of a new Listener to be created on the Load Balancer.
+type Listener struct {
+	// Name is the name of the listener.
+	Name string `json:"name"`
+
+	// Port is the port that the listener should listen on.
+	Port int32 `json:"port"`
+
+	// Protocol is the protocol that the listener should use, either TCP or UDP.
+	// +kubebuilder:validation:Enum:=TCP;UDP
+	Protocol corev1.Protocol `json:"protocol"`
+
+	// TargetPort is the port that the listener should forward traffic to.
+	BackendPort int32 `json:"backendPort"`
+}
+
+// UpdateCloudProviderConfig is a reference to a ConfigMap containing the cloud provider deployment manifests.
+// If you want to generate the cloud provider configuration, the cloud config will need a Harvester Endpoint. This is provider by `HarvesterCluster.Spec.ControlPlaneEndpoint`.
+// Beware this does not work with an endpoint that uses a Rancher proxy!
+type UpdateCloudProviderConfig struct {
+	// ManifestsConfigMapNamespace is the namespace in which the required ConfigMap should be found.
+	ManifestsConfigMapNamespace string `json:"manifestsConfigMapNamespace"`
+
+	// ManifestsConfigMapName is the name of the required ConfigMap.
+	ManifestsConfigMapName string `json:"manifestsConfigMapName"`
+
+	// ManifestsConfigMapKey is the key in the ConfigMap that contains the cloud provider deployment manifests.
+	ManifestsConfigMapKey string `json:"manifestsConfigMapKey"`
+
+	// CloudConfigCredentialsSecretName is the name of the secret containing the cloud provider credentials.
+	CloudConfigCredentialsSecretName string `json:"cloudConfigCredentialsSecretName"`
+
+	// CloudConfigCredentialsSecretKey is the key in the secret that contains the cloud provider credentials.
+	CloudConfigCredentialsSecretKey string `json:"cloudConfigCredentialsSecretKey"`
+}
+
+// HarvesterClusterStatus defines the observed state of HarvesterCluster.
+type HarvesterClusterStatus struct {
+	// Ready describes if the Harvester Cluster can be considered ready for machine creation.
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+
+	// FailureReason is the short name for the reason why a failure might be happening that makes the cluster not ready.
+	// +optional
+	FailureReason string `json:"failureReason,omitempty"`
+	// FailureMessage is a full error message dump of the above failureReason.
+	// +optional
+	FailureMessage string `json:"failureMessage,omitempty"`
+
+	// Conditions defines current service state of the Harvester cluster.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// LoadBalancerAddresses are the addresses of the control plane load balancer, at most one per IP family.
+	// +optional
+	LoadBalancerAddresses []LoadBalancerAddress `json:"loadBalancerAddresses,omitempty"`
+
+	// AllocatedAddress is the address allocated to the control plane from an IP Pool in Harvester.
+	// It is released when the HarvesterCluster is deleted, so that shared IP Pools get it back.
+	// +optional
+	AllocatedAddress *IPPoolAllocation `json:"allocatedAddress,omitempty"`
+
+	// LoadBalancer is the load balancer created in Harvester for the control plane.
+	// +optional
+	LoadBalancer *LoadBalancerReference `json:"loadBalancer,omitempty"`
+
+	// PlaceholderService is the Service created in Harvester for the control plane endpoint
+	// until the first control plane machine exists.
+	// +optional
+	PlaceholderService *HarvesterResourceReference `json:"placeholderService,omitempty"`
+
+	// IPPool is the name of the IP Pool created in Harvester for the HarvesterCluster.
+	// Referenced IP Pools are not recorded, because they are not deleted with the HarvesterCluster.
+	// +optional
+	IPPool string `json:"ipPool,omitempty"`
+
+	// CloudProvider holds the resources created in Harvester for the cloud provider of the workload cluster.
+	// +optional
+	CloudProvider *CloudProviderStatus `json:"cloudProvider,omitempty"`
+
+	// HarvesterVersion is the version of Harvester hosting the workload cluster.
+	// +optional
+	HarvesterVersion string `json:"harvesterVersion,omitempty"`
+
+	// ObservedGeneration is the latest generation of the HarvesterCluster reconciled by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// HarvesterResourceReference is a reference to a resource in Harvester.
+type HarvesterResourceReference struct {
+	// Name is the name of the resource.
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the resource.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// LoadBalancerReference is a reference to the load balancer created in Harvester for the control plane.
+type LoadBalancerReference struct {
+	// Name is the name of the load balancer.
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the load balancer.
+	Namespace string `json:"namespace"`
+
+	// Address is the address of the load balancer, once Harvester allocated it.
+	// +optional
+	Address string `json:"address,omitempty"`
+}
+
+// CloudProviderStatus holds the resources created in Harvester for the cloud provider of the workload cluster.
+type CloudProviderStatus struct {
+	// ServiceAccount is the ServiceAccount used by the cloud provider to access Harvester.
+	ServiceAccount HarvesterResourceReference `json:"serviceAccount"`
+
+	// ClusterRoleBinding is the name of the ClusterRoleBinding granting the cloud provider role to the ServiceAccount.
+	ClusterRoleBinding string `json:"clusterRoleBinding"`
+}
+
+// IPPoolAllocation is an address allocated from an IP Pool in Harvester.
+type IPPoolAllocation struct {
+	// IPPool is the name of the IP Pool in Harvester the address is allocated from.
+	IPPool string `json:"ipPool"`
+
+	// Address is the allocated IP address.
+	Address string `json:"address"`
+}
+
+// LoadBalancerAddress is an address of the control plane load balancer.
+type LoadBalancerAddress struct {
+	// IPFamily is the IP family of the address.
+	IPFamily corev1.IPFamily `json:"ipFamily"`
+
+	// Address is the IP address.
+	Address string `json:"address"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Cluster infrastructure is ready for HarvesterMachine"
+// +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".spec.server",description="Server is the address of the Harvester endpoint"
+// +kubebuilder:printcolumn:name="ControlPlaneEndpoint",type="string",JSONPath=".spec.controlPlaneEndpoint[0]",description="API Endpoint",priority=1
+
+// HarvesterCluster is the Schema for the harvesterclusters API.
+type HarvesterCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HarvesterClusterSpec   `json:"spec"`
+	Status HarvesterClusterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// HarvesterClusterList contains a list of HarvesterCluster.
+type HarvesterClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HarvesterCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HarvesterCluster{}, &HarvesterClusterList{})
+}
+
+// GetConditions returns the set of conditions for this object.
+func (m *HarvesterCluster) GetConditions() clusterv1.Conditions {
+	return m.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (m *HarvesterCluster) SetConditions(conditions clusterv1.Conditions) {
+	m.Status.Conditions = conditions
+}
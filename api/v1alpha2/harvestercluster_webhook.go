@@ -0,0 +1,683 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// apiServerListenerName is the name of the listener created by the controller for the API server.
+	// It cannot be used by user-defined listeners.
+	apiServerListenerName = "api-server"
+
+	// maxIPFamilies is the number of IP families of a dual-stack load balancer.
+	maxIPFamilies = 2
+)
+
+// SetupWebhookWithManager sets up and registers the webhooks for HarvesterCluster with the manager.
+func (r *HarvesterCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1alpha2-harvestercluster,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=harvesterclusters,verbs=create;update,versions=v1alpha2,name=default.harvestercluster.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &HarvesterCluster{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (r *HarvesterCluster) Default() {
+	if r.Spec.IdentitySecret.Namespace == "" {
+		r.Spec.IdentitySecret.Namespace = r.Namespace
+	}
+
+	defaultHarvesterClusterSpec(&r.Spec)
+}
+
+// defaultHarvesterClusterSpec sets the default values of a HarvesterClusterSpec which do not depend on the object metadata.
+// It is shared by HarvesterCluster and HarvesterClusterTemplate.
+func defaultHarvesterClusterSpec(spec *HarvesterClusterSpec) {
+	if spec.LoadBalancerConfig.Type == "" {
+		spec.LoadBalancerConfig.Type = LoadBalancerTypeHarvester
+	}
+
+	if spec.LoadBalancerConfig.IPAM.Type == "" {
+		spec.LoadBalancerConfig.IPAM.Type = IPAMTypeDHCP
+	}
+
+	if spec.LoadBalancerConfig.Type == LoadBalancerTypeKubeVIP {
+		if spec.LoadBalancerConfig.KubeVIP == nil {
+			spec.LoadBalancerConfig.KubeVIP = &KubeVIPConfig{}
+		}
+
+		defaultKubeVIPConfig(spec.LoadBalancerConfig.KubeVIP)
+	}
+
+	if spec.LoadBalancerConfig.APIServerPort == 0 {
+		spec.LoadBalancerConfig.APIServerPort = DefaultAPIServerPort
+	}
+
+	if spec.LoadBalancerConfig.BackendPort == 0 {
+		spec.LoadBalancerConfig.BackendPort = DefaultAPIServerPort
+	}
+
+	if len(spec.LoadBalancerConfig.IPFamilies) == 0 {
+		spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol}
+	}
+
+	if spec.LoadBalancerConfig.IPFamilyPolicy == "" {
+		spec.LoadBalancerConfig.IPFamilyPolicy = corev1.IPFamilyPolicySingleStack
+		if len(spec.LoadBalancerConfig.IPFamilies) > 1 {
+			spec.LoadBalancerConfig.IPFamilyPolicy = corev1.IPFamilyPolicyPreferDualStack
+		}
+	}
+
+	if spec.LoadBalancerConfig.HealthCheck == nil {
+		spec.LoadBalancerConfig.HealthCheck = &HealthCheck{}
+	}
+
+	defaultHealthCheck(spec.LoadBalancerConfig.HealthCheck, spec.LoadBalancerConfig.BackendPort)
+
+	for i := range spec.LoadBalancerConfig.Listeners {
+		listener := &spec.LoadBalancerConfig.Listeners[i]
+
+		if listener.Protocol == "" {
+			listener.Protocol = corev1.ProtocolTCP
+		}
+
+		if listener.BackendPort == 0 {
+			listener.BackendPort = listener.Port
+		}
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1alpha2-harvestercluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=harvesterclusters,verbs=create;update,versions=v1alpha2,name=validation.harvestercluster.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &HarvesterCluster{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *HarvesterCluster) ValidateCreate() (admission.Warnings, error) {
+	allErrs := validateHarvesterClusterSpec(r.Spec, field.NewPath("spec"))
+
+	return nil, aggregateObjErrors(GroupVersion.WithKind("HarvesterCluster").GroupKind(), r.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *HarvesterCluster) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	oldCluster, ok := old.(*HarvesterCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a HarvesterCluster but got a %T", old)
+	}
+
+	allErrs := validateHarvesterClusterSpec(r.Spec, field.NewPath("spec"))
+
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(
+		r.Spec.TargetNamespace, oldCluster.Spec.TargetNamespace, field.NewPath("spec", "targetNamespace"))...)
+
+	// The API server port is the port of the control plane endpoint, which cannot change once the cluster is provisioned.
+	oldSpec := oldCluster.Spec.DeepCopy()
+	defaultHarvesterClusterSpec(oldSpec)
+
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(
+		r.Spec.LoadBalancerConfig.APIServerPort, oldSpec.LoadBalancerConfig.APIServerPort,
+		field.NewPath("spec", "loadBalancerConfig", "apiServerPort"))...)
+
+	// An external control plane endpoint is given by the user, the other ones are set by the controller.
+	if r.Spec.LoadBalancerConfig.Type == LoadBalancerTypeExternal {
+		allErrs = append(allErrs, apivalidation.ValidateImmutableField(
+			r.Spec.ControlPlaneEndpoint, oldCluster.Spec.ControlPlaneEndpoint, field.NewPath("spec", "controlPlaneEndpoint"))...)
+	}
+
+	// The primary IP family is the one of the control plane endpoint.
+	if len(r.Spec.LoadBalancerConfig.IPFamilies) > 0 && len(oldSpec.LoadBalancerConfig.IPFamilies) > 0 {
+		allErrs = append(allErrs, apivalidation.ValidateImmutableField(
+			r.Spec.LoadBalancerConfig.IPFamilies[0], oldSpec.LoadBalancerConfig.IPFamilies[0],
+			field.NewPath("spec", "loadBalancerConfig", "ipFamilies").Index(0))...)
+	}
+
+	// Switching between the load balancer types would leave the control plane endpoint without anything serving it.
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(
+		r.Spec.LoadBalancerConfig.Type, oldSpec.LoadBalancerConfig.Type,
+		field.NewPath("spec", "loadBalancerConfig", "type"))...)
+
+	return nil, aggregateObjErrors(GroupVersion.WithKind("HarvesterCluster").GroupKind(), r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *HarvesterCluster) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateHarvesterClusterSpec(spec HarvesterClusterSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.IdentitySecret.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("identitySecret", "name"), "identity secret name must be set"))
+	}
+
+	if spec.IdentitySecret.Namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("identitySecret", "namespace"), "identity secret namespace must be set"))
+	}
+
+	if spec.TargetNamespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("targetNamespace"), "target namespace in Harvester must be set"))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(spec.TargetNamespace) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("targetNamespace"), spec.TargetNamespace, msg))
+		}
+	}
+
+	allErrs = append(allErrs, validateLoadBalancerConfig(spec.LoadBalancerConfig, fldPath.Child("loadBalancerConfig"))...)
+
+	if spec.LoadBalancerConfig.Type == LoadBalancerTypeExternal {
+		allErrs = append(allErrs, validateExternalControlPlaneEndpoint(spec.ControlPlaneEndpoint, fldPath.Child("controlPlaneEndpoint"))...)
+	}
+
+	if (spec.UpdateCloudProviderConfig != UpdateCloudProviderConfig{}) {
+		allErrs = append(allErrs, validateUpdateCloudProviderConfig(spec.UpdateCloudProviderConfig, fldPath.Child("updateCloudProviderConfig"))...)
+	}
+
+	return allErrs
+}
+
+func validateLoadBalancerConfig(lbConfig LoadBalancerConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := validateIPAMConfig(lbConfig.IPAM, fldPath.Child("ipam"))
+
+	apiServerPort := lbConfig.APIServerPort
+	if apiServerPort == 0 {
+		apiServerPort = DefaultAPIServerPort
+	}
+
+	allErrs = append(allErrs, validateOptionalPort(lbConfig.APIServerPort, fldPath.Child("apiServerPort"))...)
+	allErrs = append(allErrs, validateOptionalPort(lbConfig.BackendPort, fldPath.Child("backendPort"))...)
+
+	if lbConfig.HealthCheck != nil {
+		allErrs = append(allErrs, validateHealthCheck(*lbConfig.HealthCheck, fldPath.Child("healthCheck"))...)
+	}
+
+	allErrs = append(allErrs, validateIPFamilies(lbConfig, fldPath)...)
+
+	switch lbConfig.Type {
+	case "", LoadBalancerTypeHarvester:
+		if lbConfig.KubeVIP != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("kubeVIP"), "kubeVIP must not be set when type is Harvester"))
+		}
+	case LoadBalancerTypeKubeVIP:
+		allErrs = append(allErrs, validateKubeVIPLoadBalancerConfig(lbConfig, fldPath)...)
+	case LoadBalancerTypeExternal:
+		if len(lbConfig.Listeners) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("listeners"), "listeners are not supported when type is External"))
+		}
+
+		if lbConfig.KubeVIP != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("kubeVIP"), "kubeVIP must not be set when type is External"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), lbConfig.Type,
+			[]string{string(LoadBalancerTypeHarvester), string(LoadBalancerTypeKubeVIP), string(LoadBalancerTypeExternal)}))
+	}
+
+	listenerNames := map[string]bool{}
+
+	for i, listener := range lbConfig.Listeners {
+		listenerPath := fldPath.Child("listeners").Index(i)
+
+		switch {
+		case listener.Name == "":
+			allErrs = append(allErrs, field.Required(listenerPath.Child("name"), "listener name must be set"))
+		case listener.Name == apiServerListenerName:
+			allErrs = append(allErrs, field.Invalid(listenerPath.Child("name"), listener.Name, "listener name is reserved for the API server"))
+		case listenerNames[listener.Name]:
+			allErrs = append(allErrs, field.Duplicate(listenerPath.Child("name"), listener.Name))
+		}
+
+		listenerNames[listener.Name] = true
+
+		for _, msg := range validation.IsValidPortNum(int(listener.Port)) {
+			allErrs = append(allErrs, field.Invalid(listenerPath.Child("port"), listener.Port, msg))
+		}
+
+		if listener.Port == apiServerPort {
+			allErrs = append(allErrs, field.Invalid(listenerPath.Child("port"), listener.Port, "port is reserved for the API server"))
+		}
+
+		for _, msg := range validation.IsValidPortNum(int(listener.BackendPort)) {
+			allErrs = append(allErrs, field.Invalid(listenerPath.Child("backendPort"), listener.BackendPort, msg))
+		}
+
+		if listener.Protocol != corev1.ProtocolTCP && listener.Protocol != corev1.ProtocolUDP {
+			allErrs = append(allErrs, field.NotSupported(listenerPath.Child("protocol"), listener.Protocol,
+				[]string{string(corev1.ProtocolTCP), string(corev1.ProtocolUDP)}))
+		}
+	}
+
+	return allErrs
+}
+
+// validateExternalControlPlaneEndpoint checks that the control plane endpoint provided beforehand for the External load balancer type
+// is a valid IP address or DNS name with a valid port.
+func validateExternalControlPlaneEndpoint(endpoint clusterv1.APIEndpoint, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if endpoint.Host == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("host"), "host must be set when the load balancer type is External"))
+	} else if net.ParseIP(endpoint.Host) == nil {
+		for _, msg := range validation.IsDNS1123Subdomain(endpoint.Host) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("host"), endpoint.Host, msg))
+		}
+	}
+
+	if endpoint.Port == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("port"), "port must be set when the load balancer type is External"))
+	} else {
+		for _, msg := range validation.IsValidPortNum(int(endpoint.Port)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), endpoint.Port, msg))
+		}
+	}
+
+	return allErrs
+}
+
+// defaultHealthCheck sets the default values of the unset fields of a load balancer health check.
+func defaultHealthCheck(healthCheck *HealthCheck, backendPort int32) {
+	if healthCheck.Port == 0 {
+		healthCheck.Port = backendPort
+	}
+
+	if healthCheck.SuccessThreshold == 0 {
+		healthCheck.SuccessThreshold = DefaultHealthCheckSuccessThreshold
+	}
+
+	if healthCheck.FailureThreshold == 0 {
+		healthCheck.FailureThreshold = DefaultHealthCheckFailureThreshold
+	}
+
+	if healthCheck.PeriodSeconds == 0 {
+		healthCheck.PeriodSeconds = DefaultHealthCheckPeriodSeconds
+	}
+
+	if healthCheck.TimeoutSeconds == 0 {
+		healthCheck.TimeoutSeconds = DefaultHealthCheckTimeoutSeconds
+	}
+}
+
+// validateOptionalPort checks that a port which defaults when unset is a valid port number.
+func validateOptionalPort(port int32, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if port == 0 {
+		return allErrs
+	}
+
+	for _, msg := range validation.IsValidPortNum(int(port)) {
+		allErrs = append(allErrs, field.Invalid(fldPath, port, msg))
+	}
+
+	return allErrs
+}
+
+// validateHealthCheck checks that the thresholds and durations of a load balancer health check are not negative.
+func validateHealthCheck(healthCheck HealthCheck, fldPath *field.Path) field.ErrorList {
+	allErrs := validateOptionalPort(healthCheck.Port, fldPath.Child("port"))
+
+	for name, value := range map[string]int32{
+		"successThreshold": healthCheck.SuccessThreshold,
+		"failureThreshold": healthCheck.FailureThreshold,
+		"periodSeconds":    healthCheck.PeriodSeconds,
+		"timeoutSeconds":   healthCheck.TimeoutSeconds,
+	} {
+		if value < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(name), value, "must not be negative"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateIPFamilies checks the IP families of the load balancer against its IP family policy, like for a Service,
+// and that the IP Pool defined for the load balancer has a range of the primary IP family.
+func validateIPFamilies(lbConfig LoadBalancerConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	ipFamiliesPath := fldPath.Child("ipFamilies")
+	seenFamilies := map[corev1.IPFamily]bool{}
+
+	for i, ipFamily := range lbConfig.IPFamilies {
+		switch {
+		case ipFamily != corev1.IPv4Protocol && ipFamily != corev1.IPv6Protocol:
+			allErrs = append(allErrs, field.NotSupported(ipFamiliesPath.Index(i), ipFamily,
+				[]string{string(corev1.IPv4Protocol), string(corev1.IPv6Protocol)}))
+		case seenFamilies[ipFamily]:
+			allErrs = append(allErrs, field.Duplicate(ipFamiliesPath.Index(i), ipFamily))
+		}
+
+		seenFamilies[ipFamily] = true
+	}
+
+	if len(lbConfig.IPFamilies) > maxIPFamilies {
+		allErrs = append(allErrs, field.TooMany(ipFamiliesPath, len(lbConfig.IPFamilies), maxIPFamilies))
+	}
+
+	policyPath := fldPath.Child("ipFamilyPolicy")
+
+	switch lbConfig.IPFamilyPolicy {
+	case "", corev1.IPFamilyPolicyPreferDualStack:
+	case corev1.IPFamilyPolicySingleStack:
+		if len(lbConfig.IPFamilies) > 1 {
+			allErrs = append(allErrs, field.Invalid(policyPath, lbConfig.IPFamilyPolicy, "only one IP family can be set with SingleStack"))
+		}
+	case corev1.IPFamilyPolicyRequireDualStack:
+		if len(lbConfig.IPFamilies) == 1 {
+			allErrs = append(allErrs, field.Invalid(policyPath, lbConfig.IPFamilyPolicy, "two IP families must be set with RequireDualStack"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(policyPath, lbConfig.IPFamilyPolicy, []string{
+			string(corev1.IPFamilyPolicySingleStack), string(corev1.IPFamilyPolicyPreferDualStack), string(corev1.IPFamilyPolicyRequireDualStack),
+		}))
+	}
+
+	primaryFamily := corev1.IPv4Protocol
+	if len(lbConfig.IPFamilies) > 0 {
+		primaryFamily = lbConfig.IPFamilies[0]
+	}
+
+	if lbConfig.IPAM.IPPool != nil && lbConfig.IPAM.Type == IPAMTypeIPPool && !ipPoolHasIPFamily(*lbConfig.IPAM.IPPool, primaryFamily) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ipam", "ipPool"), lbConfig.IPAM.IPPool.Subnet,
+			fmt.Sprintf("the IP Pool must have a range of the primary IP family %s", primaryFamily)))
+	}
+
+	return allErrs
+}
+
+// ipPoolHasIPFamily returns true if one of the subnets of an IP Pool is of the given IP family.
+func ipPoolHasIPFamily(pool IPPool, ipFamily corev1.IPFamily) bool {
+	subnets := []string{pool.Subnet}
+	for _, ipRange := range pool.AdditionalRanges {
+		subnets = append(subnets, ipRange.Subnet)
+	}
+
+	for _, subnet := range subnets {
+		ip, _, err := net.ParseCIDR(subnet)
+		if err != nil {
+			continue
+		}
+
+		if (ip.To4() != nil) == (ipFamily == corev1.IPv4Protocol) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultKubeVIPConfig sets the default values of the unset fields of a kube-vip configuration.
+func defaultKubeVIPConfig(kubeVIP *KubeVIPConfig) {
+	if kubeVIP.Image == "" {
+		kubeVIP.Image = DefaultKubeVIPImage
+	}
+
+	if kubeVIP.ManifestPath == "" {
+		kubeVIP.ManifestPath = DefaultKubeVIPManifestPath
+	}
+
+	if kubeVIP.KubeconfigPath == "" {
+		kubeVIP.KubeconfigPath = DefaultKubeVIPKubeconfigPath
+	}
+}
+
+// validateKubeVIPLoadBalancerConfig checks that a load balancer config using kube-vip only uses what kube-vip supports.
+// kube-vip only moves the virtual IP between the control plane machines, so it cannot forward traffic to another port or to other listeners.
+func validateKubeVIPLoadBalancerConfig(lbConfig LoadBalancerConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(lbConfig.Listeners) > 0 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("listeners"), "listeners are not supported when type is KubeVIP"))
+	}
+
+	if len(lbConfig.IPFamilies) > 1 {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("ipFamilies"), "kube-vip announces a single address, only one IP family can be set when type is KubeVIP"))
+	}
+
+	if lbConfig.BackendPort != 0 && lbConfig.APIServerPort != 0 && lbConfig.BackendPort != lbConfig.APIServerPort {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("backendPort"), lbConfig.BackendPort,
+			"backendPort must be equal to apiServerPort when type is KubeVIP"))
+	}
+
+	kubeVIP := KubeVIPConfig{}
+	if lbConfig.KubeVIP != nil {
+		kubeVIP = *lbConfig.KubeVIP
+	}
+
+	kubeVIPPath := fldPath.Child("kubeVIP")
+
+	if lbConfig.IPAM.Type == IPAMTypeDHCP {
+		if kubeVIP.Hostname == "" {
+			allErrs = append(allErrs, field.Required(kubeVIPPath.Child("hostname"),
+				"hostname must be set when the IPAM type is DHCP, it is registered with dynamic DNS"))
+		}
+	} else if kubeVIP.Hostname != "" {
+		allErrs = append(allErrs, field.Forbidden(kubeVIPPath.Child("hostname"),
+			fmt.Sprintf("hostname must not be set when the IPAM type is %s", lbConfig.IPAM.Type)))
+	}
+
+	if kubeVIP.Hostname != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(kubeVIP.Hostname) {
+			allErrs = append(allErrs, field.Invalid(kubeVIPPath.Child("hostname"), kubeVIP.Hostname, msg))
+		}
+	}
+
+	for _, pathField := range []struct {
+		name  string
+		value string
+	}{
+		{"manifestPath", kubeVIP.ManifestPath},
+		{"kubeconfigPath", kubeVIP.KubeconfigPath},
+	} {
+		if pathField.value != "" && !path.IsAbs(pathField.value) {
+			allErrs = append(allErrs, field.Invalid(kubeVIPPath.Child(pathField.name), pathField.value, "must be an absolute path"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateIPAMConfig checks that only the member of the IPAM union matching its type is set.
+func validateIPAMConfig(ipam IPAMConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	switch ipam.Type {
+	case IPAMTypeDHCP:
+	case IPAMTypeIPPool:
+		if ipam.IPPool == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("ipPool"), "ipPool must be set when type is IPPool"))
+		} else {
+			allErrs = append(allErrs, validateIPPool(*ipam.IPPool, fldPath.Child("ipPool"))...)
+		}
+	case IPAMTypeIPPoolRef:
+		if ipam.IPPoolRef == nil || ipam.IPPoolRef.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("ipPoolRef", "name"), "ipPoolRef must be set when type is IPPoolRef"))
+		}
+	case IPAMTypeIPAddressClaim:
+		if ipam.PoolRef == nil || ipam.PoolRef.Name == "" || ipam.PoolRef.Kind == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("poolRef"), "poolRef must be set with a kind and a name when type is IPAddressClaim"))
+		}
+	default:
+		return append(allErrs, field.NotSupported(fldPath.Child("type"), ipam.Type,
+			[]string{string(IPAMTypeDHCP), string(IPAMTypeIPPool), string(IPAMTypeIPPoolRef), string(IPAMTypeIPAddressClaim)}))
+	}
+
+	if ipam.IPPool != nil && ipam.Type != IPAMTypeIPPool {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("ipPool"), fmt.Sprintf("ipPool must not be set when type is %s", ipam.Type)))
+	}
+
+	if ipam.IPPoolRef != nil && ipam.Type != IPAMTypeIPPoolRef {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("ipPoolRef"), fmt.Sprintf("ipPoolRef must not be set when type is %s", ipam.Type)))
+	}
+
+	if ipam.PoolRef != nil && ipam.Type != IPAMTypeIPAddressClaim {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("poolRef"), fmt.Sprintf("poolRef must not be set when type is %s", ipam.Type)))
+	}
+
+	return allErrs
+}
+
+func validateIPPool(pool IPPool, fldPath *field.Path) field.ErrorList {
+	allErrs := validateObjectReference(pool.VMNetwork, fldPath.Child("vmNetwork"))
+
+	allErrs = append(allErrs, validateIPPoolRange(IPPoolRange{
+		Subnet:     pool.Subnet,
+		Gateway:    pool.Gateway,
+		RangeStart: pool.RangeStart,
+		RangeEnd:   pool.RangeEnd,
+	}, fldPath)...)
+
+	subnets := []string{pool.Subnet}
+
+	for i, ipRange := range pool.AdditionalRanges {
+		allErrs = append(allErrs, validateIPPoolRange(ipRange, fldPath.Child("additionalRanges").Index(i))...)
+		subnets = append(subnets, ipRange.Subnet)
+	}
+
+	for i, excluded := range pool.Exclude {
+		allErrs = append(allErrs, validateExcludedAddress(excluded, subnets, fldPath.Child("exclude").Index(i))...)
+	}
+
+	for i, scope := range pool.Scope {
+		scopePath := fldPath.Child("scope").Index(i)
+
+		if scope == (IPPoolScope{}) {
+			allErrs = append(allErrs, field.Required(scopePath, "one of project, namespace or guestCluster must be set"))
+		}
+
+		if scope.Namespace != "" {
+			for _, msg := range validation.IsDNS1123Label(scope.Namespace) {
+				allErrs = append(allErrs, field.Invalid(scopePath.Child("namespace"), scope.Namespace, msg))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateExcludedAddress checks that an address or CIDR excluded from an IP Pool is inside one of its subnets.
+func validateExcludedAddress(excluded string, subnets []string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	ip := net.ParseIP(excluded)
+	if ip == nil {
+		var err error
+
+		ip, _, err = net.ParseCIDR(excluded)
+		if err != nil {
+			return append(allErrs, field.Invalid(fldPath, excluded, "must be a valid IP address or CIDR"))
+		}
+	}
+
+	for _, subnet := range subnets {
+		if _, ipNet, err := net.ParseCIDR(subnet); err == nil && ipNet.Contains(ip) {
+			return allErrs
+		}
+	}
+
+	return append(allErrs, field.Invalid(fldPath, excluded, "must be inside one of the subnets of the IP Pool"))
+}
+
+// validateIPPoolRange checks that the gateway and the bounds of a range of an IP Pool are inside its subnet.
+func validateIPPoolRange(ipRange IPPoolRange, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if ipRange.Subnet == "" {
+		return append(allErrs, field.Required(fldPath.Child("subnet"), "subnet must be set"))
+	}
+
+	_, subnet, err := net.ParseCIDR(ipRange.Subnet)
+	if err != nil {
+		return append(allErrs, field.Invalid(fldPath.Child("subnet"), ipRange.Subnet, "subnet must be a valid CIDR"))
+	}
+
+	if ipRange.Gateway == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("gateway"), "gateway must be set"))
+	} else {
+		allErrs = append(allErrs, validateIPInSubnet(ipRange.Gateway, subnet, fldPath.Child("gateway"))...)
+	}
+
+	if ipRange.RangeStart != "" {
+		allErrs = append(allErrs, validateIPInSubnet(ipRange.RangeStart, subnet, fldPath.Child("rangeStart"))...)
+	}
+
+	if ipRange.RangeEnd != "" {
+		allErrs = append(allErrs, validateIPInSubnet(ipRange.RangeEnd, subnet, fldPath.Child("rangeEnd"))...)
+	}
+
+	rangeStart, rangeEnd := net.ParseIP(ipRange.RangeStart), net.ParseIP(ipRange.RangeEnd)
+	if rangeStart != nil && rangeEnd != nil && bytes.Compare(rangeStart.To16(), rangeEnd.To16()) > 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("rangeEnd"), ipRange.RangeEnd, "rangeEnd must not be lower than rangeStart"))
+	}
+
+	return allErrs
+}
+
+func validateIPInSubnet(address string, subnet *net.IPNet, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return append(allErrs, field.Invalid(fldPath, address, "must be a valid IP address"))
+	}
+
+	if !subnet.Contains(ip) {
+		allErrs = append(allErrs, field.Invalid(fldPath, address, fmt.Sprintf("must be inside the subnet %s", subnet.String())))
+	}
+
+	return allErrs
+}
+
+func validateUpdateCloudProviderConfig(config UpdateCloudProviderConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for _, requiredField := range []struct {
+		name  string
+		value string
+	}{
+		{"manifestsConfigMapNamespace", config.ManifestsConfigMapNamespace},
+		{"manifestsConfigMapName", config.ManifestsConfigMapName},
+		{"manifestsConfigMapKey", config.ManifestsConfigMapKey},
+		{"cloudConfigCredentialsSecretName", config.CloudConfigCredentialsSecretName},
+		{"cloudConfigCredentialsSecretKey", config.CloudConfigCredentialsSecretKey},
+	} {
+		if requiredField.value == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child(requiredField.name), "must be set when updateCloudProviderConfig is used"))
+		}
+	}
+
+	return allErrs
+}
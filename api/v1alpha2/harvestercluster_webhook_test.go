@@ -0,0 +1,493 @@
+package v1alpha2
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+var _ = Describe("HarvesterCluster webhook", func() {
+	var hvCluster *HarvesterCluster
+
+	BeforeEach(func() {
+		hvCluster = &HarvesterCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-cluster",
+				Namespace: "default",
+			},
+			Spec: HarvesterClusterSpec{
+				Server:          "https://harvester.example.com:6443",
+				TargetNamespace: "default",
+				IdentitySecret: SecretKey{
+					Name: "harvester-secret",
+				},
+				LoadBalancerConfig: LoadBalancerConfig{
+					IPAM: IPAMConfig{
+						Type: IPAMTypeIPPool,
+						IPPool: &IPPool{
+							VMNetwork:  ObjectReference{Namespace: "default", Name: "vm-network"},
+							Subnet:     "172.19.0.0/16",
+							Gateway:    "172.19.0.1",
+							RangeStart: "172.19.10.1",
+							RangeEnd:   "172.19.10.10",
+						},
+					},
+					Listeners: []Listener{
+						{
+							Name: "ingress",
+							Port: 443,
+						},
+					},
+				},
+			},
+		}
+	})
+
+	Context("When defaulting a HarvesterCluster", func() {
+		It("Should set the identity secret namespace and the listener defaults", func() {
+			hvCluster.Default()
+
+			Expect(hvCluster.Spec.IdentitySecret.Namespace).To(Equal("default"))
+			Expect(hvCluster.Spec.LoadBalancerConfig.Listeners[0].Protocol).To(Equal(corev1.ProtocolTCP))
+			Expect(hvCluster.Spec.LoadBalancerConfig.Listeners[0].BackendPort).To(Equal(int32(443)))
+		})
+
+		It("Should default the IPAM type to DHCP", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM = IPAMConfig{}
+			hvCluster.Default()
+
+			Expect(hvCluster.Spec.LoadBalancerConfig.IPAM.Type).To(Equal(IPAMTypeDHCP))
+		})
+
+		It("Should default the API server ports and the health check", func() {
+			hvCluster.Spec.LoadBalancerConfig.BackendPort = 9345
+			hvCluster.Spec.LoadBalancerConfig.HealthCheck = &HealthCheck{FailureThreshold: 1}
+			hvCluster.Default()
+
+			Expect(hvCluster.Spec.LoadBalancerConfig.APIServerPort).To(Equal(int32(DefaultAPIServerPort)))
+			Expect(*hvCluster.Spec.LoadBalancerConfig.HealthCheck).To(Equal(HealthCheck{
+				Port:             9345,
+				SuccessThreshold: DefaultHealthCheckSuccessThreshold,
+				FailureThreshold: 1,
+				PeriodSeconds:    DefaultHealthCheckPeriodSeconds,
+				TimeoutSeconds:   DefaultHealthCheckTimeoutSeconds,
+			}))
+		})
+	})
+
+	Context("When defaulting the IP families", func() {
+		It("Should default to a single IPv4 stack", func() {
+			hvCluster.Default()
+
+			Expect(hvCluster.Spec.LoadBalancerConfig.IPFamilies).To(Equal([]corev1.IPFamily{corev1.IPv4Protocol}))
+			Expect(hvCluster.Spec.LoadBalancerConfig.IPFamilyPolicy).To(Equal(corev1.IPFamilyPolicySingleStack))
+		})
+
+		It("Should prefer dual-stack with two IP families", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}
+			hvCluster.Default()
+
+			Expect(hvCluster.Spec.LoadBalancerConfig.IPFamilyPolicy).To(Equal(corev1.IPFamilyPolicyPreferDualStack))
+		})
+	})
+
+	Context("When validating a new HarvesterCluster", func() {
+		BeforeEach(func() {
+			hvCluster.Default()
+		})
+
+		It("Should accept a valid HarvesterCluster", func() {
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should accept a reference to an existing IP Pool", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM = IPAMConfig{
+				Type:      IPAMTypeIPPoolRef,
+				IPPoolRef: &IPPoolReference{Name: "my-pool"},
+			}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should accept a reference to the pool of an IPAM provider", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM = IPAMConfig{
+				Type:    IPAMTypeIPAddressClaim,
+				PoolRef: &corev1.TypedLocalObjectReference{Kind: "InClusterIPPool", Name: "my-pool"},
+			}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject an IPAddressClaim IPAM type without poolRef", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM = IPAMConfig{Type: IPAMTypeIPAddressClaim}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a poolRef with the IPPool IPAM type", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.PoolRef = &corev1.TypedLocalObjectReference{Kind: "InClusterIPPool", Name: "my-pool"}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject an IPPool IPAM type without ipPool", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool = nil
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject an IPPoolRef IPAM type without ipPoolRef", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM = IPAMConfig{Type: IPAMTypeIPPoolRef}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject both ipPool and ipPoolRef", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPoolRef = &IPPoolReference{Name: "my-pool"}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject an ipPool with the DHCP IPAM type", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.Type = IPAMTypeDHCP
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject an invalid subnet", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.Subnet = "172.19.0.0"
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a range outside of the subnet", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.RangeEnd = "10.0.0.10"
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a range start greater than the range end", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.RangeStart = "172.19.10.20"
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a malformed VM network reference", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.VMNetwork = ObjectReference{Namespace: "default/vm", Name: "network"}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a listener using the API server port", func() {
+			hvCluster.Spec.LoadBalancerConfig.Listeners[0].Port = 6443
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a listener using a custom API server port", func() {
+			hvCluster.Spec.LoadBalancerConfig.APIServerPort = 443
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a negative health check period", func() {
+			hvCluster.Spec.LoadBalancerConfig.HealthCheck.PeriodSeconds = -1
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should accept kube-vip with a referenced IP Pool", func() {
+			hvCluster.Spec.LoadBalancerConfig.Type = LoadBalancerTypeKubeVIP
+			hvCluster.Spec.LoadBalancerConfig.Listeners = nil
+			hvCluster.Spec.LoadBalancerConfig.IPAM = IPAMConfig{
+				Type:      IPAMTypeIPPoolRef,
+				IPPoolRef: &IPPoolReference{Name: "my-pool"},
+			}
+			hvCluster.Default()
+
+			Expect(hvCluster.Spec.LoadBalancerConfig.KubeVIP.Image).To(Equal(DefaultKubeVIPImage))
+
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject listeners with kube-vip", func() {
+			hvCluster.Spec.LoadBalancerConfig.Type = LoadBalancerTypeKubeVIP
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should require a hostname for kube-vip with DHCP", func() {
+			hvCluster.Spec.LoadBalancerConfig.Type = LoadBalancerTypeKubeVIP
+			hvCluster.Spec.LoadBalancerConfig.Listeners = nil
+			hvCluster.Spec.LoadBalancerConfig.IPAM = IPAMConfig{Type: IPAMTypeDHCP}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+
+			hvCluster.Spec.LoadBalancerConfig.KubeVIP = &KubeVIPConfig{Hostname: "cp.example.com"}
+			_, err = hvCluster.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject a kube-vip configuration with a Harvester load balancer", func() {
+			hvCluster.Spec.LoadBalancerConfig.KubeVIP = &KubeVIPConfig{}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should require the control plane endpoint with an external load balancer", func() {
+			hvCluster.Spec.LoadBalancerConfig.Type = LoadBalancerTypeExternal
+			hvCluster.Spec.LoadBalancerConfig.Listeners = nil
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+
+			hvCluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "cp.example.com", Port: 6443}
+			_, err = hvCluster.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should accept a dual-stack IP Pool with IPv6 as primary IP family", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}
+			hvCluster.Spec.LoadBalancerConfig.IPFamilyPolicy = corev1.IPFamilyPolicyRequireDualStack
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.AdditionalRanges = []IPPoolRange{
+				{Subnet: "fd00:172:19::/64", Gateway: "fd00:172:19::1", RangeStart: "fd00:172:19::10", RangeEnd: "fd00:172:19::20"},
+			}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject an IP Pool without a range of the primary IP family", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject an additional range outside of its subnet", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.AdditionalRanges = []IPPoolRange{
+				{Subnet: "fd00:172:19::/64", Gateway: "fd00:172:20::1"},
+			}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject two IP families with SingleStack", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+			hvCluster.Spec.LoadBalancerConfig.IPFamilyPolicy = corev1.IPFamilyPolicySingleStack
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a duplicate IP family", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv4Protocol}
+			hvCluster.Spec.LoadBalancerConfig.IPFamilyPolicy = corev1.IPFamilyPolicyPreferDualStack
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject two IP families with kube-vip", func() {
+			hvCluster.Spec.LoadBalancerConfig.Type = LoadBalancerTypeKubeVIP
+			hvCluster.Spec.LoadBalancerConfig.Listeners = nil
+			hvCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+			hvCluster.Spec.LoadBalancerConfig.IPFamilyPolicy = corev1.IPFamilyPolicyPreferDualStack
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should accept exclusions and a scope in the IP Pool", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.Exclude = []string{"172.19.10.5", "172.19.10.8/30"}
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.Scope = []IPPoolScope{{Namespace: "default", GuestCluster: "test-cluster"}}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject an exclusion outside of the subnets of the IP Pool", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.Exclude = []string{"10.0.0.1"}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a malformed exclusion", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.Exclude = []string{"172.19.10"}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject an empty scope of the IP Pool", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.IPPool.Scope = []IPPoolScope{{}}
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject an unknown IPAM type", func() {
+			hvCluster.Spec.LoadBalancerConfig.IPAM.Type = "static"
+			_, err := hvCluster.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When validating an updated HarvesterCluster", func() {
+		BeforeEach(func() {
+			hvCluster.Default()
+		})
+
+		It("Should accept a change of the server", func() {
+			newCluster := hvCluster.DeepCopy()
+			newCluster.Spec.Server = "https://other.example.com:6443"
+
+			_, err := newCluster.ValidateUpdate(hvCluster)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject a change of the target namespace", func() {
+			newCluster := hvCluster.DeepCopy()
+			newCluster.Spec.TargetNamespace = "other"
+
+			_, err := newCluster.ValidateUpdate(hvCluster)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should accept a change of the health check", func() {
+			newCluster := hvCluster.DeepCopy()
+			newCluster.Spec.LoadBalancerConfig.HealthCheck.FailureThreshold = 1
+
+			_, err := newCluster.ValidateUpdate(hvCluster)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject a change of the load balancer type", func() {
+			newCluster := hvCluster.DeepCopy()
+			newCluster.Spec.LoadBalancerConfig.Type = LoadBalancerTypeKubeVIP
+
+			_, err := newCluster.ValidateUpdate(hvCluster)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a change of an external control plane endpoint", func() {
+			hvCluster.Spec.LoadBalancerConfig.Type = LoadBalancerTypeExternal
+			hvCluster.Spec.LoadBalancerConfig.Listeners = nil
+			hvCluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "10.0.0.10", Port: 6443}
+
+			newCluster := hvCluster.DeepCopy()
+			newCluster.Spec.ControlPlaneEndpoint.Host = "10.0.0.11"
+
+			_, err := newCluster.ValidateUpdate(hvCluster)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a change of the primary IP family", func() {
+			newCluster := hvCluster.DeepCopy()
+			newCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}
+			newCluster.Spec.LoadBalancerConfig.IPFamilyPolicy = corev1.IPFamilyPolicyPreferDualStack
+
+			_, err := newCluster.ValidateUpdate(hvCluster)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should accept adding a secondary IP family", func() {
+			newCluster := hvCluster.DeepCopy()
+			newCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+			newCluster.Spec.LoadBalancerConfig.IPFamilyPolicy = corev1.IPFamilyPolicyPreferDualStack
+
+			_, err := newCluster.ValidateUpdate(hvCluster)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject a change of the API server port", func() {
+			newCluster := hvCluster.DeepCopy()
+			newCluster.Spec.LoadBalancerConfig.APIServerPort = 9345
+
+			_, err := newCluster.ValidateUpdate(hvCluster)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("HarvesterClusterTemplate webhook", func() {
+	dryRunDisabled, dryRunEnabled := false, true
+
+	var (
+		hvTemplate *HarvesterClusterTemplate
+		w          *HarvesterClusterTemplateWebhook
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		hvTemplate = &HarvesterClusterTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-template",
+				Namespace: "default",
+			},
+			Spec: HarvesterClusterTemplateSpec{
+				Template: HarvesterClusterTemplateResource{
+					Spec: HarvesterClusterSpec{
+						Server: "https://harvester.example.com:6443",
+						LoadBalancerConfig: LoadBalancerConfig{
+							Listeners: []Listener{
+								{
+									Name: "ingress",
+									Port: 443,
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		w = &HarvesterClusterTemplateWebhook{}
+		ctx = admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				DryRun: &dryRunDisabled,
+			},
+		})
+
+		Expect(w.Default(ctx, hvTemplate)).To(Succeed())
+	})
+
+	It("Should default the template spec", func() {
+		Expect(hvTemplate.Spec.Template.Spec.LoadBalancerConfig.IPAM.Type).To(Equal(IPAMTypeDHCP))
+		Expect(hvTemplate.Spec.Template.Spec.LoadBalancerConfig.Listeners[0].Protocol).To(Equal(corev1.ProtocolTCP))
+	})
+
+	It("Should accept a template without the fields set by ClusterClass patches", func() {
+		_, err := w.ValidateCreate(ctx, hvTemplate)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should reject invalid values in the template", func() {
+		hvTemplate.Spec.Template.Spec.TargetNamespace = "Not_A_Namespace"
+		_, err := w.ValidateCreate(ctx, hvTemplate)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should reject changes to the template spec", func() {
+		newTemplate := hvTemplate.DeepCopy()
+		newTemplate.Spec.Template.Spec.Server = "https://other.example.com:6443"
+
+		_, err := w.ValidateUpdate(ctx, hvTemplate, newTemplate)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should allow dry-run changes from the topology controller", func() {
+		newTemplate := hvTemplate.DeepCopy()
+		newTemplate.Spec.Template.Spec.Server = "https://other.example.com:6443"
+		newTemplate.Annotations = map[string]string{clusterv1.TopologyDryRunAnnotation: ""}
+
+		dryRunCtx := admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				DryRun: &dryRunEnabled,
+			},
+		})
+
+		_, err := w.ValidateUpdate(dryRunCtx, hvTemplate, newTemplate)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,127 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"fmt"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"sigs.k8s.io/cluster-api/util/topology"
+)
+
+// HarvesterClusterTemplateWebhook implements a custom defaulting and validation webhook for HarvesterClusterTemplate.
+// A custom webhook is needed because the immutability check relies on the admission request,
+// which is not available to webhook.Validator implementations.
+// +kubebuilder:object:generate=false
+type HarvesterClusterTemplateWebhook struct{}
+
+// SetupWebhookWithManager sets up and registers the webhooks for HarvesterClusterTemplate with the manager.
+func (w *HarvesterClusterTemplateWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&HarvesterClusterTemplate{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1alpha2-harvesterclustertemplate,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=harvesterclustertemplates,verbs=create;update,versions=v1alpha2,name=default.harvesterclustertemplate.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+var _ webhook.CustomDefaulter = &HarvesterClusterTemplateWebhook{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type.
+// The identity secret namespace is not defaulted here, it is defaulted on the HarvesterCluster created from the template.
+func (w *HarvesterClusterTemplateWebhook) Default(_ context.Context, obj runtime.Object) error {
+	template, ok := obj.(*HarvesterClusterTemplate)
+	if !ok {
+		return fmt.Errorf("expected a HarvesterClusterTemplate but got a %T", obj)
+	}
+
+	defaultHarvesterClusterSpec(&template.Spec.Template.Spec)
+
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1alpha2-harvesterclustertemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=harvesterclustertemplates,verbs=create;update,versions=v1alpha2,name=validation.harvesterclustertemplate.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+var _ webhook.CustomValidator = &HarvesterClusterTemplateWebhook{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (w *HarvesterClusterTemplateWebhook) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	template, ok := obj.(*HarvesterClusterTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a HarvesterClusterTemplate but got a %T", obj)
+	}
+
+	allErrs := validateHarvesterClusterTemplateSpec(template.Spec.Template.Spec, field.NewPath("spec", "template", "spec"))
+
+	return nil, aggregateObjErrors(GroupVersion.WithKind("HarvesterClusterTemplate").GroupKind(), template.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (w *HarvesterClusterTemplateWebhook) ValidateUpdate(
+	ctx context.Context, oldObj, newObj runtime.Object,
+) (admission.Warnings, error) {
+	oldTemplate, ok := oldObj.(*HarvesterClusterTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a HarvesterClusterTemplate but got a %T", oldObj)
+	}
+
+	newTemplate, ok := newObj.(*HarvesterClusterTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a HarvesterClusterTemplate but got a %T", newObj)
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("expected an admission.Request inside context: %w", err)
+	}
+
+	templatePath := field.NewPath("spec", "template", "spec")
+	allErrs := validateHarvesterClusterTemplateSpec(newTemplate.Spec.Template.Spec, templatePath)
+
+	// The old spec is defaulted as well, since it may have been stored before new fields got default values.
+	oldSpec := oldTemplate.Spec.Template.Spec.DeepCopy()
+	defaultHarvesterClusterSpec(oldSpec)
+
+	// ClusterClasses are expected to be rotated to a new template instead of changing an existing one.
+	// The topology controller is allowed to perform dry-run updates to detect changes.
+	if !topology.ShouldSkipImmutabilityChecks(req, newTemplate) &&
+		!apiequality.Semantic.DeepEqual(*oldSpec, newTemplate.Spec.Template.Spec) {
+		allErrs = append(allErrs, field.Forbidden(templatePath, "HarvesterClusterTemplate spec.template.spec field is immutable"))
+	}
+
+	return nil, aggregateObjErrors(GroupVersion.WithKind("HarvesterClusterTemplate").GroupKind(), newTemplate.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
+func (w *HarvesterClusterTemplateWebhook) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateHarvesterClusterTemplateSpec validates the values set in a HarvesterClusterTemplate.
+// Missing fields are accepted, because ClusterClass patches can set them from variables on the HarvesterCluster.
+// The HarvesterCluster webhook checks that they are eventually present.
+func validateHarvesterClusterTemplateSpec(spec HarvesterClusterSpec, fldPath *field.Path) field.ErrorList {
+	return validateHarvesterClusterSpec(spec, fldPath).Filter(field.NewErrorTypeMatcher(field.ErrorTypeRequired))
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// MachineFinalizer allows ReconcileHarvesterMachine to clean up resources associated with HarvesterMachine before.
+	// removing it from the apiserver.
+	MachineFinalizer = "harvestermachine.infrastructure.cluster.x-k8s.io"
+
+	// DefaultVolumeSize is the size given to volumes which do not define a VolumeSize.
+	DefaultVolumeSize = "40Gi"
+
+	// DefaultInterfaceModel is the model given to network interfaces which do not define a Model.
+	DefaultInterfaceModel = "virtio"
+)
+
+const (
+	// MachineCreatedCondition documents that the machine has been created.
+	//
+	// Deprecated: replaced by VMProvisionedCondition, it is converted to it when the HarvesterMachine is reconciled.
+	MachineCreatedCondition capiv1beta1.ConditionType = "MachineCreated"
+
+	// MachineNotFoundReason documents that the machine was not found.
+	MachineNotFoundReason = "MachineNotFound"
+
+	// VMProvisionedCondition documents that the VM of the HarvesterMachine has been created in Harvester.
+	VMProvisionedCondition capiv1beta1.ConditionType = "VMProvisioned"
+	// WaitingForClusterInfrastructureReason documents that the VM waits for the infrastructure of the cluster to be ready.
+	WaitingForClusterInfrastructureReason = "WaitingForClusterInfrastructure"
+	// WaitingForStaticAddressesReason documents that the VM waits for the static addresses of its interfaces to be allocated.
+	WaitingForStaticAddressesReason = "WaitingForStaticAddresses"
+	// VMProvisioningFailedReason documents that the VM could not be created in Harvester.
+	VMProvisioningFailedReason = "VMProvisioningFailed"
+
+	// BootstrapDataAppliedCondition documents that the bootstrap data of the Machine was given to the VM as cloud-init user data.
+	BootstrapDataAppliedCondition capiv1beta1.ConditionType = "BootstrapDataApplied"
+	// WaitingForBootstrapDataReason documents that the VM waits for the bootstrap data of the Machine to be generated.
+	WaitingForBootstrapDataReason = "WaitingForBootstrapData"
+
+	// VMRunningCondition documents that the VM is running in Harvester.
+	// When it is not, the reason is the printable status of the VM, or the phase of its instance.
+	VMRunningCondition capiv1beta1.ConditionType = "VMRunning"
+	// VMInstanceNotFoundReason documents that the VM has no instance, and no printable status yet.
+	VMInstanceNotFoundReason = "VMInstanceNotFound"
+
+	// AddressesReportedCondition documents that the guest agent of the VM reports its IP addresses.
+	AddressesReportedCondition capiv1beta1.ConditionType = "AddressesReported"
+	// WaitingForGuestAgentReason documents that the guest agent of the VM does not report any IP address yet.
+	WaitingForGuestAgentReason = "WaitingForGuestAgent"
+
+	// NodeRegisteredCondition documents that the Node of the VM registered in the workload cluster with its provider ID.
+	NodeRegisteredCondition capiv1beta1.ConditionType = "NodeRegistered"
+	// WaitingForNodeReason documents that the Node of the VM is not registered in the workload cluster yet.
+	WaitingForNodeReason = "WaitingForNode"
+)
+
+// HarvesterMachineSpec defines the desired state of HarvesterMachine.
+type HarvesterMachineSpec struct {
+	// ProviderID will be the ID of the VM in the provider (Harvester).
+	// This is set by the Cloud provider on the Workload cluster node and replicated by CAPI.
+	// +optional
+	ProviderID string `json:"providerID,omitempty"`
+
+	// FailureDomain defines the zone or failure domain where this VM should be.
+	// +optional
+	FailureDomain string `json:"failureDomain,omitempty"`
+
+	// CPU is the CPU configuration of the VM.
+	CPU CPU `json:"cpu"`
+
+	// Memory is the memory size to assign to the VM (should be similar to pod.spec.containers.resources.limits).
+	Memory resource.Quantity `json:"memory"`
+
+	// SSHUser is the user that should be used to connect to the VMs using SSH.
+	SSHUser string `json:"sshUser"`
+
+	// SSHKeyPair is a reference to the SSH key pair to use for SSH access to the VM (this keyPair should be created in Harvester).
+	SSHKeyPair ObjectReference `json:"sshKeyPair"`
+
+	// Volumes is a list of Volumes to attach to the VM
+	Volumes []Volume `json:"volumes"`
+
+	// Networks is a list of Networks to attach to the VM.
+	Networks []Network `json:"networks"`
+
+	// NodeAffinity gives the possibility to select preferred nodes for VM scheduling on Harvester. This works exactly like Pods.
+	// +optional
+	NodeAffinity *corev1.NodeAffinity `json:"nodeAffinity,omitempty"`
+
+	// WorkloadAffinity gives the possibility to define affinity rules with other workloads running on Harvester.
+	// +optional
+	WorkloadAffinity *corev1.PodAffinity `json:"workloadAffinity,omitempty"`
+}
+
+// CPU defines the CPU topology of the VM. The number of vCPUs of the VM is Cores * Sockets * Threads.
+type CPU struct {
+	// Cores is the number of CPU cores per socket.
+	Cores int32 `json:"cores"`
+
+	// Sockets is the number of CPU sockets. Defaults to 1.
+	// +optional
+	Sockets int32 `json:"sockets,omitempty"`
+
+	// Threads is the number of threads per CPU core. Defaults to 1.
+	// +optional
+	Threads int32 `json:"threads,omitempty"`
+
+	// DedicatedCPUPlacement requests the VM to be pinned to dedicated physical CPUs of the Harvester node.
+	// This requires the CPU Manager to be enabled on the node.
+	// +optional
+	DedicatedCPUPlacement bool `json:"dedicatedCPUPlacement,omitempty"`
+
+	// Model is the CPU model exposed to the VM, e.g. "host-passthrough" or "host-model".
+	// If absent, the default model of the Harvester cluster is used.
+	// +optional
+	Model string `json:"model,omitempty"`
+}
+
+// VCPUs returns the total number of vCPUs of the topology, counting unset sockets and threads as 1.
+func (c CPU) VCPUs() int64 {
+	vcpus := int64(c.Cores)
+
+	if c.Sockets > 0 {
+		vcpus *= int64(c.Sockets)
+	}
+
+	if c.Threads > 0 {
+		vcpus *= int64(c.Threads)
+	}
+
+	return vcpus
+}
+
+// Volume defines a volume that should be attached to the VM.
+type Volume struct {
+	// VolumeType is the type of volume to attach.
+	// Choose between: "storageClass" or "image"
+	VolumeType VolumeType `json:"volumeType"`
+
+	// Image is a reference to the image to use if the volumeType is "image".
+	// The name is matched against the display name of the images in Harvester.
+	// +optional
+	Image *ObjectReference `json:"image,omitempty"`
+
+	// StorageClass is the name of the storage class to be used if the volumeType is "storageClass".
+	// Such volumes are created blank and can be used as data disks.
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// VolumeSize is the desired size of the volume. This satisfies to standard Kubernetes *resource.Quantity syntax.
+	// Examples: 40.5Gi, 30M, etc. are valid
+	// +optional
+	VolumeSize *resource.Quantity `json:"volumeSize,omitempty"`
+
+	// BootOrder is an integer that determines the order of priority of volumes for booting the VM.
+	// Volumes without a BootOrder are tried after the others, in the sequence with which they appear in the manifest.
+	// +optional
+	BootOrder int `json:"bootOrder,omitempty"`
+}
+
+// Network defines a network interface of the VM.
+type Network struct {
+	// VMNetwork is a reference to the VM Network in Harvester the interface is attached to.
+	// It must be set, unless the binding is "masquerade", in which case the interface is attached to the management network of Harvester.
+	// +optional
+	VMNetwork *ObjectReference `json:"vmNetwork,omitempty"`
+
+	// Binding is the way the interface is connected to the network.
+	// Choose between: "bridge", "masquerade" or "sriov". Defaults to "bridge".
+	// +optional
+	Binding InterfaceBinding `json:"binding,omitempty"`
+
+	// Model is the model of the emulated network interface. Defaults to "virtio".
+	// It does not apply to "sriov" interfaces, which pass a virtual function of the host NIC through to the VM.
+	// +kubebuilder:validation:Enum:=virtio;e1000;e1000e;ne2k_pci;pcnet;rtl8139
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// MACAddress is a static MAC address to assign to the interface. If absent, a MAC address is generated.
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+
+	// Primary marks the interface used to reach the node, e.g. by the API server and for the addresses of the Machine.
+	// At most one network can be primary. If none is, the first network is the primary one.
+	// +optional
+	Primary bool `json:"primary,omitempty"`
+
+	// IPAM configures how the address of the interface is obtained. If absent, the address is obtained with DHCP.
+	// Static addresses are configured in the VM through the cloud-init network config.
+	// +optional
+	IPAM *NetworkIPAM `json:"ipam,omitempty"`
+}
+
+// NetworkIPAMType is an enum string. It can only take the values: "DHCP", "IPPool" or "IPAddressClaim".
+// +kubebuilder:validation:Enum:=DHCP;IPPool;IPAddressClaim
+type NetworkIPAMType string
+
+const (
+	// NetworkIPAMTypeDHCP obtains the address of the interface with DHCP.
+	NetworkIPAMTypeDHCP NetworkIPAMType = "DHCP"
+	// NetworkIPAMTypeIPPool allocates a static address to the interface from an IP Pool in Harvester.
+	NetworkIPAMTypeIPPool NetworkIPAMType = "IPPool"
+	// NetworkIPAMTypeIPAddressClaim claims a static address for the interface from a Cluster API IPAM provider.
+	NetworkIPAMTypeIPAddressClaim NetworkIPAMType = "IPAddressClaim"
+)
+
+// NetworkIPAM configures how the address of a network interface is obtained.
+type NetworkIPAM struct {
+	// Type is the way the address is obtained. Defaults to "DHCP".
+	// +optional
+	Type NetworkIPAMType `json:"type,omitempty"`
+
+	// IPPoolRef is a reference to the IP Pool in Harvester to allocate the address from, if the type is "IPPool".
+	// +optional
+	IPPoolRef *IPPoolReference `json:"ipPoolRef,omitempty"`
+
+	// PoolRef is a reference to the pool of a Cluster API IPAM provider, e.g. an InClusterIPPool,
+	// an IPAddressClaim is created against if the type is "IPAddressClaim".
+	// +optional
+	PoolRef *corev1.TypedLocalObjectReference `json:"poolRef,omitempty"`
+
+	// Nameservers is a list of DNS servers to configure on the interface when its address is static.
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+}
+
+// InterfaceBinding is an enum string. It can only take the values: "bridge", "masquerade" or "sriov".
+// +kubebuilder:validation:Enum:=bridge;masquerade;sriov
+type InterfaceBinding string
+
+const (
+	// InterfaceBindingBridge connects the interface to the network through a bridge.
+	InterfaceBindingBridge InterfaceBinding = "bridge"
+	// InterfaceBindingMasquerade connects the interface to the management network of Harvester through NAT.
+	InterfaceBindingMasquerade InterfaceBinding = "masquerade"
+	// InterfaceBindingSRIOV passes a SR-IOV virtual function of the host through to the VM.
+	InterfaceBindingSRIOV InterfaceBinding = "sriov"
+)
+
+// VolumeType is an enum string. It can only take the values: "storageClass" or "image".
+// +kubebuilder:validation:Enum:=storageClass;image
+type VolumeType string
+
+const (
+	// VolumeTypeStorageClass is a blank volume backed by a PVC using a StorageClass in Harvester.
+	VolumeTypeStorageClass VolumeType = "storageClass"
+	// VolumeTypeImage is a volume backed by a PVC created from a VM image in Harvester.
+	VolumeTypeImage VolumeType = "image"
+)
+
+// HarvesterMachineStatus defines the observed state of HarvesterMachine.
+type HarvesterMachineStatus struct {
+	// Ready is true when the provider resource is ready.
+	Ready bool `json:"ready,omitempty"`
+
+	Conditions []capiv1beta1.Condition `json:"conditions,omitempty"`
+
+	FailureReason  string                       `json:"failureReason,omitempty"`
+	FailureMessage string                       `json:"failureMessage,omitempty"`
+	Addresses      []capiv1beta1.MachineAddress `json:"addresses,omitempty"`
+
+	// StaticAddresses are the static addresses allocated to the interfaces of the VM.
+	// They are released when the HarvesterMachine is deleted.
+	// +optional
+	StaticAddresses []StaticAddress `json:"staticAddresses,omitempty"`
+
+	// VMState is the printable status of the VM in Harvester, such as Starting, Running or ErrorUnschedulable.
+	// +optional
+	VMState string `json:"vmState,omitempty"`
+}
+
+// StaticAddress is a static address allocated to an interface of the VM.
+type StaticAddress struct {
+	// Interface is the name of the VM interface the address is allocated to.
+	Interface string `json:"interface"`
+
+	// Address is the IP address.
+	Address string `json:"address"`
+
+	// Prefix is the prefix length of the subnet of the address.
+	Prefix int32 `json:"prefix"`
+
+	// Gateway is the gateway of the subnet of the address.
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+
+	// IPPool is the name of the IP Pool in Harvester the address was allocated from, if any.
+	// +optional
+	IPPool string `json:"ipPool,omitempty"`
+
+	// IPAddressClaim is the name of the IPAddressClaim the address was claimed with, if any.
+	// +optional
+	IPAddressClaim string `json:"ipAddressClaim,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="HarvesterMachine is ready"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.vmState",description="Printable status of the VM in Harvester"
+// +kubebuilder:printcolumn:name="Addresses",type="string",JSONPath=".status.addresses[*].address",description="IP addresses of the VM"
+// +kubebuilder:printcolumn:name="ProviderID",type="string",JSONPath=".spec.providerID",description="Provider ID of the Node of the VM",priority=1
+
+// HarvesterMachine is the Schema for the harvestermachines API.
+type HarvesterMachine struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HarvesterMachineSpec   `json:"spec,omitempty"`
+	Status HarvesterMachineStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// HarvesterMachineList contains a list of HarvesterMachine.
+type HarvesterMachineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HarvesterMachine `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HarvesterMachine{}, &HarvesterMachineList{})
+}
+
+// GetConditions returns the set of conditions for this object.
+func (m *HarvesterMachine) GetConditions() clusterv1.Conditions {
+	return m.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (m *HarvesterMachine) SetConditions(conditions clusterv1.Conditions) {
+	m.Status.Conditions = conditions
+}
@@ -0,0 +1,371 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager sets up and registers the webhooks for HarvesterMachine with the manager.
+func (r *HarvesterMachine) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1alpha2-harvestermachine,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=harvestermachines,verbs=create;update,versions=v1alpha2,name=default.harvestermachine.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &HarvesterMachine{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (r *HarvesterMachine) Default() {
+	defaultHarvesterMachineSpec(&r.Spec)
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1alpha2-harvestermachine,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=harvestermachines,verbs=create;update,versions=v1alpha2,name=validation.harvestermachine.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &HarvesterMachine{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *HarvesterMachine) ValidateCreate() (admission.Warnings, error) {
+	allErrs := validateHarvesterMachineSpec(r.Spec, field.NewPath("spec"))
+
+	return nil, aggregateObjErrors(GroupVersion.WithKind("HarvesterMachine").GroupKind(), r.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *HarvesterMachine) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	oldMachine, ok := old.(*HarvesterMachine)
+	if !ok {
+		return nil, fmt.Errorf("expected a HarvesterMachine but got a %T", old)
+	}
+
+	// Objects being deleted are not validated, so that removing the finalizers is never blocked.
+	if !r.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	// The old spec is defaulted, since it may have been stored before new fields got default values.
+	oldSpec := oldMachine.Spec.DeepCopy()
+	defaultHarvesterMachineSpec(oldSpec)
+
+	allErrs := validateHarvesterMachineSpecUpdate(*oldSpec, r.Spec, field.NewPath("spec"))
+
+	return nil, aggregateObjErrors(GroupVersion.WithKind("HarvesterMachine").GroupKind(), r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *HarvesterMachine) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+// defaultHarvesterMachineSpec sets the default values of a HarvesterMachineSpec.
+// It is shared by HarvesterMachine and HarvesterMachineTemplate.
+func defaultHarvesterMachineSpec(spec *HarvesterMachineSpec) {
+	if spec.CPU.Sockets == 0 {
+		spec.CPU.Sockets = 1
+	}
+
+	if spec.CPU.Threads == 0 {
+		spec.CPU.Threads = 1
+	}
+
+	for i := range spec.Volumes {
+		volume := &spec.Volumes[i]
+
+		if volume.VolumeType == "" {
+			switch {
+			case volume.Image != nil:
+				volume.VolumeType = VolumeTypeImage
+			case volume.StorageClass != "":
+				volume.VolumeType = VolumeTypeStorageClass
+			}
+		}
+
+		if volume.VolumeSize == nil {
+			volumeSize := resource.MustParse(DefaultVolumeSize)
+			volume.VolumeSize = &volumeSize
+		}
+	}
+
+	for i := range spec.Networks {
+		network := &spec.Networks[i]
+
+		if network.Binding == "" {
+			network.Binding = InterfaceBindingBridge
+		}
+
+		if network.Model == "" && network.Binding != InterfaceBindingSRIOV {
+			network.Model = DefaultInterfaceModel
+		}
+
+		if network.IPAM != nil && network.IPAM.Type == "" {
+			network.IPAM.Type = NetworkIPAMTypeDHCP
+		}
+	}
+}
+
+// validateHarvesterMachineSpec validates a HarvesterMachineSpec.
+// It is shared by HarvesterMachine and HarvesterMachineTemplate.
+func validateHarvesterMachineSpec(spec HarvesterMachineSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, validateCPU(spec.CPU, fldPath.Child("cpu"))...)
+
+	allErrs = append(allErrs, validateMemory(spec.Memory, fldPath.Child("memory"))...)
+
+	allErrs = append(allErrs, validateObjectReference(spec.SSHKeyPair, fldPath.Child("sshKeyPair"))...)
+
+	allErrs = append(allErrs, validateVolumes(spec.Volumes, fldPath.Child("volumes"))...)
+
+	allErrs = append(allErrs, validateNetworks(spec.Networks, fldPath.Child("networks"))...)
+
+	return allErrs
+}
+
+// validateHarvesterMachineSpecUpdate validates the fields of a HarvesterMachineSpec which changed, so that objects stored
+// before a validation was added can still be updated, e.g. by the Machine controller setting the providerID.
+func validateHarvesterMachineSpecUpdate(oldSpec, newSpec HarvesterMachineSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if !apiequality.Semantic.DeepEqual(oldSpec.CPU, newSpec.CPU) {
+		allErrs = append(allErrs, validateCPU(newSpec.CPU, fldPath.Child("cpu"))...)
+	}
+
+	if !apiequality.Semantic.DeepEqual(oldSpec.Memory, newSpec.Memory) {
+		allErrs = append(allErrs, validateMemory(newSpec.Memory, fldPath.Child("memory"))...)
+	}
+
+	if !apiequality.Semantic.DeepEqual(oldSpec.SSHKeyPair, newSpec.SSHKeyPair) {
+		allErrs = append(allErrs, validateObjectReference(newSpec.SSHKeyPair, fldPath.Child("sshKeyPair"))...)
+	}
+
+	if !apiequality.Semantic.DeepEqual(oldSpec.Volumes, newSpec.Volumes) {
+		allErrs = append(allErrs, validateVolumes(newSpec.Volumes, fldPath.Child("volumes"))...)
+	}
+
+	if !apiequality.Semantic.DeepEqual(oldSpec.Networks, newSpec.Networks) {
+		allErrs = append(allErrs, validateNetworks(newSpec.Networks, fldPath.Child("networks"))...)
+	}
+
+	return allErrs
+}
+
+func validateMemory(memory resource.Quantity, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if memory.IsZero() {
+		allErrs = append(allErrs, field.Required(fldPath, "memory must be set"))
+	} else if memory.Sign() < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath, memory.String(), "must be greater than 0"))
+	}
+
+	return allErrs
+}
+
+func validateNetworks(networks []Network, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(networks) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one network must be set"))
+	}
+
+	primary := -1
+	masquerade := -1
+	macAddresses := map[string]int{}
+
+	for i, network := range networks {
+		networkPath := fldPath.Index(i)
+
+		switch network.Binding {
+		case InterfaceBindingBridge, InterfaceBindingSRIOV:
+			if network.VMNetwork == nil {
+				allErrs = append(allErrs, field.Required(networkPath.Child("vmNetwork"),
+					fmt.Sprintf("vmNetwork must be set when binding is %s", network.Binding)))
+			}
+		case InterfaceBindingMasquerade:
+			if network.VMNetwork != nil {
+				allErrs = append(allErrs, field.Forbidden(networkPath.Child("vmNetwork"),
+					"vmNetwork must not be set when binding is masquerade, the management network is used"))
+			}
+
+			if masquerade >= 0 {
+				allErrs = append(allErrs, field.Invalid(networkPath.Child("binding"), network.Binding,
+					fmt.Sprintf("only one network can use the masquerade binding, already used by network %d", masquerade)))
+			} else {
+				masquerade = i
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(networkPath.Child("binding"), network.Binding,
+				[]string{string(InterfaceBindingBridge), string(InterfaceBindingMasquerade), string(InterfaceBindingSRIOV)}))
+		}
+
+		if network.VMNetwork != nil {
+			allErrs = append(allErrs, validateObjectReference(*network.VMNetwork, networkPath.Child("vmNetwork"))...)
+		}
+
+		if network.Binding == InterfaceBindingSRIOV && network.Model != "" {
+			allErrs = append(allErrs, field.Forbidden(networkPath.Child("model"), "model must not be set when binding is sriov"))
+		}
+
+		if network.MACAddress != "" {
+			if _, err := net.ParseMAC(network.MACAddress); err != nil {
+				allErrs = append(allErrs, field.Invalid(networkPath.Child("macAddress"), network.MACAddress, err.Error()))
+			} else if j, ok := macAddresses[strings.ToLower(network.MACAddress)]; ok {
+				allErrs = append(allErrs, field.Invalid(networkPath.Child("macAddress"), network.MACAddress,
+					fmt.Sprintf("must be unique, already used by network %d", j)))
+			} else {
+				macAddresses[strings.ToLower(network.MACAddress)] = i
+			}
+		}
+
+		if network.IPAM != nil {
+			allErrs = append(allErrs, validateNetworkIPAM(network, networkPath.Child("ipam"))...)
+		}
+
+		if network.Primary {
+			if primary >= 0 {
+				allErrs = append(allErrs, field.Invalid(networkPath.Child("primary"), network.Primary,
+					fmt.Sprintf("only one network can be primary, network %d already is", primary)))
+			} else {
+				primary = i
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateNetworkIPAM(network Network, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	ipam := network.IPAM
+
+	switch ipam.Type {
+	case NetworkIPAMTypeDHCP:
+	case NetworkIPAMTypeIPPool:
+		if ipam.IPPoolRef == nil || ipam.IPPoolRef.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("ipPoolRef", "name"), "ipPoolRef must be set when type is IPPool"))
+		}
+	case NetworkIPAMTypeIPAddressClaim:
+		if ipam.PoolRef == nil || ipam.PoolRef.Name == "" || ipam.PoolRef.Kind == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("poolRef"), "poolRef must be set with a kind and a name when type is IPAddressClaim"))
+		}
+	default:
+		return append(allErrs, field.NotSupported(fldPath.Child("type"), ipam.Type,
+			[]string{string(NetworkIPAMTypeDHCP), string(NetworkIPAMTypeIPPool), string(NetworkIPAMTypeIPAddressClaim)}))
+	}
+
+	if ipam.Type != NetworkIPAMTypeIPPool && ipam.IPPoolRef != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("ipPoolRef"), "ipPoolRef can only be set when type is IPPool"))
+	}
+
+	if ipam.Type != NetworkIPAMTypeIPAddressClaim && ipam.PoolRef != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("poolRef"), "poolRef can only be set when type is IPAddressClaim"))
+	}
+
+	if ipam.Type != NetworkIPAMTypeDHCP && network.Binding == InterfaceBindingMasquerade {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("type"), "static addresses are not supported with the masquerade binding"))
+	}
+
+	for i, nameserver := range ipam.Nameservers {
+		if net.ParseIP(nameserver) == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("nameservers").Index(i), nameserver, "must be a valid IP address"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateCPU(cpu CPU, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if cpu.Cores <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("cores"), cpu.Cores, "must be greater than 0"))
+	}
+
+	if cpu.Sockets <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("sockets"), cpu.Sockets, "must be greater than 0"))
+	}
+
+	if cpu.Threads <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("threads"), cpu.Threads, "must be greater than 0"))
+	}
+
+	return allErrs
+}
+
+func validateVolumes(volumes []Volume, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	hasImageVolume := false
+	bootOrders := map[int]int{}
+
+	for i, volume := range volumes {
+		volumePath := fldPath.Index(i)
+
+		switch volume.VolumeType {
+		case VolumeTypeImage:
+			hasImageVolume = true
+
+			if volume.Image == nil {
+				allErrs = append(allErrs, field.Required(volumePath.Child("image"), "image must be set when volumeType is image"))
+			} else {
+				allErrs = append(allErrs, validateObjectReference(*volume.Image, volumePath.Child("image"))...)
+			}
+		case VolumeTypeStorageClass:
+			if volume.StorageClass == "" {
+				allErrs = append(allErrs, field.Required(volumePath.Child("storageClass"),
+					"storageClass must be set when volumeType is storageClass"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(volumePath.Child("volumeType"), volume.VolumeType,
+				[]string{string(VolumeTypeImage), string(VolumeTypeStorageClass)}))
+		}
+
+		if volume.VolumeSize == nil {
+			allErrs = append(allErrs, field.Required(volumePath.Child("volumeSize"), "volumeSize must be set"))
+		} else if volume.VolumeSize.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(volumePath.Child("volumeSize"), volume.VolumeSize.String(), "must be greater than 0"))
+		}
+
+		if volume.BootOrder < 0 {
+			allErrs = append(allErrs, field.Invalid(volumePath.Child("bootOrder"), volume.BootOrder, "must not be negative"))
+		} else if volume.BootOrder > 0 {
+			if j, ok := bootOrders[volume.BootOrder]; ok {
+				allErrs = append(allErrs, field.Invalid(volumePath.Child("bootOrder"), volume.BootOrder,
+					fmt.Sprintf("must be unique, already used by volume %d", j)))
+			} else {
+				bootOrders[volume.BootOrder] = i
+			}
+		}
+	}
+
+	if !hasImageVolume {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one volume of type image is required to boot the VM"))
+	}
+
+	return allErrs
+}
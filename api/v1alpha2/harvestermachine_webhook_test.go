@@ -0,0 +1,365 @@
+package v1alpha2
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func newTestHarvesterMachineSpec() HarvesterMachineSpec {
+	return HarvesterMachineSpec{
+		CPU:        CPU{Cores: 2},
+		Memory:     resource.MustParse("4Gi"),
+		SSHUser:    "ubuntu",
+		SSHKeyPair: ObjectReference{Namespace: "default", Name: "capi-ssh-key"},
+		Volumes: []Volume{
+			{
+				Image: &ObjectReference{Namespace: "default", Name: "ubuntu-22.04"},
+			},
+			{
+				StorageClass: "longhorn",
+				VolumeSize:   resource.NewQuantity(10*1024*1024*1024, resource.BinarySI),
+			},
+		},
+		Networks: []Network{{VMNetwork: &ObjectReference{Namespace: "default", Name: "vm-network"}}},
+	}
+}
+
+var _ = Describe("HarvesterMachine webhook", func() {
+	var hvMachine *HarvesterMachine
+
+	BeforeEach(func() {
+		hvMachine = &HarvesterMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-machine",
+				Namespace: "default",
+			},
+			Spec: newTestHarvesterMachineSpec(),
+		}
+	})
+
+	Context("When defaulting a HarvesterMachine", func() {
+		It("Should infer the volume types and default the volume size", func() {
+			hvMachine.Default()
+
+			Expect(hvMachine.Spec.Volumes[0].VolumeType).To(Equal(VolumeTypeImage))
+			Expect(hvMachine.Spec.Volumes[0].VolumeSize.String()).To(Equal(DefaultVolumeSize))
+			Expect(hvMachine.Spec.Volumes[1].VolumeType).To(Equal(VolumeTypeStorageClass))
+			Expect(hvMachine.Spec.Volumes[1].VolumeSize.String()).To(Equal("10Gi"))
+		})
+	})
+
+	Context("When defaulting the CPU topology", func() {
+		It("Should default sockets and threads to 1", func() {
+			hvMachine.Default()
+
+			Expect(hvMachine.Spec.CPU).To(Equal(CPU{Cores: 2, Sockets: 1, Threads: 1}))
+			Expect(hvMachine.Spec.CPU.VCPUs()).To(Equal(int64(2)))
+		})
+
+		It("Should keep an explicit topology", func() {
+			hvMachine.Spec.CPU = CPU{Cores: 2, Sockets: 2, Threads: 2}
+			hvMachine.Default()
+
+			Expect(hvMachine.Spec.CPU.VCPUs()).To(Equal(int64(8)))
+		})
+	})
+
+	Context("When defaulting the networks", func() {
+		It("Should default the binding and the model", func() {
+			hvMachine.Spec.Networks = append(hvMachine.Spec.Networks, Network{
+				VMNetwork: &ObjectReference{Name: "sriov-network"},
+				Binding:   InterfaceBindingSRIOV,
+			})
+			hvMachine.Default()
+
+			Expect(hvMachine.Spec.Networks[0].Binding).To(Equal(InterfaceBindingBridge))
+			Expect(hvMachine.Spec.Networks[0].Model).To(Equal(DefaultInterfaceModel))
+			Expect(hvMachine.Spec.Networks[1].Model).To(BeEmpty())
+		})
+	})
+
+	Context("When validating a new HarvesterMachine", func() {
+		BeforeEach(func() {
+			hvMachine.Default()
+		})
+
+		It("Should accept a valid HarvesterMachine", func() {
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject a negative memory", func() {
+			hvMachine.Spec.Memory = resource.MustParse("-4Gi")
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a zero memory", func() {
+			hvMachine.Spec.Memory = resource.MustParse("0")
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a zero CPU count", func() {
+			hvMachine.Spec.CPU.Cores = 0
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a negative socket count", func() {
+			hvMachine.Spec.CPU.Sockets = -1
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a machine without image volume", func() {
+			hvMachine.Spec.Volumes = hvMachine.Spec.Volumes[1:]
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a storageClass volume without storage class", func() {
+			hvMachine.Spec.Volumes[1].StorageClass = ""
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should accept distinct boot orders", func() {
+			hvMachine.Spec.Volumes[0].BootOrder = 2
+			hvMachine.Spec.Volumes[1].BootOrder = 1
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject a duplicate boot order", func() {
+			hvMachine.Spec.Volumes[0].BootOrder = 1
+			hvMachine.Spec.Volumes[1].BootOrder = 1
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject an image volume without image", func() {
+			hvMachine.Spec.Volumes[0].Image = nil
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a malformed image reference", func() {
+			hvMachine.Spec.Volumes[0].Image.Name = "ubuntu/22.04"
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a malformed network reference", func() {
+			hvMachine.Spec.Networks[0].VMNetwork = &ObjectReference{Namespace: "Default", Name: "VM_Network"}
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should accept a masquerade network without VM network", func() {
+			hvMachine.Spec.Networks = append(hvMachine.Spec.Networks, Network{Binding: InterfaceBindingMasquerade})
+			hvMachine.Default()
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject a bridge network without VM network", func() {
+			hvMachine.Spec.Networks[0].VMNetwork = nil
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a malformed MAC address", func() {
+			hvMachine.Spec.Networks[0].MACAddress = "02:00:00:00:00"
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject several primary networks", func() {
+			hvMachine.Spec.Networks[0].Primary = true
+			hvMachine.Spec.Networks = append(hvMachine.Spec.Networks, Network{
+				VMNetwork: &ObjectReference{Name: "vm-network-2"},
+				Primary:   true,
+			})
+			hvMachine.Default()
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a model on a SR-IOV network", func() {
+			hvMachine.Spec.Networks[0].Binding = InterfaceBindingSRIOV
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should accept a network with an address from an IP Pool", func() {
+			hvMachine.Spec.Networks[0].IPAM = &NetworkIPAM{
+				Type:        NetworkIPAMTypeIPPool,
+				IPPoolRef:   &IPPoolReference{Name: "vm-pool"},
+				Nameservers: []string{"8.8.8.8"},
+			}
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject an IPAddressClaim network without pool", func() {
+			hvMachine.Spec.Networks[0].IPAM = &NetworkIPAM{Type: NetworkIPAMTypeIPAddressClaim}
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject an IP Pool reference with the DHCP type", func() {
+			hvMachine.Spec.Networks[0].IPAM = &NetworkIPAM{
+				Type:      NetworkIPAMTypeDHCP,
+				IPPoolRef: &IPPoolReference{Name: "vm-pool"},
+			}
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a malformed nameserver", func() {
+			hvMachine.Spec.Networks[0].IPAM = &NetworkIPAM{
+				Type:        NetworkIPAMTypeIPPool,
+				IPPoolRef:   &IPPoolReference{Name: "vm-pool"},
+				Nameservers: []string{"dns.example.com"},
+			}
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a machine without networks", func() {
+			hvMachine.Spec.Networks = nil
+			_, err := hvMachine.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When validating an updated HarvesterMachine", func() {
+		BeforeEach(func() {
+			hvMachine.Default()
+		})
+
+		It("Should accept setting the providerID", func() {
+			newMachine := hvMachine.DeepCopy()
+			newMachine.Spec.ProviderID = "harvester://default/test-machine"
+
+			_, err := newMachine.ValidateUpdate(hvMachine)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should accept changing the CPU count", func() {
+			newMachine := hvMachine.DeepCopy()
+			newMachine.Spec.CPU.Cores = 4
+
+			_, err := newMachine.ValidateUpdate(hvMachine)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject an invalid change", func() {
+			newMachine := hvMachine.DeepCopy()
+			newMachine.Spec.CPU.Cores = 0
+
+			_, err := newMachine.ValidateUpdate(hvMachine)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should not validate the fields which did not change", func() {
+			hvMachine.Spec.Networks = nil
+			newMachine := hvMachine.DeepCopy()
+			newMachine.Spec.ProviderID = "harvester://default/test-machine"
+
+			_, err := newMachine.ValidateUpdate(hvMachine)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should not validate a HarvesterMachine being deleted", func() {
+			newMachine := hvMachine.DeepCopy()
+			newMachine.Spec.CPU.Cores = 0
+			newMachine.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+			_, err := newMachine.ValidateUpdate(hvMachine)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("HarvesterMachineTemplate webhook", func() {
+	dryRunDisabled, dryRunEnabled := false, true
+
+	var (
+		hvTemplate *HarvesterMachineTemplate
+		w          *HarvesterMachineTemplateWebhook
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		hvTemplate = &HarvesterMachineTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-template",
+				Namespace: "default",
+			},
+			Spec: HarvesterMachineTemplateSpec{
+				Template: HarvesterMachineTemplateResource{
+					Spec: newTestHarvesterMachineSpec(),
+				},
+			},
+		}
+		w = &HarvesterMachineTemplateWebhook{}
+		ctx = admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				DryRun: &dryRunDisabled,
+			},
+		})
+
+		Expect(w.Default(ctx, hvTemplate)).To(Succeed())
+	})
+
+	It("Should default the template spec", func() {
+		Expect(hvTemplate.Spec.Template.Spec.Volumes[0].VolumeType).To(Equal(VolumeTypeImage))
+		Expect(hvTemplate.Spec.Template.Spec.Volumes[0].VolumeSize).NotTo(BeNil())
+	})
+
+	It("Should accept a valid HarvesterMachineTemplate", func() {
+		_, err := w.ValidateCreate(ctx, hvTemplate)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should reject an invalid HarvesterMachineTemplate", func() {
+		hvTemplate.Spec.Template.Spec.CPU.Cores = 0
+		_, err := w.ValidateCreate(ctx, hvTemplate)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should reject changes to the template spec", func() {
+		newTemplate := hvTemplate.DeepCopy()
+		newTemplate.Spec.Template.Spec.CPU.Cores = 8
+
+		_, err := w.ValidateUpdate(ctx, hvTemplate, newTemplate)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should allow dry-run changes from the topology controller", func() {
+		newTemplate := hvTemplate.DeepCopy()
+		newTemplate.Spec.Template.Spec.CPU.Cores = 8
+		newTemplate.Annotations = map[string]string{clusterv1.TopologyDryRunAnnotation: ""}
+
+		dryRunCtx := admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				DryRun: &dryRunEnabled,
+			},
+		})
+
+		_, err := w.ValidateUpdate(dryRunCtx, hvTemplate, newTemplate)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
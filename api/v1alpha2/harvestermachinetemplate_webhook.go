@@ -0,0 +1,119 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"fmt"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"sigs.k8s.io/cluster-api/util/topology"
+)
+
+// HarvesterMachineTemplateWebhook implements a custom defaulting and validation webhook for HarvesterMachineTemplate.
+// A custom webhook is needed because the immutability check relies on the admission request,
+// which is not available to webhook.Validator implementations.
+// +kubebuilder:object:generate=false
+type HarvesterMachineTemplateWebhook struct{}
+
+// SetupWebhookWithManager sets up and registers the webhooks for HarvesterMachineTemplate with the manager.
+func (w *HarvesterMachineTemplateWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&HarvesterMachineTemplate{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1alpha2-harvestermachinetemplate,mutating=true,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=harvestermachinetemplates,verbs=create;update,versions=v1alpha2,name=default.harvestermachinetemplate.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+var _ webhook.CustomDefaulter = &HarvesterMachineTemplateWebhook{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type.
+func (w *HarvesterMachineTemplateWebhook) Default(_ context.Context, obj runtime.Object) error {
+	template, ok := obj.(*HarvesterMachineTemplate)
+	if !ok {
+		return fmt.Errorf("expected a HarvesterMachineTemplate but got a %T", obj)
+	}
+
+	defaultHarvesterMachineSpec(&template.Spec.Template.Spec)
+
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1alpha2-harvestermachinetemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=harvestermachinetemplates,verbs=create;update,versions=v1alpha2,name=validation.harvestermachinetemplate.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+var _ webhook.CustomValidator = &HarvesterMachineTemplateWebhook{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (w *HarvesterMachineTemplateWebhook) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	template, ok := obj.(*HarvesterMachineTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a HarvesterMachineTemplate but got a %T", obj)
+	}
+
+	allErrs := validateHarvesterMachineSpec(template.Spec.Template.Spec, field.NewPath("spec", "template", "spec"))
+
+	return nil, aggregateObjErrors(GroupVersion.WithKind("HarvesterMachineTemplate").GroupKind(), template.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type.
+func (w *HarvesterMachineTemplateWebhook) ValidateUpdate(
+	ctx context.Context, oldObj, newObj runtime.Object,
+) (admission.Warnings, error) {
+	oldTemplate, ok := oldObj.(*HarvesterMachineTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a HarvesterMachineTemplate but got a %T", oldObj)
+	}
+
+	newTemplate, ok := newObj.(*HarvesterMachineTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a HarvesterMachineTemplate but got a %T", newObj)
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("expected an admission.Request inside context: %w", err)
+	}
+
+	templatePath := field.NewPath("spec", "template", "spec")
+	allErrs := validateHarvesterMachineSpec(newTemplate.Spec.Template.Spec, templatePath)
+
+	// The old spec is defaulted as well, since it may have been stored before new fields got default values.
+	oldSpec := oldTemplate.Spec.Template.Spec.DeepCopy()
+	defaultHarvesterMachineSpec(oldSpec)
+
+	// Machines are created from a snapshot of the template, so a change would never reach existing machines.
+	// The topology controller is allowed to perform dry-run updates to detect changes.
+	if !topology.ShouldSkipImmutabilityChecks(req, newTemplate) &&
+		!apiequality.Semantic.DeepEqual(*oldSpec, newTemplate.Spec.Template.Spec) {
+		allErrs = append(allErrs, field.Forbidden(templatePath, "HarvesterMachineTemplate spec.template.spec field is immutable"))
+	}
+
+	return nil, aggregateObjErrors(GroupVersion.WithKind("HarvesterMachineTemplate").GroupKind(), newTemplate.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type.
+func (w *HarvesterMachineTemplateWebhook) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
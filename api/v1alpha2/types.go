@@ -0,0 +1,54 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ObjectReference is a reference to a namespaced object in Harvester.
+type ObjectReference struct {
+	// Namespace is the namespace of the object in Harvester.
+	// If empty, the TargetNamespace of the HarvesterCluster is used.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the name of the object in Harvester.
+	Name string `json:"name"`
+}
+
+// String returns the reference with the format "namespace/name", or "name" if the namespace is empty.
+func (r ObjectReference) String() string {
+	if r.Namespace == "" {
+		return r.Name
+	}
+
+	return r.Namespace + "/" + r.Name
+}
+
+// NamespacedName returns the reference as a types.NamespacedName, using defaultNamespace if the namespace is empty.
+func (r ObjectReference) NamespacedName(defaultNamespace string) types.NamespacedName {
+	namespace := r.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	return types.NamespacedName{
+		Namespace: namespace,
+		Name:      r.Name,
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Actions done on the resources in Harvester, which are recorded as events on the HarvesterCluster or HarvesterMachine.
+// The reason of the event is Successful<Action> or Failed<Action>, like the events of the Kubernetes controllers.
+const (
+	harvesterActionCreate   = "Create"
+	harvesterActionUpdate   = "Update"
+	harvesterActionDelete   = "Delete"
+	harvesterActionAllocate = "Allocate"
+	harvesterActionRelease  = "Release"
+)
+
+// machineFailedEventReason is the reason of the event recorded when a HarvesterMachine fails with a terminal error.
+const machineFailedEventReason = "MachineFailed"
+
+// loadBalancerAddressMismatchEventReason is the reason of the event recorded when the load balancer in Harvester
+// did not get the address reserved for it.
+const loadBalancerAddressMismatchEventReason = "LoadBalancerAddressMismatch"
+
+// recordHarvesterEvent records an event on an object for an action done on a resource in Harvester:
+// a Normal event when the action succeeded, a Warning event with the error otherwise.
+// Nothing is recorded without a recorder.
+func recordHarvesterEvent(recorder record.EventRecorder, object runtime.Object, action string, resource string, err error) {
+	if recorder == nil {
+		return
+	}
+
+	if err != nil {
+		recorder.Eventf(object, corev1.EventTypeWarning, "Failed"+action,
+			"Failed to %s %s in Harvester: %v", strings.ToLower(action), resource, err)
+
+		return
+	}
+
+	// All the actions are regular verbs
+	recorder.Eventf(object, corev1.EventTypeNormal, "Successful"+action, "%sd %s in Harvester", action, resource)
+}
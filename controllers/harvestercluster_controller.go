@@ -21,25 +21,26 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
-	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/plugins/ipam/host-local/backend/allocator"
 	"github.com/go-logr/logr"
 	lbv1beta1 "github.com/harvester/harvester-load-balancer/pkg/apis/loadbalancer.harvesterhci.io/v1beta1"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	kubeclient "k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -50,24 +51,25 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	capiutil "sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 
-	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha1"
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
 	lbclient "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/clientset/versioned"
+	locmetrics "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/metrics"
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/remote"
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/tracing"
 	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
 )
 
 const (
 	harvesterNamespace           = "harvester-system"
 	harvesterDeploymentName      = "harvester"
+	harvesterVersionLabel        = "app.kubernetes.io/version"
 	availableConditionType       = "Available"
-	lbHealthCheckPeriodSections  = 30
-	lbHealthCheckTimeoutSections = 60
 	apiServerListener            = "api-server"
-	apiServerLBPort              = 6443
-	apiServerBackendPort         = 6443
 	apiServerProtocol            = "TCP"
 	cpIPPoolDescriptionPrefix    = "IP Pool for the control plane's LB of cluster"
 	cpVMLabelKey                 = "harvestercluster/machinetype"
@@ -76,14 +78,33 @@ const (
 	requeueTimeMedium            = 5 * time.Minute
 	requeueTimeLong              = 3 * time.Minute
 	dhcpLbIP                     = "0.0.0.0"
-	failureThreshold             = 3
 	cloudProviderTargetNamespace = "kube-system"
 )
 
+// harvesterClusterOwnedConditions are the conditions of a HarvesterCluster set by its controller, whose values
+// take precedence over the ones in the API server when the HarvesterCluster is patched.
+var harvesterClusterOwnedConditions = []clusterv1.ConditionType{
+	clusterv1.ReadyCondition,
+	infrav1.LoadBalancerReadyCondition,
+	infrav1.LoadBalancerSpecInSyncCondition,
+	infrav1.CustomIPPoolCreatedCondition,
+	infrav1.KubeVIPAddressReservedCondition,
+	infrav1.ControlPlaneEndpointReachableCondition,
+	infrav1.CloudProviderConfigReadyCondition,
+}
+
 // HarvesterClusterReconciler reconciles a HarvesterCluster object.
 type HarvesterClusterReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// ClientCache shares the clients of the Harvester APIs between the reconciliations. When nil, clients are built each time.
+	ClientCache *locutil.ClientCache
+
+	// Tracker watches the load balancers in Harvester to reconcile their HarvesterClusters when they change.
+	// When nil, the load balancers are polled.
+	Tracker *remote.Tracker
 }
 
 // ClusterScope is a struct that contains the necessary data needed for a HarvesterCluster controller.
@@ -94,6 +115,10 @@ type ClusterScope struct {
 	Ctx              context.Context
 	HarvesterClient  lbclient.Interface
 	ReconcileClient  client.Client
+	EventRecorder    record.EventRecorder
+	// LoadBalancerWatched is true when the changes of the load balancer in Harvester trigger the reconciliation,
+	// which then does not need to poll.
+	LoadBalancerWatched bool
 }
 
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=harvesterclusters,verbs=get;list;watch;create;update;patch;delete
@@ -102,10 +127,16 @@ type ClusterScope struct {
 //+kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status;machinesets;machines;machines/status;machinepools;machinepools/status,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile reads that state of the cluster for a HarvesterCluster object and makes changes based on the state read.
-func (r *HarvesterClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *HarvesterClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, rerr error) {
 	logger := log.FromContext(ctx)
+
+	ctx, span := tracing.StartSpan(ctx, "HarvesterCluster.Reconcile",
+		attribute.String("namespace", req.Namespace), attribute.String("name", req.Name))
+	defer func() { tracing.EndSpan(span, rerr) }()
+
 	logger.Info("Reconciling HarvesterCluster", "cluster-name", req.NamespacedName.Name, "cluster-namespace", req.NamespacedName.Namespace)
 
 	var cluster infrav1.HarvesterCluster
@@ -129,7 +160,10 @@ func (r *HarvesterClusterReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	defer func() {
-		if err := patchHelper.Patch(ctx, &cluster); err != nil {
+		cluster.Status.ObservedGeneration = cluster.Generation
+		conditions.SetSummary(&cluster, conditions.WithConditions(getReadyConditions(&cluster)...))
+
+		if err := patchHelper.Patch(ctx, &cluster, patch.WithOwnedConditions{Conditions: harvesterClusterOwnedConditions}); err != nil {
 			clusterString := cluster.Namespace + "/" + cluster.Name
 			logger.Error(err, "unable to patch", "cluster", clusterString)
 		}
@@ -148,17 +182,8 @@ func (r *HarvesterClusterReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, nil
 	}
 
-	var hvRESTConfig *rest.Config
-
-	if hvRESTConfig, err = r.reconcileHarvesterConfig(ctx, &cluster); err != nil {
-		return ctrl.Result{RequeueAfter: requeueTimeLong}, err
-	}
-
-	// get a Harvester Client
-	hvClient, err := lbclient.NewForConfig(hvRESTConfig)
+	hvClients, err := r.reconcileHarvesterConfig(ctx, &cluster)
 	if err != nil {
-		logger.Error(err, "unable to create kubernetes client from restConfig")
-
 		return ctrl.Result{RequeueAfter: requeueTimeLong}, err
 	}
 
@@ -167,15 +192,21 @@ func (r *HarvesterClusterReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		HarvesterCluster: &cluster,
 		Logger:           logger,
 		Ctx:              ctx,
-		HarvesterClient:  hvClient,
+		HarvesterClient:  hvClients.Harvester,
 		ReconcileClient:  r.Client,
+		EventRecorder:    r.Recorder,
 	}
 
 	// Handling DeletionTimestamp to decide if it is a Deletion or a Normal reconcile
 	if !cluster.DeletionTimestamp.IsZero() {
+		r.Tracker.UnwatchCluster(hvClients.Identity, cluster.Spec.TargetNamespace, req.NamespacedName)
+
 		return r.ReconcileDelete(scope) //nolint:contextcheck
 	}
 
+	scope.LoadBalancerWatched = r.Tracker.WatchCluster(hvClients, cluster.Spec.TargetNamespace,
+		[]string{getLoadBalancerReference(&cluster).Name, getPlaceholderServiceReference(&cluster).Name}, req.NamespacedName)
+
 	return r.ReconcileNormal(scope) //nolint:contextcheck
 }
 
@@ -183,6 +214,20 @@ const (
 	secretIdField = ".spec.identitySecret.name" //nolint:gosec
 )
 
+// getReadyConditions returns the conditions summarized in the Ready condition of a HarvesterCluster,
+// which depend on the type of its control plane endpoint.
+func getReadyConditions(cluster *infrav1.HarvesterCluster) []clusterv1.ConditionType {
+	switch cluster.Spec.LoadBalancerConfig.Type {
+	case infrav1.LoadBalancerTypeKubeVIP:
+		return []clusterv1.ConditionType{infrav1.KubeVIPAddressReservedCondition, infrav1.CloudProviderConfigReadyCondition}
+	case infrav1.LoadBalancerTypeExternal:
+		// The reachability of an external endpoint is only informative, it does not prevent the cluster from being ready.
+		return []clusterv1.ConditionType{infrav1.CloudProviderConfigReadyCondition}
+	default:
+		return []clusterv1.ConditionType{infrav1.LoadBalancerReadyCondition, infrav1.CloudProviderConfigReadyCondition}
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *HarvesterClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	if err := mgr.GetFieldIndexer().IndexField(ctx, &infrav1.HarvesterCluster{}, secretIdField, func(obj client.Object) []string {
@@ -202,17 +247,31 @@ func (r *HarvesterClusterReconciler) SetupWithManager(ctx context.Context, mgr c
 		return err
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1.HarvesterCluster{}).
+		Owns(&ipamv1.IPAddressClaim{}).
 		Watches(
 			&apiv1.Secret{},
 			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSecret),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
 		).
-		Complete(r)
+		Watches(
+			&ipamv1.IPAddress{},
+			handler.EnqueueRequestsFromMapFunc(ipAddressToClaimOwnerMapFunc(mgr.GetClient(), infrav1.GroupVersion.WithKind("HarvesterCluster"))),
+		)
+
+	// Reconcile the HarvesterClusters when their load balancer changes in Harvester
+	if r.Tracker != nil {
+		controllerBuilder = controllerBuilder.WatchesRawSource(r.Tracker.ClusterSource(), &handler.EnqueueRequestForObject{})
+	}
+
+	return controllerBuilder.Complete(r)
 }
 
 func (r *HarvesterClusterReconciler) findObjectsForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	// The clients built from the previous version of the secret are not valid anymore
+	r.ClientCache.Invalidate(secret.GetUID())
+
 	attachedClusters := &infrav1.HarvesterClusterList{}
 	listOps := &client.ListOptions{
 		FieldSelector: fields.OneTermEqualSelector(secretIdField, secret.GetName()),
@@ -253,6 +312,9 @@ func isHarvesterAvailable(conditions []appsv1.DeploymentCondition) bool {
 func (r *HarvesterClusterReconciler) ReconcileNormal(scope *ClusterScope) (res ctrl.Result, err error) {
 	logger := log.FromContext(scope.Ctx)
 
+	endPhase := tracing.StartPhase(&scope.Ctx, "ReconcileNormal")
+	defer func() { endPhase(err) }()
+
 	// Add finalizer first if not exist to avoid the race condition between init and delete
 	if !controllerutil.ContainsFinalizer(scope.HarvesterCluster, infrav1.ClusterFinalizer) {
 		controllerutil.AddFinalizer(scope.HarvesterCluster, infrav1.ClusterFinalizer)
@@ -261,10 +323,10 @@ func (r *HarvesterClusterReconciler) ReconcileNormal(scope *ClusterScope) (res c
 	}
 
 	// Check if TargetNamespace exists, if not create it
-	_, err = scope.HarvesterClient.CoreV1().Namespaces().Get(context.TODO(), scope.HarvesterCluster.Spec.TargetNamespace, v1.GetOptions{})
+	_, err = scope.HarvesterClient.CoreV1().Namespaces().Get(scope.Ctx, scope.HarvesterCluster.Spec.TargetNamespace, v1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			_, err = scope.HarvesterClient.CoreV1().Namespaces().Create(context.TODO(), &apiv1.Namespace{
+			_, err = scope.HarvesterClient.CoreV1().Namespaces().Create(scope.Ctx, &apiv1.Namespace{
 				ObjectMeta: v1.ObjectMeta{
 					Name: scope.HarvesterCluster.Spec.TargetNamespace,
 				},
@@ -272,11 +334,21 @@ func (r *HarvesterClusterReconciler) ReconcileNormal(scope *ClusterScope) (res c
 			if err != nil {
 				logger.Error(err, "unable to create TargetNamespace")
 			}
+
+			recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionCreate,
+				"namespace "+scope.HarvesterCluster.Spec.TargetNamespace, err)
 		} else {
 			logger.Error(err, "unable to get TargetNamespace in Harvester, problem with the HarvesterClient")
 		}
 	}
 
+	switch scope.HarvesterCluster.Spec.LoadBalancerConfig.Type {
+	case infrav1.LoadBalancerTypeKubeVIP:
+		return r.reconcileKubeVIP(scope)
+	case infrav1.LoadBalancerTypeExternal:
+		return r.reconcileExternalEndpoint(scope)
+	}
+
 	// Initializing return values
 	res = ctrl.Result{}
 
@@ -288,13 +360,17 @@ func (r *HarvesterClusterReconciler) ReconcileNormal(scope *ClusterScope) (res c
 	}
 
 	if len(ownedCPHarvesterMachines) == 0 {
-		// Give the LB a name that is RFC-1035 compliant
-		lbName := locutil.GenerateRFC1035Name([]string{scope.HarvesterCluster.Namespace, scope.HarvesterCluster.Name, "lb"})
-		lbNamespacedName := scope.HarvesterCluster.Spec.TargetNamespace + "/" + lbName
+		// The placeholder Service gets the address of the LB, which is allocated to the LB in the IP Pool
+		lbNamespacedName := getIPPoolApplicant(scope.HarvesterCluster)
+		placeholderRef := getPlaceholderServiceReference(scope.HarvesterCluster)
+
+		conditions.MarkFalse(scope.HarvesterCluster, infrav1.LoadBalancerReadyCondition,
+			infrav1.LoadBalancerNoBackendMachineReason, clusterv1.ConditionSeverityInfo,
+			"a placeholder Service is used for the control plane endpoint until the first control plane machine is created")
 		// Create a placeholder LoadBalancer svc to avoid blocking the CAPI Controller
-		existingPlaceholderLB, err1 := scope.HarvesterClient.CoreV1().Services(scope.HarvesterCluster.Spec.TargetNamespace).Get(
+		existingPlaceholderLB, err1 := scope.HarvesterClient.CoreV1().Services(placeholderRef.Namespace).Get(
 			scope.Ctx,
-			lbName,
+			placeholderRef.Name,
 			v1.GetOptions{})
 
 		if err1 != nil {
@@ -304,8 +380,14 @@ func (r *HarvesterClusterReconciler) ReconcileNormal(scope *ClusterScope) (res c
 				return ctrl.Result{RequeueAfter: requeueTimeMedium}, err1
 			}
 			lbIP := dhcpLbIP
-			if scope.HarvesterCluster.Spec.LoadBalancerConfig.IPAMType == infrav1.POOL {
+			if scope.HarvesterCluster.Spec.LoadBalancerConfig.IPAM.Type != infrav1.IPAMTypeDHCP {
 				lbIP, err = getIPFromIPPool(scope, lbNamespacedName)
+				if errors.Is(err, errIPAddressClaimPending) {
+					logger.Info("waiting for the IPAddressClaim of the load balancer to be fulfilled ...")
+
+					return ctrl.Result{RequeueAfter: requeueTimeShort}, nil
+				}
+
 				if err != nil {
 					logger.Error(err, "could not get IP from IP Pool")
 
@@ -313,28 +395,40 @@ func (r *HarvesterClusterReconciler) ReconcileNormal(scope *ClusterScope) (res c
 				}
 			}
 
-			err = createPlaceholderSVC(lbName, scope, lbIP)
+			err = createPlaceholderSVC(placeholderRef.Name, scope, lbIP)
 			if err != nil {
 				if !apierrors.IsAlreadyExists(err) {
 					scope.Logger.Error(err, "could not create the placeholder LoadBalancer")
+					recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionCreate,
+						"placeholder service "+placeholderRef.Namespace+"/"+placeholderRef.Name, err)
 
 					return ctrl.Result{Requeue: true}, err
 				} else {
 					scope.Logger.Info("placeholder LoadBalancer already exists, skipping ...")
 				}
+			} else {
+				recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionCreate,
+					"placeholder service "+placeholderRef.Namespace+"/"+placeholderRef.Name, nil)
 			}
 
-			res = ctrl.Result{RequeueAfter: requeueTimeShort}
+			scope.HarvesterCluster.Status.PlaceholderService = &placeholderRef
+
+			res = requeueForLoadBalancerChange(scope, requeueTimeShort)
 
 			return res, err
 
 		}
 
+		scope.HarvesterCluster.Status.PlaceholderService = &placeholderRef
+
+		placeholderAddresses := getLoadBalancerAddresses(scope.HarvesterCluster, getIngressIPs(existingPlaceholderLB.Status.LoadBalancer.Ingress))
+		primaryAddress := getPrimaryLoadBalancerAddress(scope.HarvesterCluster, placeholderAddresses)
+
 		// Requeue if the placeholder LoadBalancer IP is empty
-		if len(existingPlaceholderLB.Status.LoadBalancer.Ingress) == 0 || existingPlaceholderLB.Status.LoadBalancer.Ingress[0].IP == "" {
+		if primaryAddress == "" {
 			logger.Info("placeholder LoadBalancer IP is empty, waiting for IP to be set ...")
 
-			if scope.HarvesterCluster.Spec.LoadBalancerConfig.IPAMType == infrav1.POOL {
+			if scope.HarvesterCluster.Spec.LoadBalancerConfig.IPAM.Type != infrav1.IPAMTypeDHCP {
 				newLbIP, err := getIPFromIPPool(scope, lbNamespacedName)
 				if err != nil {
 					return ctrl.Result{RequeueAfter: requeueTimeShort}, err
@@ -342,8 +436,11 @@ func (r *HarvesterClusterReconciler) ReconcileNormal(scope *ClusterScope) (res c
 
 				existingPlaceholderLB.Spec.LoadBalancerIP = newLbIP
 
-				_, err = scope.HarvesterClient.CoreV1().Services(scope.HarvesterCluster.Spec.TargetNamespace).Update(context.TODO(),
+				_, err = scope.HarvesterClient.CoreV1().Services(scope.HarvesterCluster.Spec.TargetNamespace).Update(scope.Ctx,
 					existingPlaceholderLB, v1.UpdateOptions{})
+				recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionUpdate,
+					"placeholder service "+existingPlaceholderLB.Namespace+"/"+existingPlaceholderLB.Name, err)
+
 				if err != nil {
 					err = errors.Wrap(err, "could not update the placeholder LoadBalancer")
 
@@ -355,14 +452,15 @@ func (r *HarvesterClusterReconciler) ReconcileNormal(scope *ClusterScope) (res c
 				return ctrl.Result{Requeue: true}, nil
 			}
 
-			return ctrl.Result{RequeueAfter: requeueTimeShort}, nil
+			return requeueForLoadBalancerChange(scope, requeueTimeShort), nil
 		}
 
 		// res = ctrl.Result{RequeueAfter: 5 * time.Minute}
 		scope.HarvesterCluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{
-			Host: existingPlaceholderLB.Status.LoadBalancer.Ingress[0].IP,
-			Port: apiServerLBPort,
+			Host: primaryAddress,
+			Port: getAPIServerPort(scope.HarvesterCluster),
 		}
+		scope.HarvesterCluster.Status.LoadBalancerAddresses = placeholderAddresses
 		scope.HarvesterCluster.Status.Ready = true
 
 		res = ctrl.Result{RequeueAfter: 1 * time.Minute}
@@ -371,42 +469,89 @@ func (r *HarvesterClusterReconciler) ReconcileNormal(scope *ClusterScope) (res c
 	}
 
 	// Reconcile Cloud Provider Config
-	r.reconcileCloudProviderConfig(scope)
+	endCloudProviderPhase := tracing.StartPhase(&scope.Ctx, "ReconcileCloudProviderConfig")
+	cloudProviderErr := r.reconcileCloudProviderConfig(scope)
+	endCloudProviderPhase(cloudProviderErr)
 
 	// The following is executed only if there are ownedCPHarvesterMachines
+	endLoadBalancerPhase := tracing.StartPhase(&scope.Ctx, "ReconcileLoadBalancer")
+	err = reconcileLoadBalancer(scope)
+	endLoadBalancerPhase(err)
+
+	if err != nil {
+		logger.V(1).Info("could not reconcile the LoadBalancer, requeuing ...", "error", err.Error())
+		conditions.MarkFalse(scope.HarvesterCluster, infrav1.LoadBalancerReadyCondition,
+			infrav1.LoadBalancerNotReadyReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil //nolint:nlreturn
+	}
+
 	if !conditions.IsTrue(scope.HarvesterCluster, infrav1.LoadBalancerReadyCondition) {
-		err := createLoadBalancerIfNotExists(scope)
-		if err != nil {
-			logger.V(1).Info("could not create the LoadBalancer, requeuing ...")
+		lbAddresses, err := getHarvesterLoadBalancerAddresses(scope)
+		if errors.Is(err, errLoadBalancerAddressMismatch) {
+			// The control plane endpoint would change, the load balancer has to be fixed in Harvester
+			conditions.MarkFalse(scope.HarvesterCluster, infrav1.LoadBalancerReadyCondition,
+				infrav1.LoadBalancerAddressMismatchReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+
+			if scope.EventRecorder != nil {
+				scope.EventRecorder.Event(scope.HarvesterCluster, apiv1.EventTypeWarning, loadBalancerAddressMismatchEventReason, err.Error())
+			}
 
-			return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil //nolint:nlreturn
+			return ctrl.Result{}, err
 		}
 
-		lbIP, err := getLoadBalancerIP(scope.HarvesterCluster, scope.HarvesterClient)
 		if err != nil {
 			logger.Info("LoadBalancer IP is not yet available, requeuing ...")
+			conditions.MarkFalse(scope.HarvesterCluster, infrav1.LoadBalancerReadyCondition,
+				infrav1.LoadBalancerNotReadyReason, clusterv1.ConditionSeverityInfo, "waiting for the address of the load balancer")
+
+			return requeueForLoadBalancerChange(scope, requeueTimeShort), nil //nolint:nlreturn
+		}
 
-			return ctrl.Result{RequeueAfter: requeueTimeShort}, nil //nolint:nlreturn
+		lbIP := getPrimaryLoadBalancerAddress(scope.HarvesterCluster, lbAddresses)
+		if lbIP == "" {
+			logger.Info("LoadBalancer IP of the primary IP family is not yet available, requeuing ...",
+				"ipFamily", getIPFamilies(scope.HarvesterCluster)[0])
+			conditions.MarkFalse(scope.HarvesterCluster, infrav1.LoadBalancerReadyCondition,
+				infrav1.LoadBalancerNotReadyReason, clusterv1.ConditionSeverityInfo,
+				"waiting for the %s address of the load balancer", getIPFamilies(scope.HarvesterCluster)[0])
+
+			return requeueForLoadBalancerChange(scope, requeueTimeShort), nil
 		}
 
 		scope.HarvesterCluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{
 			Host: lbIP,
-			Port: apiServerLBPort,
+			Port: getAPIServerPort(scope.HarvesterCluster),
+		}
+		scope.HarvesterCluster.Status.LoadBalancerAddresses = lbAddresses
+
+		if scope.HarvesterCluster.Status.LoadBalancer != nil {
+			scope.HarvesterCluster.Status.LoadBalancer.Address = lbIP
 		}
 
 		scope.HarvesterCluster.Status.Ready = true
-		scope.HarvesterCluster.Status.Conditions = append(scope.HarvesterCluster.Status.Conditions, clusterv1.Condition{
-			Type:   infrav1.LoadBalancerReadyCondition,
-			Status: apiv1.ConditionTrue,
-		})
+		conditions.MarkTrue(scope.HarvesterCluster, infrav1.LoadBalancerReadyCondition)
 
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 	}
 
-	return res, err
+	// Requeue to detect changes of the load balancer made in Harvester
+	return requeueForLoadBalancerChange(scope, requeueTimeMedium), nil
+}
+
+// requeueForLoadBalancerChange returns the result of a reconciliation waiting for the load balancer to change in Harvester.
+// The load balancer is only polled when its changes are not watched.
+func requeueForLoadBalancerChange(scope *ClusterScope, pollInterval time.Duration) ctrl.Result {
+	if scope.LoadBalancerWatched {
+		return ctrl.Result{}
+	}
+
+	return ctrl.Result{RequeueAfter: pollInterval}
 }
 
 func createPlaceholderSVC(lbName string, scope *ClusterScope, lbIP string) error {
+	ipFamilyPolicy := getIPFamilyPolicy(scope.HarvesterCluster)
+
 	placeholderSVC := &apiv1.Service{
 		ObjectMeta: v1.ObjectMeta{
 			Name:      lbName,
@@ -420,15 +565,14 @@ func createPlaceholderSVC(lbName string, scope *ClusterScope, lbIP string) error
 			Ports: []apiv1.ServicePort{
 				{
 					Name:       apiServerListener,
-					Port:       apiServerLBPort,
+					Port:       getAPIServerPort(scope.HarvesterCluster),
 					Protocol:   apiServerProtocol,
-					TargetPort: intstr.FromInt(apiServerBackendPort),
+					TargetPort: intstr.FromInt(int(getBackendPort(scope.HarvesterCluster))),
 				},
 			},
-			Type: apiv1.ServiceTypeLoadBalancer,
-			IPFamilies: []apiv1.IPFamily{
-				apiv1.IPv4Protocol,
-			},
+			Type:           apiv1.ServiceTypeLoadBalancer,
+			IPFamilies:     getIPFamilies(scope.HarvesterCluster),
+			IPFamilyPolicy: &ipFamilyPolicy,
 			LoadBalancerIP: lbIP,
 		},
 	}
@@ -443,135 +587,418 @@ func createPlaceholderSVC(lbName string, scope *ClusterScope, lbIP string) error
 	return nil
 }
 
-func checkValidIpPoolDefinition(ipPool infrav1.IpPool) bool {
-	if ipPool == (infrav1.IpPool{}) {
-		return false
-	}
-
-	if ipPool.Subnet == "" || ipPool.Gateway == "" || ipPool.VMNetwork == "" {
-		return false
-	}
-
-	return true
-}
-
+// getIPFromIPPool allocates the address of the control plane to the given applicant from the IP Pool of the HarvesterCluster,
+// creating the IP Pool first if it is defined in the HarvesterCluster, and records the allocation in its status.
 func getIPFromIPPool(scope *ClusterScope, lbNamespacedName string) (string, error) {
-	poolRef := scope.HarvesterCluster.Spec.LoadBalancerConfig.IpPoolRef
+	ipam := scope.HarvesterCluster.Spec.LoadBalancerConfig.IPAM
 
-	if poolRef == "" && !checkValidIpPoolDefinition(scope.HarvesterCluster.Spec.LoadBalancerConfig.IpPool) {
-		return "", fmt.Errorf("IP Pool reference is empty, while IPAMType is set to %s", infrav1.POOL)
-	}
+	var (
+		ipPool *lbv1beta1.IPPool
+		err    error
+	)
 
-	ipPool := &lbv1beta1.IPPool{}
+	switch {
+	case ipam.Type == infrav1.IPAMTypeIPPool && ipam.IPPool != nil:
+		var ipPoolSpec lbv1beta1.IPPoolSpec
 
-	var err error
+		ipPoolSpec, err = getIPPoolSpecFromConfig(ipam.IPPool, scope.HarvesterCluster.Spec.TargetNamespace)
+		if err != nil {
+			return "", err
+		}
 
-	if poolRef == "" && checkValidIpPoolDefinition(scope.HarvesterCluster.Spec.LoadBalancerConfig.IpPool) {
 		ipPool, err = createIPPoolIfNotExists(
-			scope.HarvesterCluster,
-			scope.HarvesterClient,
-			scope.HarvesterCluster.Spec.LoadBalancerConfig.IpPool.VMNetwork,
+			scope,
+			ipPoolSpec,
 			scope.HarvesterCluster.Spec.TargetNamespace)
 		if err != nil {
 			return "", err
 		}
+	case ipam.Type == infrav1.IPAMTypeIPAddressClaim:
+		var ipAddress *ipamv1.IPAddress
 
-		scope.HarvesterCluster.Spec.LoadBalancerConfig.IpPoolRef = ipPool.Name
-	}
+		ipAddress, err = claimLoadBalancerAddress(scope)
+		if err != nil {
+			return "", err
+		}
 
-	if poolRef != "" {
-		ipPool, err = scope.HarvesterClient.LoadbalancerV1beta1().IPPools().Get(
-			context.TODO(),
-			poolRef,
-			v1.GetOptions{})
+		var ipPoolSpec lbv1beta1.IPPoolSpec
+
+		ipPoolSpec, err = getIPPoolSpecFromIPAddress(ipAddress)
+		if err != nil {
+			return "", err
+		}
+
+		ipPool, err = createIPPoolIfNotExists(
+			scope,
+			ipPoolSpec,
+			scope.HarvesterCluster.Spec.TargetNamespace)
 		if err != nil {
-			return "", errors.Wrapf(err, "could not get referenced IP Pool %s", poolRef)
+			return "", err
 		}
+	case ipam.Type == infrav1.IPAMTypeIPPoolRef && ipam.IPPoolRef != nil && ipam.IPPoolRef.Name != "":
+		ipPool = &lbv1beta1.IPPool{ObjectMeta: v1.ObjectMeta{Name: ipam.IPPoolRef.Name}}
+	default:
+		return "", fmt.Errorf("no IP Pool is defined, while the IPAM type is set to %s", ipam.Type)
 	}
 
-	if ipPool.Status.Available == 0 {
-		return "", fmt.Errorf("IP Pool %s does not have available addresses", poolRef)
+	ip, err := allocateIPFromPool(scope, ipPool.Name, lbNamespacedName)
+	if err != nil {
+		return "", err
 	}
 
-	return allocateIPFromPool(ipPool, lbNamespacedName, scope)
+	scope.HarvesterCluster.Status.AllocatedAddress = &infrav1.IPPoolAllocation{
+		IPPool:  ipPool.Name,
+		Address: ip,
+	}
+
+	return ip, nil
 }
 
-func allocateIPFromPool(refPool *lbv1beta1.IPPool, lbNamespacedName string, scope *ClusterScope) (string, error) {
+// getIPPoolRangeSet returns the set of IP ranges of an IP Pool in Harvester.
+func getIPPoolRangeSet(pool *lbv1beta1.IPPool) (allocator.RangeSet, error) {
 	rangeSlice := make([]allocator.Range, 0)
 
-	var total int64
-
-	ranges := refPool.Spec.Ranges
+	ranges := pool.Spec.Ranges
 
 	for i := range ranges {
 		element, err := locutil.MakeRange(&ranges[i])
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		rangeSlice = append(rangeSlice, *element)
+	}
+
+	return allocator.RangeSet(rangeSlice), nil
+}
 
-		total += locutil.CountIP(element)
+// getIPPoolRangeSetForFamily returns the set of IP ranges of an IP Pool in Harvester which are of the given IP family.
+func getIPPoolRangeSetForFamily(pool *lbv1beta1.IPPool, ipFamily apiv1.IPFamily) (allocator.RangeSet, error) {
+	rangeSet, err := getIPPoolRangeSet(pool)
+	if err != nil {
+		return nil, err
 	}
 
-	rangeSet := allocator.RangeSet(rangeSlice)
+	familyRangeSet := make(allocator.RangeSet, 0, len(rangeSet))
 
-	a := allocator.NewIPAllocator(&rangeSet, locutil.NewStore(refPool), 0)
+	for _, ipRange := range rangeSet {
+		if getIPFamily(ipRange.Subnet.IP) == ipFamily {
+			familyRangeSet = append(familyRangeSet, ipRange)
+		}
+	}
+
+	if len(familyRangeSet) == 0 {
+		return nil, fmt.Errorf("IP Pool %s does not have any %s range", pool.Name, ipFamily)
+	}
 
-	var ipObj *current.IPConfig
+	return familyRangeSet, nil
+}
 
-	var err error
+// allocateIPFromPool allocates the address of the primary IP family of the load balancer from an IP Pool in Harvester,
+// or returns the one already allocated to it. The allocation is retried on the latest version of the IP Pool when it conflicts
+// with another one, so that the same address is never given to two applicants.
+func allocateIPFromPool(scope *ClusterScope, ipPoolName string, lbNamespacedName string) (string, error) {
+	ipFamily := getIPFamilies(scope.HarvesterCluster)[0]
 
-	// apply the IP allocated before in priority
-	if refPool.Status.AllocatedHistory != nil {
-		for k, v := range refPool.Status.AllocatedHistory {
-			if lbNamespacedName == v {
-				ipObj, err = a.Get(lbNamespacedName, "", net.ParseIP(k))
-				if err != nil {
-					return "", err
-				}
+	var (
+		ip        net.IP
+		allocated bool
+	)
+
+	start := time.Now()
+
+	err := updateIPPool(scope.Ctx, scope.HarvesterClient, ipPoolName, func(ipPool *lbv1beta1.IPPool) (bool, error) {
+		ip = nil
+		allocated = false
+
+		rangeSet, err := getIPPoolRangeSetForFamily(ipPool, ipFamily)
+		if err != nil {
+			return false, err
+		}
+
+		store := locutil.NewStore(ipPool)
+
+		// The address might have been allocated by a previous reconciliation which could not be persisted.
+		for _, allocatedIP := range store.GetByID(lbNamespacedName, "") {
+			if rangeSet.Contains(allocatedIP) {
+				ip = allocatedIP
+
+				return false, nil
 			}
 		}
-	}
 
-	if ipObj == nil {
-		ipObj, err = a.Get(lbNamespacedName, "", nil)
+		if ipPool.Status.Available == 0 {
+			return false, fmt.Errorf("IP Pool %s does not have available addresses", ipPool.Name)
+		}
+
+		// apply the IP allocated before in priority, unless it has been given to someone else since
+		var requestedIP net.IP
+
+		for k, v := range ipPool.Status.AllocatedHistory {
+			if _, allocated := ipPool.Status.Allocated[k]; lbNamespacedName == v && !allocated && rangeSet.Contains(net.ParseIP(k)) {
+				requestedIP = net.ParseIP(k)
+			}
+		}
+
+		ipObj, err := allocator.NewIPAllocator(&rangeSet, store, 0).Get(lbNamespacedName, "", requestedIP)
 		if err != nil {
-			return "", err
+			return false, err
 		}
+
+		ip = ipObj.Address.IP
+		allocated = true
+
+		return true, nil
+	})
+	if err != nil {
+		recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionAllocate,
+			"an address from IP Pool "+ipPoolName, err)
+
+		return "", errors.Wrapf(err, "could not allocate an address from IP Pool %s", ipPoolName)
+	}
+
+	if allocated {
+		locmetrics.LoadBalancerIPAllocationDuration.WithLabelValues(ipPoolName).Observe(time.Since(start).Seconds())
+		recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionAllocate,
+			"address "+ip.String()+" from IP Pool "+ipPoolName, nil)
+	}
+
+	return ip.String(), nil
+}
+
+// releaseIPFromPool releases the addresses allocated to an applicant from an IP Pool in Harvester. They stay in the allocation
+// history, so that the applicant gets them again in priority. Nothing is done if the IP Pool does not exist anymore.
+func releaseIPFromPool(scope *ClusterScope, ipPoolName string, applicant string) error {
+	released := false
+
+	err := updateIPPool(scope.Ctx, scope.HarvesterClient, ipPoolName, func(ipPool *lbv1beta1.IPPool) (bool, error) {
+		store := locutil.NewStore(ipPool)
+		if len(store.GetByID(applicant, "")) == 0 {
+			return false, nil
+		}
+
+		released = true
+
+		return true, store.ReleaseByID(applicant, "")
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionRelease,
+			"the addresses of "+applicant+" from IP Pool "+ipPoolName, err)
+
+		return errors.Wrapf(err, "could not release the addresses of %s from IP Pool %s", applicant, ipPoolName)
+	}
+
+	if err == nil && released {
+		recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionRelease,
+			"the addresses of "+applicant+" from IP Pool "+ipPoolName, nil)
 	}
 
-	// Update Pool in Harvester with the new allocated IP
-	_, err = scope.HarvesterClient.LoadbalancerV1beta1().IPPools().Update(context.TODO(), refPool, v1.UpdateOptions{})
+	return nil
+}
+
+// getReservedLoadBalancerAddress returns the address of the primary IP family allocated to a load balancer in an IP Pool
+// of Harvester, in the form Harvester records it in the status of the load balancer, or nil if none is allocated to it.
+func getReservedLoadBalancerAddress(scope *ClusterScope, ipPoolName string, applicant string) (*lbv1beta1.AllocatedAddress, error) {
+	ipPool, err := scope.HarvesterClient.LoadbalancerV1beta1().IPPools().Get(scope.Ctx, ipPoolName, v1.GetOptions{})
 	if err != nil {
-		return "", err
+		return nil, errors.Wrapf(err, "unable to get IP Pool %s", ipPoolName)
+	}
+
+	rangeSet, err := getIPPoolRangeSetForFamily(ipPool, getIPFamilies(scope.HarvesterCluster)[0])
+	if err != nil {
+		return nil, err
 	}
 
-	return ipObj.Address.IP.String(), nil
+	for _, ip := range locutil.NewStore(ipPool).GetByID(applicant, "") {
+		ipRange, err := rangeSet.RangeFor(ip)
+		if err != nil {
+			continue
+		}
+
+		allocatedAddress := &lbv1beta1.AllocatedAddress{
+			IPPool: ipPoolName,
+			IP:     ip.String(),
+			Mask:   net.IP(ipRange.Subnet.Mask).String(),
+		}
+
+		if ipRange.Gateway != nil {
+			allocatedAddress.Gateway = ipRange.Gateway.String()
+		}
+
+		return allocatedAddress, nil
+	}
+
+	return nil, nil
+}
+
+// getIPPoolApplicant returns the applicant recorded in the IP Pool for the control plane address of a HarvesterCluster,
+// which is the load balancer in Harvester, or the kube-vip owner.
+func getIPPoolApplicant(cluster *infrav1.HarvesterCluster) string {
+	if cluster.Spec.LoadBalancerConfig.Type == infrav1.LoadBalancerTypeKubeVIP {
+		return getKubeVIPOwner(cluster)
+	}
+
+	lbRef := getLoadBalancerReference(cluster)
+
+	return lbRef.Namespace + "/" + lbRef.Name
 }
 
-func getLoadBalancerIP(harvesterCluster *infrav1.HarvesterCluster, hvClient lbclient.Interface) (string, error) {
-	createdLB, err := hvClient.LoadbalancerV1beta1().LoadBalancers(harvesterCluster.Spec.TargetNamespace).Get(
-		context.TODO(),
-		locutil.GenerateRFC1035Name([]string{harvesterCluster.Namespace, harvesterCluster.Name, "lb"}),
+// releaseAllocatedAddress releases the control plane address of a deleted HarvesterCluster from its IP Pool in Harvester,
+// so that shared IP Pools do not leak an address for each deleted cluster. The address stays in the allocation history
+// of the IP Pool, and is given again in priority to a cluster with the same name. The address of a cluster which has
+// no allocation recorded in its status is released from the referenced IP Pool.
+func releaseAllocatedAddress(scope *ClusterScope) error {
+	var ipPoolName string
+
+	ipam := scope.HarvesterCluster.Spec.LoadBalancerConfig.IPAM
+
+	switch {
+	case scope.HarvesterCluster.Status.AllocatedAddress != nil:
+		ipPoolName = scope.HarvesterCluster.Status.AllocatedAddress.IPPool
+	case ipam.Type == infrav1.IPAMTypeIPPoolRef && ipam.IPPoolRef != nil:
+		ipPoolName = ipam.IPPoolRef.Name
+	default:
+		return nil
+	}
+
+	if err := releaseIPFromPool(scope, ipPoolName, getIPPoolApplicant(scope.HarvesterCluster)); err != nil {
+		return err
+	}
+
+	scope.HarvesterCluster.Status.AllocatedAddress = nil
+
+	return nil
+}
+
+// getHarvesterLoadBalancerAddresses returns the addresses of the load balancer in Harvester. The load balancer only has one address,
+// the ones of the other IP family of a dual-stack cluster are those of the Service Harvester creates for the load balancer.
+func getHarvesterLoadBalancerAddresses(scope *ClusterScope) ([]infrav1.LoadBalancerAddress, error) {
+	lbRef := getLoadBalancerReference(scope.HarvesterCluster)
+
+	createdLB, err := scope.HarvesterClient.LoadbalancerV1beta1().LoadBalancers(lbRef.Namespace).Get(
+		scope.Ctx,
+		lbRef.Name,
 		v1.GetOptions{})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if createdLB.Status.Address == "" {
-		return "", fmt.Errorf("the LB Address was empty after its creation")
+		return nil, fmt.Errorf("the LB Address was empty after its creation")
+	}
+
+	if err := checkReservedAddress(scope.HarvesterCluster, createdLB); err != nil {
+		return nil, err
+	}
+
+	ips := []string{createdLB.Status.Address}
+
+	lbSVC, err := scope.HarvesterClient.CoreV1().Services(lbRef.Namespace).Get(scope.Ctx, lbRef.Name, v1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, "unable to get the Service of the LB")
+	}
+
+	if err == nil {
+		ips = append(ips, getIngressIPs(lbSVC.Status.LoadBalancer.Ingress)...)
+	}
+
+	return getLoadBalancerAddresses(scope.HarvesterCluster, ips), nil
+}
+
+// errLoadBalancerAddressMismatch is returned when the load balancer in Harvester did not get the address reserved for it.
+var errLoadBalancerAddressMismatch = errors.New("the load balancer did not get the reserved address")
+
+// checkReservedAddress checks that a load balancer got the address reserved for the control plane in its IP Pool.
+// The reservation is handed over to Harvester when the load balancer is created, the address only differs when the load balancer
+// was created or changed outside of the provider.
+func checkReservedAddress(cluster *infrav1.HarvesterCluster, lb *lbv1beta1.LoadBalancer) error {
+	reserved := cluster.Status.AllocatedAddress
+	if reserved == nil || lb.Spec.IPAM != lbv1beta1.Pool || lb.Spec.IPPool != reserved.IPPool || lb.Status.Address == reserved.Address {
+		return nil
+	}
+
+	return errors.Wrapf(errLoadBalancerAddressMismatch, "load balancer %s/%s got address %s from IP Pool %s instead of the reserved address %s",
+		lb.Namespace, lb.Name, lb.Status.Address, reserved.IPPool, reserved.Address)
+}
+
+// getIngressIPs returns the IP addresses of the ingress points of a load balancer Service.
+func getIngressIPs(ingress []apiv1.LoadBalancerIngress) []string {
+	ips := make([]string, 0, len(ingress))
+
+	for _, ingressPoint := range ingress {
+		if ingressPoint.IP != "" {
+			ips = append(ips, ingressPoint.IP)
+		}
+	}
+
+	return ips
+}
+
+// getLoadBalancerAddresses returns the first address of each IP family of the load balancer found in a list of IP addresses,
+// in the order of the IP families of the HarvesterCluster.
+func getLoadBalancerAddresses(cluster *infrav1.HarvesterCluster, ips []string) []infrav1.LoadBalancerAddress {
+	ipFamilies := getIPFamilies(cluster)
+	addresses := make([]infrav1.LoadBalancerAddress, 0, len(ipFamilies))
+
+	for _, ipFamily := range ipFamilies {
+		for _, ip := range ips {
+			parsedIP := net.ParseIP(ip)
+			if parsedIP != nil && getIPFamily(parsedIP) == ipFamily {
+				addresses = append(addresses, infrav1.LoadBalancerAddress{IPFamily: ipFamily, Address: parsedIP.String()})
+
+				break
+			}
+		}
+	}
+
+	return addresses
+}
+
+// getPrimaryLoadBalancerAddress returns the address of the primary IP family of the load balancer, which is used as the
+// control plane endpoint, or an empty string if there is none.
+func getPrimaryLoadBalancerAddress(cluster *infrav1.HarvesterCluster, addresses []infrav1.LoadBalancerAddress) string {
+	primaryFamily := getIPFamilies(cluster)[0]
+
+	for _, address := range addresses {
+		if address.IPFamily == primaryFamily {
+			return address.Address
+		}
+	}
+
+	return ""
+}
+
+// getIPFamily returns the IP family of an IP address.
+func getIPFamily(ip net.IP) apiv1.IPFamily {
+	if ip.To4() != nil {
+		return apiv1.IPv4Protocol
 	}
 
-	return createdLB.Status.Address, nil
+	return apiv1.IPv6Protocol
 }
 
+// reconcileCloudProviderConfig generates the cloud provider config once, and reports the outcome in the CloudProviderConfigReadyCondition.
 func (r *HarvesterClusterReconciler) reconcileCloudProviderConfig(scope *ClusterScope) error {
 	// Skip if the Cloud Provider Config is already ready
 	if conditions.IsTrue(scope.HarvesterCluster, infrav1.CloudProviderConfigReadyCondition) {
 		return nil
 	}
 
+	if err := r.updateCloudProviderConfig(scope); err != nil {
+		conditions.MarkFalse(scope.HarvesterCluster, infrav1.CloudProviderConfigReadyCondition,
+			infrav1.CloudProviderConfigGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+
+		return err
+	}
+
+	conditions.MarkTrue(scope.HarvesterCluster, infrav1.CloudProviderConfigReadyCondition)
+
+	return nil
+}
+
+// updateCloudProviderConfig adds the kubeconfig of the cloud provider to its manifests in the referenced ConfigMap,
+// if the HarvesterCluster requests it.
+func (r *HarvesterClusterReconciler) updateCloudProviderConfig(scope *ClusterScope) error {
 	// Check if user provided the necessary information to generate the cloud provider config
 	updateCloudConfig := scope.HarvesterCluster.Spec.UpdateCloudProviderConfig
 	if (updateCloudConfig != infrav1.UpdateCloudProviderConfig{}) {
@@ -582,7 +1009,7 @@ func (r *HarvesterClusterReconciler) reconcileCloudProviderConfig(scope *Cluster
 
 		referencedConfigMap := &apiv1.ConfigMap{}
 
-		err := r.Client.Get(context.TODO(), types.NamespacedName{
+		err := r.Client.Get(scope.Ctx, types.NamespacedName{
 			Name:      updateCloudConfig.ManifestsConfigMapName,
 			Namespace: updateCloudConfig.ManifestsConfigMapNamespace,
 		}, referencedConfigMap)
@@ -598,10 +1025,21 @@ func (r *HarvesterClusterReconciler) reconcileCloudProviderConfig(scope *Cluster
 
 		// Generate the B64 Kubeconfig fpr the cloud provider
 		cloudProviderKubeconfigB64, err := locutil.GetCloudConfigB64(scope.HarvesterClient, scope.Cluster.Name, scope.HarvesterCluster.Spec.TargetNamespace, scope.HarvesterCluster.Spec.Server)
+		recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionCreate,
+			"cloud provider credentials "+scope.HarvesterCluster.Spec.TargetNamespace+"/"+scope.Cluster.Name, err)
+
 		if err != nil {
 			return errors.Wrapf(err, "unable to generate the kubeconfig for the cloud provider")
 		}
 
+		scope.HarvesterCluster.Status.CloudProvider = &infrav1.CloudProviderStatus{
+			ServiceAccount: infrav1.HarvesterResourceReference{
+				Name:      scope.Cluster.Name,
+				Namespace: scope.HarvesterCluster.Spec.TargetNamespace,
+			},
+			ClusterRoleBinding: scope.Cluster.Name,
+		}
+
 		cloudProviderKubeconfigBytes, err := base64.StdEncoding.DecodeString(cloudProviderKubeconfigB64)
 		if err != nil {
 			return errors.Wrapf(err, "unable to decode the kubeconfig for the cloud provider")
@@ -621,22 +1059,20 @@ func (r *HarvesterClusterReconciler) reconcileCloudProviderConfig(scope *Cluster
 		// Update the ConfigMap with the modified cloudConfig Manifest
 		referencedConfigMap.Data[updateCloudConfig.ManifestsConfigMapKey] = modifiedManifests
 
-		err = r.Client.Update(context.TODO(), referencedConfigMap)
+		err = r.Client.Update(scope.Ctx, referencedConfigMap)
 		if err != nil {
 			return errors.Wrapf(err, "unable to update the referenced config map %s/%s", updateCloudConfig.ManifestsConfigMapNamespace, updateCloudConfig.ManifestsConfigMapName)
 		}
 	}
 
-	conditions.Set(scope.HarvesterCluster, &clusterv1.Condition{
-		Type:    infrav1.CloudProviderConfigReadyCondition,
-		Status:  apiv1.ConditionTrue,
-		Reason:  infrav1.CloudProviderConfigGeneratedSuccessfullyReason,
-		Message: "Cloud Provider Config was generated successfully",
-	})
 	return nil
 }
 
-func (r *HarvesterClusterReconciler) reconcileHarvesterConfig(ctx context.Context, cluster *infrav1.HarvesterCluster) (*rest.Config, error) {
+// reconcileHarvesterConfig returns the clients of the Harvester API of a HarvesterCluster, and checks that Harvester is available.
+func (r *HarvesterClusterReconciler) reconcileHarvesterConfig(
+	ctx context.Context,
+	cluster *infrav1.HarvesterCluster,
+) (*locutil.HarvesterClients, error) {
 	logger := log.FromContext(ctx)
 
 	secret, err := locutil.GetSecretForHarvesterConfig(ctx, cluster, r.Client)
@@ -645,7 +1081,7 @@ func (r *HarvesterClusterReconciler) reconcileHarvesterConfig(ctx context.Contex
 		cluster.Status.FailureMessage = "unable to find the IdentitySecret for Harvester"
 		cluster.Status.Ready = false
 
-		return &rest.Config{}, errors.Wrapf(err, "unable to find the IdentitySecret for Harvester %s", ctx)
+		return nil, errors.Wrapf(err, "unable to find the IdentitySecret for Harvester %s", ctx)
 	}
 
 	kubeconfig := secret.Data[locutil.ConfigSecretDataKey]
@@ -656,7 +1092,7 @@ func (r *HarvesterClusterReconciler) reconcileHarvesterConfig(ctx context.Contex
 		cluster.Status.FailureMessage = err.Error()
 		cluster.Status.Ready = false
 
-		return &rest.Config{}, err
+		return nil, err
 	}
 
 	if cluster.Spec.Server == "" || cluster.Spec.Server != harvesterServer {
@@ -664,82 +1100,265 @@ func (r *HarvesterClusterReconciler) reconcileHarvesterConfig(ctx context.Contex
 		logger.Info("Value for Server is now set to " + cluster.Spec.Server)
 	}
 
-	hvRESTConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
-	if err != nil {
-		logger.Error(err, "unable to create kubernetes client config for Harvester")
-
-		return &rest.Config{}, err
-	}
-
-	hvClient, err := kubeclient.NewForConfig(hvRESTConfig)
+	hvClients, err := r.ClientCache.Get(secret)
 	if err != nil {
-		logger.Error(err, "unable to create kubernetes client from restConfig")
+		logger.Error(err, "unable to create the clients of Harvester")
 
-		return &rest.Config{}, err
+		return nil, err
 	}
 
-	harvesterDeployment, err := hvClient.AppsV1().Deployments(harvesterNamespace).Get(ctx, harvesterDeploymentName, v1.GetOptions{})
+	harvesterDeployment, err := hvClients.Kubernetes.AppsV1().Deployments(harvesterNamespace).Get(ctx, harvesterDeploymentName, v1.GetOptions{})
 	if err != nil {
 		logger.Error(err, "Harvester deployment not found on target Kubernetes cluster")
 
-		return &rest.Config{}, err
+		return nil, err
 	}
 
 	if !isHarvesterAvailable(harvesterDeployment.Status.Conditions) {
+		err = errors.Errorf("deployment %s/%s of Harvester is not available", harvesterNamespace, harvesterDeploymentName)
 		logger.Error(err, "harvester cluster is unavailable")
 
-		return &rest.Config{}, err
+		return nil, err
+	}
+
+	cluster.Status.HarvesterVersion = getHarvesterVersion(harvesterDeployment)
+
+	return hvClients, nil
+}
+
+// getHarvesterVersion returns the version of Harvester from the labels of its Deployment, or from the tag of its image.
+func getHarvesterVersion(harvesterDeployment *appsv1.Deployment) string {
+	if version := harvesterDeployment.Labels[harvesterVersionLabel]; version != "" {
+		return version
+	}
+
+	for _, container := range harvesterDeployment.Spec.Template.Spec.Containers {
+		if container.Name != harvesterDeploymentName {
+			continue
+		}
+
+		if i := strings.LastIndex(container.Image, ":"); i >= 0 && !strings.Contains(container.Image[i:], "/") {
+			return container.Image[i+1:]
+		}
 	}
 
-	return hvRESTConfig, nil
+	return ""
 }
 
-func createLoadBalancerIfNotExists(scope *ClusterScope) (err error) {
+// buildLoadBalancer returns the load balancer that should exist in Harvester for the control plane of a HarvesterCluster.
+func buildLoadBalancer(scope *ClusterScope) *lbv1beta1.LoadBalancer {
 	additionalListeners := getListenersFromAPI(scope.HarvesterCluster)
+	ipam, ipPoolName := getLoadBalancerIPAM(scope.HarvesterCluster)
 
-	lbToCreate := &lbv1beta1.LoadBalancer{
+	description := scope.HarvesterCluster.Spec.LoadBalancerConfig.Description
+	if description == "" {
+		description = "Load Balancer for cluster " + scope.HarvesterCluster.Name
+	}
+
+	lbRef := getLoadBalancerReference(scope.HarvesterCluster)
+
+	return &lbv1beta1.LoadBalancer{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      locutil.GenerateRFC1035Name([]string{scope.HarvesterCluster.Namespace, scope.HarvesterCluster.Name, "lb"}),
-			Namespace: scope.HarvesterCluster.Spec.TargetNamespace,
+			Name:      lbRef.Name,
+			Namespace: lbRef.Namespace,
 		},
 		Spec: lbv1beta1.LoadBalancerSpec{
-			Description:  "Load Balancer for cluster " + scope.HarvesterCluster.Name,
+			Description:  description,
 			WorkloadType: "vm",
-			IPPool:       scope.HarvesterCluster.Spec.LoadBalancerConfig.IpPoolRef,
-			IPAM:         lbv1beta1.IPAM(scope.HarvesterCluster.Spec.LoadBalancerConfig.IPAMType),
+			IPPool:       ipPoolName,
+			IPAM:         ipam,
 			Listeners: append(additionalListeners, lbv1beta1.Listener{
 				Name:        apiServerListener,
-				Port:        apiServerLBPort,
+				Port:        getAPIServerPort(scope.HarvesterCluster),
 				Protocol:    apiServerProtocol,
-				BackendPort: apiServerBackendPort,
+				BackendPort: getBackendPort(scope.HarvesterCluster),
 			}),
-			HealthCheck: &lbv1beta1.HealthCheck{
-				Port:             apiServerBackendPort,
-				SuccessThreshold: 1,
-				FailureThreshold: failureThreshold,
-				PeriodSeconds:    lbHealthCheckPeriodSections,
-				TimeoutSeconds:   lbHealthCheckTimeoutSections,
-			},
+			HealthCheck: getLoadBalancerHealthCheck(scope.HarvesterCluster),
 			BackendServerSelector: map[string][]string{
 				cpVMLabelKey: {cpVMLabelValuePrefix + "-" + scope.Cluster.Name},
 			},
 		},
 	}
+}
 
-	// Harvester Call to Harvester
-	_, err = scope.HarvesterClient.LoadbalancerV1beta1().LoadBalancers(scope.HarvesterCluster.Spec.TargetNamespace).Create(
-		context.TODO(),
-		lbToCreate,
-		v1.CreateOptions{})
+// reconcileLoadBalancer creates the load balancer in Harvester if it does not exist yet, or updates it if its spec drifted
+// from the desired one. The IPAM of an existing load balancer is not changed because it would change the control plane endpoint,
+// the drift is only reported in the LoadBalancerSpecInSyncCondition.
+func reconcileLoadBalancer(scope *ClusterScope) error {
+	desiredLB := buildLoadBalancer(scope)
+	lbClient := scope.HarvesterClient.LoadbalancerV1beta1().LoadBalancers(scope.HarvesterCluster.Spec.TargetNamespace)
+
+	existingLB, err := lbClient.Get(scope.Ctx, desiredLB.Name, v1.GetOptions{})
 	if err != nil {
-		if !apierrors.IsAlreadyExists(err) {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "unable to get LB")
+		}
+
+		// The address reserved for the load balancer stays allocated to it in the IP Pool, and is handed over to Harvester
+		// in the allocation recorded in the status of the load balancer, which Harvester uses instead of allocating an address
+		// itself. Releasing the address first would let another applicant of a shared IP Pool take it before Harvester does.
+		if desiredLB.Spec.IPAM == lbv1beta1.Pool && desiredLB.Spec.IPPool != "" {
+			allocatedAddress, err := getReservedLoadBalancerAddress(scope, desiredLB.Spec.IPPool, desiredLB.Namespace+"/"+desiredLB.Name)
+			if err != nil {
+				return err
+			}
+
+			if allocatedAddress != nil {
+				desiredLB.Status.AllocatedAddress = *allocatedAddress
+			}
+		}
+
+		// Harvester Call to Harvester
+		_, err = lbClient.Create(scope.Ctx, desiredLB, v1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionCreate,
+				"load balancer "+desiredLB.Namespace+"/"+desiredLB.Name, err)
+
 			return errors.Wrapf(err, "error during creation of LB")
 		}
+
+		if err == nil {
+			recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionCreate,
+				"load balancer "+desiredLB.Namespace+"/"+desiredLB.Name, nil)
+		}
+
+		recordLoadBalancer(scope.HarvesterCluster, desiredLB)
+
+		conditions.MarkTrue(scope.HarvesterCluster, infrav1.LoadBalancerSpecInSyncCondition)
+
+		return nil
+	}
+
+	recordLoadBalancer(scope.HarvesterCluster, existingLB)
+
+	driftedFields := getLoadBalancerDrift(desiredLB, existingLB)
+	if len(driftedFields) == 0 {
+		conditions.MarkTrue(scope.HarvesterCluster, infrav1.LoadBalancerSpecInSyncCondition)
+
+		return nil
+	}
+
+	ipamDrifted := false
+	updatableFields := make([]string, 0, len(driftedFields))
+
+	for _, driftedField := range driftedFields {
+		if driftedField == "ipam" {
+			ipamDrifted = true
+
+			continue
+		}
+
+		updatableFields = append(updatableFields, driftedField)
+	}
+
+	if len(updatableFields) > 0 {
+		scope.Logger.Info("Load Balancer spec drifted from the desired one, updating it ...", "fields", updatableFields)
+
+		existingLB.Spec.Description = desiredLB.Spec.Description
+		existingLB.Spec.WorkloadType = desiredLB.Spec.WorkloadType
+		existingLB.Spec.Listeners = desiredLB.Spec.Listeners
+		existingLB.Spec.HealthCheck = desiredLB.Spec.HealthCheck
+		existingLB.Spec.BackendServerSelector = desiredLB.Spec.BackendServerSelector
+
+		_, err = lbClient.Update(scope.Ctx, existingLB, v1.UpdateOptions{})
+		recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionUpdate,
+			"load balancer "+existingLB.Namespace+"/"+existingLB.Name, err)
+
+		if err != nil {
+			conditions.MarkFalse(scope.HarvesterCluster, infrav1.LoadBalancerSpecInSyncCondition,
+				infrav1.LoadBalancerSpecDriftedReason, clusterv1.ConditionSeverityWarning,
+				"fields %s of the Load Balancer differ from the desired ones", strings.Join(updatableFields, ", "))
+
+			return errors.Wrapf(err, "unable to update LB")
+		}
+	}
+
+	if ipamDrifted {
+		conditions.MarkFalse(scope.HarvesterCluster, infrav1.LoadBalancerSpecInSyncCondition,
+			infrav1.LoadBalancerIPAMDriftedReason, clusterv1.ConditionSeverityWarning,
+			"the IPAM of the Load Balancer is %s with IP Pool %q instead of %s with IP Pool %q",
+			existingLB.Spec.IPAM, existingLB.Spec.IPPool, desiredLB.Spec.IPAM, desiredLB.Spec.IPPool)
+
+		return nil
 	}
 
+	conditions.MarkTrue(scope.HarvesterCluster, infrav1.LoadBalancerSpecInSyncCondition)
+
 	return nil
 }
 
+// getLoadBalancerReference returns the load balancer recorded in the status of a HarvesterCluster,
+// or the one named after the HarvesterCluster if none was recorded.
+func getLoadBalancerReference(cluster *infrav1.HarvesterCluster) infrav1.HarvesterResourceReference {
+	if cluster.Status.LoadBalancer != nil {
+		return infrav1.HarvesterResourceReference{Name: cluster.Status.LoadBalancer.Name, Namespace: cluster.Status.LoadBalancer.Namespace}
+	}
+
+	return infrav1.HarvesterResourceReference{Name: getDefaultLoadBalancerName(cluster), Namespace: cluster.Spec.TargetNamespace}
+}
+
+// getPlaceholderServiceReference returns the placeholder Service recorded in the status of a HarvesterCluster,
+// or the one named after the HarvesterCluster if none was recorded.
+func getPlaceholderServiceReference(cluster *infrav1.HarvesterCluster) infrav1.HarvesterResourceReference {
+	if cluster.Status.PlaceholderService != nil {
+		return *cluster.Status.PlaceholderService
+	}
+
+	return infrav1.HarvesterResourceReference{Name: getDefaultLoadBalancerName(cluster), Namespace: cluster.Spec.TargetNamespace}
+}
+
+// getDefaultLoadBalancerName returns the RFC-1035 compliant name given to the load balancer and to the placeholder Service
+// of a HarvesterCluster when they are created.
+func getDefaultLoadBalancerName(cluster *infrav1.HarvesterCluster) string {
+	return locutil.GenerateRFC1035Name([]string{cluster.Namespace, cluster.Name, "lb"})
+}
+
+// recordLoadBalancer records the load balancer created in Harvester in the status of a HarvesterCluster,
+// keeping its address if it is already known.
+func recordLoadBalancer(cluster *infrav1.HarvesterCluster, lb *lbv1beta1.LoadBalancer) {
+	if cluster.Status.LoadBalancer == nil {
+		cluster.Status.LoadBalancer = &infrav1.LoadBalancerReference{}
+	}
+
+	cluster.Status.LoadBalancer.Name = lb.Name
+	cluster.Status.LoadBalancer.Namespace = lb.Namespace
+
+	if lb.Status.Address != "" {
+		cluster.Status.LoadBalancer.Address = lb.Status.Address
+	}
+}
+
+// getLoadBalancerDrift returns the names of the fields of the spec of an existing load balancer which differ from the desired one.
+func getLoadBalancerDrift(desiredLB, existingLB *lbv1beta1.LoadBalancer) []string {
+	driftedFields := make([]string, 0)
+
+	if desiredLB.Spec.Description != existingLB.Spec.Description {
+		driftedFields = append(driftedFields, "description")
+	}
+
+	if desiredLB.Spec.WorkloadType != existingLB.Spec.WorkloadType {
+		driftedFields = append(driftedFields, "workloadType")
+	}
+
+	if desiredLB.Spec.IPAM != existingLB.Spec.IPAM || desiredLB.Spec.IPPool != existingLB.Spec.IPPool {
+		driftedFields = append(driftedFields, "ipam")
+	}
+
+	if !equality.Semantic.DeepEqual(desiredLB.Spec.Listeners, existingLB.Spec.Listeners) {
+		driftedFields = append(driftedFields, "listeners")
+	}
+
+	if !equality.Semantic.DeepEqual(desiredLB.Spec.HealthCheck, existingLB.Spec.HealthCheck) {
+		driftedFields = append(driftedFields, "healthCheck")
+	}
+
+	if !equality.Semantic.DeepEqual(desiredLB.Spec.BackendServerSelector, existingLB.Spec.BackendServerSelector) {
+		driftedFields = append(driftedFields, "backendServerSelector")
+	}
+
+	return driftedFields
+}
+
 // getListenersFromAPI is a function that gets the listeners from the HarvesterCluster Resource and returns them as a slice of lbv1beta1.Listener.
 func getListenersFromAPI(cluster *infrav1.HarvesterCluster) []lbv1beta1.Listener {
 	additionalListeners := make([]lbv1beta1.Listener, len(cluster.Spec.LoadBalancerConfig.Listeners))
@@ -755,46 +1374,192 @@ func getListenersFromAPI(cluster *infrav1.HarvesterCluster) []lbv1beta1.Listener
 	return additionalListeners
 }
 
+// getAPIServerPort returns the port the load balancer listens on for the API server, which is the port of the control plane endpoint.
+func getAPIServerPort(cluster *infrav1.HarvesterCluster) int32 {
+	if cluster.Spec.LoadBalancerConfig.APIServerPort != 0 {
+		return cluster.Spec.LoadBalancerConfig.APIServerPort
+	}
+
+	return infrav1.DefaultAPIServerPort
+}
+
+// getBackendPort returns the port the API server listens on in the control plane machines.
+func getBackendPort(cluster *infrav1.HarvesterCluster) int32 {
+	if cluster.Spec.LoadBalancerConfig.BackendPort != 0 {
+		return cluster.Spec.LoadBalancerConfig.BackendPort
+	}
+
+	return infrav1.DefaultAPIServerPort
+}
+
+// getIPFamilies returns the IP families of the load balancer, the first one being the primary one.
+func getIPFamilies(cluster *infrav1.HarvesterCluster) []apiv1.IPFamily {
+	if len(cluster.Spec.LoadBalancerConfig.IPFamilies) > 0 {
+		return cluster.Spec.LoadBalancerConfig.IPFamilies
+	}
+
+	return []apiv1.IPFamily{apiv1.IPv4Protocol}
+}
+
+// getIPFamilyPolicy returns the IP family policy of the load balancer Service.
+func getIPFamilyPolicy(cluster *infrav1.HarvesterCluster) apiv1.IPFamilyPolicy {
+	if cluster.Spec.LoadBalancerConfig.IPFamilyPolicy != "" {
+		return cluster.Spec.LoadBalancerConfig.IPFamilyPolicy
+	}
+
+	if len(getIPFamilies(cluster)) > 1 {
+		return apiv1.IPFamilyPolicyPreferDualStack
+	}
+
+	return apiv1.IPFamilyPolicySingleStack
+}
+
+// getLoadBalancerHealthCheck returns the health check of the load balancer, using the defaults for the fields that are not set
+// in the HarvesterCluster.
+func getLoadBalancerHealthCheck(cluster *infrav1.HarvesterCluster) *lbv1beta1.HealthCheck {
+	healthCheck := &lbv1beta1.HealthCheck{
+		Port:             uint(getBackendPort(cluster)),
+		SuccessThreshold: infrav1.DefaultHealthCheckSuccessThreshold,
+		FailureThreshold: infrav1.DefaultHealthCheckFailureThreshold,
+		PeriodSeconds:    infrav1.DefaultHealthCheckPeriodSeconds,
+		TimeoutSeconds:   infrav1.DefaultHealthCheckTimeoutSeconds,
+	}
+
+	spec := cluster.Spec.LoadBalancerConfig.HealthCheck
+	if spec == nil {
+		return healthCheck
+	}
+
+	if spec.Port != 0 {
+		healthCheck.Port = uint(spec.Port)
+	}
+
+	if spec.SuccessThreshold != 0 {
+		healthCheck.SuccessThreshold = uint(spec.SuccessThreshold)
+	}
+
+	if spec.FailureThreshold != 0 {
+		healthCheck.FailureThreshold = uint(spec.FailureThreshold)
+	}
+
+	if spec.PeriodSeconds != 0 {
+		healthCheck.PeriodSeconds = uint(spec.PeriodSeconds)
+	}
+
+	if spec.TimeoutSeconds != 0 {
+		healthCheck.TimeoutSeconds = uint(spec.TimeoutSeconds)
+	}
+
+	return healthCheck
+}
+
+// getLoadBalancerIPAM returns the IPAM mode of the load balancer and the name of the IP Pool it should use, if any.
+func getLoadBalancerIPAM(cluster *infrav1.HarvesterCluster) (lbv1beta1.IPAM, string) {
+	ipam := cluster.Spec.LoadBalancerConfig.IPAM
+
+	switch ipam.Type {
+	case infrav1.IPAMTypeIPPool, infrav1.IPAMTypeIPAddressClaim:
+		return lbv1beta1.Pool, getIPPoolName(cluster)
+	case infrav1.IPAMTypeIPPoolRef:
+		if ipam.IPPoolRef != nil {
+			return lbv1beta1.Pool, ipam.IPPoolRef.Name
+		}
+	}
+
+	return lbv1beta1.DHCP, ""
+}
+
+// getIPPoolName returns the name of the IP Pool created in Harvester for a HarvesterCluster.
+func getIPPoolName(cluster *infrav1.HarvesterCluster) string {
+	return locutil.GenerateRFC1035Name([]string{cluster.Namespace, cluster.Name, "ippool"})
+}
+
+// getCreatedIPPoolName returns the name of the IP Pool created in Harvester recorded in the status of a HarvesterCluster,
+// or the one named after the HarvesterCluster if none was recorded.
+func getCreatedIPPoolName(cluster *infrav1.HarvesterCluster) string {
+	if cluster.Status.IPPool != "" {
+		return cluster.Status.IPPool
+	}
+
+	return getIPPoolName(cluster)
+}
+
+// getIPPoolSpecFromConfig returns the spec of the IP Pool to create in Harvester from its description in the HarvesterCluster.
+// Harvester IP Pools do not have exclusions, the ranges are split around the excluded addresses instead.
+func getIPPoolSpecFromConfig(ipPool *infrav1.IPPool, targetVMNamespace string) (lbv1beta1.IPPoolSpec, error) {
+	ranges := []lbv1beta1.Range{
+		{
+			Subnet:     ipPool.Subnet,
+			Gateway:    ipPool.Gateway,
+			RangeStart: ipPool.RangeStart,
+			RangeEnd:   ipPool.RangeEnd,
+		},
+	}
+
+	for _, ipRange := range ipPool.AdditionalRanges {
+		ranges = append(ranges, lbv1beta1.Range{
+			Subnet:     ipRange.Subnet,
+			Gateway:    ipRange.Gateway,
+			RangeStart: ipRange.RangeStart,
+			RangeEnd:   ipRange.RangeEnd,
+		})
+	}
+
+	ranges, err := locutil.ExcludeFromRanges(ranges, ipPool.Exclude)
+	if err != nil {
+		return lbv1beta1.IPPoolSpec{}, errors.Wrap(err, "invalid ranges in the IP Pool definition")
+	}
+
+	var scope []lbv1beta1.Tuple
+
+	for _, ipPoolScope := range ipPool.Scope {
+		scope = append(scope, lbv1beta1.Tuple{
+			Project:      ipPoolScope.Project,
+			Namespace:    ipPoolScope.Namespace,
+			GuestCluster: ipPoolScope.GuestCluster,
+		})
+	}
+
+	return lbv1beta1.IPPoolSpec{
+		Ranges: ranges,
+		Selector: lbv1beta1.Selector{
+			Network: ipPool.VMNetwork.NamespacedName(targetVMNamespace).String(),
+			Scope:   scope,
+		},
+	}, nil
+}
+
 // createIPPoolIfNotExists is a function that creates an IP Pool in Harvester.
-func createIPPoolIfNotExists(cluster *infrav1.HarvesterCluster,
-	lbClient lbclient.Interface,
-	machineNetwork string,
+func createIPPoolIfNotExists(scope *ClusterScope,
+	ipPoolSpec lbv1beta1.IPPoolSpec,
 	targetVMNamespace string,
 ) (*lbv1beta1.IPPool, error) {
+	cluster := scope.HarvesterCluster
+	lbClient := scope.HarvesterClient
+
 	ipPoolToCreate := lbv1beta1.IPPool{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      locutil.GenerateRFC1035Name([]string{cluster.Namespace, cluster.Name, "ippool"}),
+			Name:      getIPPoolName(cluster),
 			Namespace: targetVMNamespace,
 		},
-		Spec: lbv1beta1.IPPoolSpec{
-			Description: cpIPPoolDescriptionPrefix + " " + cluster.Name,
-			Ranges: []lbv1beta1.Range{
-				{
-					Subnet:     cluster.Spec.LoadBalancerConfig.IpPool.Subnet,
-					Gateway:    cluster.Spec.LoadBalancerConfig.IpPool.Gateway,
-					RangeStart: cluster.Spec.LoadBalancerConfig.IpPool.RangeStart,
-					RangeEnd:   cluster.Spec.LoadBalancerConfig.IpPool.RangeEnd,
-				},
-			},
-			Selector: lbv1beta1.Selector{
-				Network: machineNetwork,
-			},
-		},
+		Spec: ipPoolSpec,
 	}
+	ipPoolToCreate.Spec.Description = cpIPPoolDescriptionPrefix + " " + cluster.Name
 
-	createdIPPool, err := lbClient.LoadbalancerV1beta1().IPPools().Create(context.TODO(), &ipPoolToCreate, v1.CreateOptions{})
+	createdIPPool, err := lbClient.LoadbalancerV1beta1().IPPools().Create(scope.Ctx, &ipPoolToCreate, v1.CreateOptions{})
 	if err != nil {
 		if apierrors.IsAlreadyExists(err) {
-			return lbClient.LoadbalancerV1beta1().IPPools().Get(context.TODO(), ipPoolToCreate.Name, v1.GetOptions{})
+			cluster.Status.IPPool = ipPoolToCreate.Name
+			conditions.MarkTrue(cluster, infrav1.CustomIPPoolCreatedCondition)
+
+			return lbClient.LoadbalancerV1beta1().IPPools().Get(scope.Ctx, ipPoolToCreate.Name, v1.GetOptions{})
 		}
 
-		cluster.Status.Conditions = append(cluster.Status.Conditions, clusterv1.Condition{
-			Type:    infrav1.CustomIPPoolCreatedCondition,
-			Status:  apiv1.ConditionFalse,
-			Reason:  infrav1.CustomPoolCreationInHarvesterFailedReason,
-			Message: "Unable to create Custom Ip Pool in Harvester",
-		})
+		conditions.MarkFalse(cluster, infrav1.CustomIPPoolCreatedCondition,
+			infrav1.CustomPoolCreationInHarvesterFailedReason, clusterv1.ConditionSeverityError,
+			"unable to create custom IP Pool in Harvester: %s", err.Error())
 		cluster.Status.Ready = false
+		recordHarvesterEvent(scope.EventRecorder, cluster, harvesterActionCreate, "IP Pool "+ipPoolToCreate.Name, err)
 
 		return &lbv1beta1.IPPool{}, err
 	}
@@ -803,13 +1568,11 @@ func createIPPoolIfNotExists(cluster *infrav1.HarvesterCluster,
 		return &lbv1beta1.IPPool{}, fmt.Errorf("IP Pool for HarvesterCluster %s could not be correctly created", cluster.Name)
 	}
 
-	cluster.Status.Conditions = append(cluster.Status.Conditions, clusterv1.Condition{
-		Type:    infrav1.CustomIPPoolCreatedCondition,
-		Status:  apiv1.ConditionTrue,
-		Reason:  infrav1.CustomIPPoolCreatedSuccessfullyReason,
-		Message: "Custom Pool was created successfully",
-	})
+	cluster.Status.IPPool = createdIPPool.Name
+
+	conditions.MarkTrue(cluster, infrav1.CustomIPPoolCreatedCondition)
 	cluster.Status.Ready = false
+	recordHarvesterEvent(scope.EventRecorder, cluster, harvesterActionCreate, "IP Pool "+createdIPPool.Name, nil)
 
 	return createdIPPool, nil
 }
@@ -842,7 +1605,7 @@ func (r *HarvesterClusterReconciler) getOwnedCPHarversterMachines(scope *Cluster
 		hvMachineName := machine.Name
 		hvMachineNamespace := scope.HarvesterCluster.Spec.TargetNamespace
 
-		_, err := scope.HarvesterClient.KubevirtV1().VirtualMachines(hvMachineNamespace).Get(context.TODO(), hvMachineName, v1.GetOptions{})
+		_, err := scope.HarvesterClient.KubevirtV1().VirtualMachines(hvMachineNamespace).Get(scope.Ctx, hvMachineName, v1.GetOptions{})
 		if err != nil {
 			if apierrors.IsNotFound(err) {
 				scope.Logger.V(4).Info("Owned ControlPlane Machine does not exist in Harvester yet", "machine-name", //nolint:mnd
@@ -859,77 +1622,123 @@ func (r *HarvesterClusterReconciler) getOwnedCPHarversterMachines(scope *Cluster
 }
 
 // ReconcileDelete is the part of the Reconcialiation that deletes a HarvesterCluster and everything which depends on it.
-func (r *HarvesterClusterReconciler) ReconcileDelete(scope *ClusterScope) (ctrl.Result, error) {
+func (r *HarvesterClusterReconciler) ReconcileDelete(scope *ClusterScope) (res ctrl.Result, rerr error) {
 	logger := log.FromContext(scope.Ctx)
 	logger.Info("Deleting Harvester Cluster ...", "cluster-name", scope.HarvesterCluster.Name, "cluster-namespace", scope.HarvesterCluster.Namespace)
 
-	err := scope.HarvesterClient.LoadbalancerV1beta1().LoadBalancers(scope.HarvesterCluster.Spec.TargetNamespace).Delete(
-		context.TODO(),
-		locutil.GenerateRFC1035Name([]string{scope.HarvesterCluster.Namespace, scope.HarvesterCluster.Name, "lb"}),
+	endPhase := tracing.StartPhase(&scope.Ctx, "ReconcileDelete")
+	defer func() { endPhase(rerr) }()
+
+	lbRef := getLoadBalancerReference(scope.HarvesterCluster)
+
+	err := scope.HarvesterClient.LoadbalancerV1beta1().LoadBalancers(lbRef.Namespace).Delete(
+		scope.Ctx,
+		lbRef.Name,
 		v1.DeleteOptions{})
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
 			logger.Error(err, "unable to delete Load Balancer in Harvester")
+			recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionDelete,
+				"load balancer "+lbRef.Namespace+"/"+lbRef.Name, err)
 
 			return ctrl.Result{RequeueAfter: requeueTimeLong}, err
 		}
 
 		logger.Info("no Load Balancer to be deleted, skipping ...")
+	} else {
+		recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionDelete,
+			"load balancer "+lbRef.Namespace+"/"+lbRef.Name, nil)
 	}
 
 	logger.V(5).Info("Load Balancer deleted successfully")
 
+	scope.HarvesterCluster.Status.LoadBalancer = nil
+
 	if conditions.IsTrue(scope.HarvesterCluster, infrav1.CustomIPPoolCreatedCondition) {
 		err := scope.HarvesterClient.LoadbalancerV1beta1().IPPools().Delete(
-			context.TODO(),
-			scope.HarvesterCluster.Spec.LoadBalancerConfig.IpPoolRef,
+			scope.Ctx,
+			getCreatedIPPoolName(scope.HarvesterCluster),
 			v1.DeleteOptions{},
 		)
 		if err != nil {
 			if !apierrors.IsNotFound(err) {
 				logger.Error(err, "unable to delete IP Pool in Harvester")
+				recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionDelete,
+					"IP Pool "+getCreatedIPPoolName(scope.HarvesterCluster), err)
 
 				return ctrl.Result{RequeueAfter: requeueTimeLong}, err
 			}
 
 			logger.Info("no IP Pool to be deleted, skipping ...")
+		} else {
+			recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionDelete,
+				"IP Pool "+getCreatedIPPoolName(scope.HarvesterCluster), nil)
 		}
 
 		logger.Info("Custom IP Pool deleted")
 		conditions.Delete(scope.HarvesterCluster, infrav1.CustomIPPoolCreatedCondition)
 	}
 
-	err = scope.HarvesterClient.CoreV1().Services(scope.HarvesterCluster.Spec.TargetNamespace).Delete(
-		context.TODO(),
-		locutil.GenerateRFC1035Name([]string{scope.HarvesterCluster.Namespace, scope.HarvesterCluster.Name, "lb"}),
+	placeholderRef := getPlaceholderServiceReference(scope.HarvesterCluster)
+
+	err = scope.HarvesterClient.CoreV1().Services(placeholderRef.Namespace).Delete(
+		scope.Ctx,
+		placeholderRef.Name,
 		v1.DeleteOptions{})
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
 			logger.Error(err, "unable to delete Load Balancer Service in Harvester")
+			recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionDelete,
+				"placeholder service "+placeholderRef.Namespace+"/"+placeholderRef.Name, err)
 
 			return ctrl.Result{RequeueAfter: requeueTimeLong}, err
 		}
 
 		logger.Info("no Load Balancer Service to be deleted, skipping ...")
+	} else {
+		recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionDelete,
+			"placeholder service "+placeholderRef.Namespace+"/"+placeholderRef.Name, nil)
 	}
 
 	logger.V(5).Info("Load Balancer Service deleted successfully") //nolint:mnd
 
+	scope.HarvesterCluster.Status.PlaceholderService = nil
+
 	err = scope.HarvesterClient.LoadbalancerV1beta1().IPPools().Delete(
-		context.TODO(),
-		locutil.GenerateRFC1035Name([]string{scope.HarvesterCluster.Namespace, scope.HarvesterCluster.Name, "ippool"}),
+		scope.Ctx,
+		getCreatedIPPoolName(scope.HarvesterCluster),
 		v1.DeleteOptions{})
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
 			logger.Error(err, "unable to delete generated IP Pool in Harvester")
+			recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionDelete,
+				"IP Pool "+getCreatedIPPoolName(scope.HarvesterCluster), err)
 
 			return ctrl.Result{RequeueAfter: requeueTimeLong}, err
 		}
 
 		logger.Info("no IP Pool to be deleted, skipping ...")
+	} else {
+		recordHarvesterEvent(scope.EventRecorder, scope.HarvesterCluster, harvesterActionDelete,
+			"IP Pool "+getCreatedIPPoolName(scope.HarvesterCluster), nil)
 	}
 
 	logger.V(5).Info("IP Pool deleted successfully") //nolint:mnd
+
+	scope.HarvesterCluster.Status.IPPool = ""
+
+	if err := releaseAllocatedAddress(scope); err != nil {
+		logger.Error(err, "unable to release the control plane address from its IP Pool")
+
+		return ctrl.Result{RequeueAfter: requeueTimeLong}, err
+	}
+
+	if err := releaseLoadBalancerAddress(scope); err != nil {
+		logger.Error(err, "unable to release the address of the Load Balancer")
+
+		return ctrl.Result{RequeueAfter: requeueTimeLong}, err
+	}
+
 	logger.Info("Removing finalizer from HarvesterCluster ...",
 		"cluster-name", scope.HarvesterCluster.Name,
 		"cluster-namespace", scope.HarvesterCluster.Namespace)
@@ -18,22 +18,39 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/containernetworking/plugins/plugins/ipam/host-local/backend/allocator"
 	"github.com/go-logr/logr"
+	lbv1beta1 "github.com/harvester/harvester-load-balancer/pkg/apis/loadbalancer.harvesterhci.io/v1beta1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha1"
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
 	hvclient "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/clientset/versioned"
+	hvfake "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/clientset/versioned/fake"
+	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
 )
 
 var _ = Describe("Extract Server from Kubeconfig", func() {
@@ -250,3 +267,837 @@ data:
 	})
 
 })
+
+var _ = Describe("Get an IP Pool spec from its definition", func() {
+	It("Should split the ranges around the exclusions and set the scope", func() {
+		ipPoolSpec, err := getIPPoolSpecFromConfig(&infrav1.IPPool{
+			VMNetwork:  infrav1.ObjectReference{Name: "vm-network"},
+			Subnet:     "172.19.0.0/16",
+			Gateway:    "172.19.0.1",
+			RangeStart: "172.19.10.1",
+			RangeEnd:   "172.19.10.10",
+			AdditionalRanges: []infrav1.IPPoolRange{
+				{Subnet: "172.20.0.0/16", Gateway: "172.20.0.1", RangeStart: "172.20.10.1", RangeEnd: "172.20.10.10"},
+			},
+			Exclude: []string{"172.19.10.5"},
+			Scope:   []infrav1.IPPoolScope{{Namespace: "default"}},
+		}, "default")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipPoolSpec.Ranges).To(Equal([]lbv1beta1.Range{
+			{Subnet: "172.19.0.0/16", Gateway: "172.19.0.1", RangeStart: "172.19.10.1", RangeEnd: "172.19.10.4"},
+			{Subnet: "172.19.0.0/16", Gateway: "172.19.0.1", RangeStart: "172.19.10.6", RangeEnd: "172.19.10.10"},
+			{Subnet: "172.20.0.0/16", Gateway: "172.20.0.1", RangeStart: "172.20.10.1", RangeEnd: "172.20.10.10"},
+		}))
+		Expect(ipPoolSpec.Selector.Network).To(Equal("default/vm-network"))
+		Expect(ipPoolSpec.Selector.Scope).To(Equal([]lbv1beta1.Tuple{{Namespace: "default"}}))
+	})
+})
+
+var _ = Describe("Get an IP Pool spec from an IPAddress", func() {
+	It("Should hold only the claimed address", func() {
+		ipAddress := &ipamv1.IPAddress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-lb"},
+			Spec: ipamv1.IPAddressSpec{
+				Address: "172.19.10.20",
+				Prefix:  24,
+				Gateway: "172.19.10.1",
+			},
+		}
+
+		ipPoolSpec, err := getIPPoolSpecFromIPAddress(ipAddress)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipPoolSpec.Ranges).To(HaveLen(1))
+		Expect(ipPoolSpec.Ranges[0].Subnet).To(Equal("172.19.10.0/24"))
+		Expect(ipPoolSpec.Ranges[0].Gateway).To(Equal("172.19.10.1"))
+		Expect(ipPoolSpec.Ranges[0].RangeStart).To(Equal("172.19.10.20"))
+		Expect(ipPoolSpec.Ranges[0].RangeEnd).To(Equal("172.19.10.20"))
+	})
+
+	It("Should fail with an invalid address", func() {
+		ipAddress := &ipamv1.IPAddress{
+			Spec: ipamv1.IPAddressSpec{Address: "172.19.10", Prefix: 24},
+		}
+
+		_, err := getIPPoolSpecFromIPAddress(ipAddress)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Map an IPAddress to the owner of its IPAddressClaim", func() {
+	scheme := runtime.NewScheme()
+	_ = ipamv1.AddToScheme(scheme)
+
+	claim := &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-lb",
+			Namespace: "test-hv",
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: infrav1.GroupVersion.String(),
+					Kind:       "HarvesterCluster",
+					Name:       "test",
+					UID:        "test-uid",
+					Controller: locutil.NewTrue(),
+				},
+			},
+		},
+	}
+	ipAddress := &ipamv1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-lb",
+			Namespace: "test-hv",
+		},
+		Spec: ipamv1.IPAddressSpec{
+			ClaimRef: corev1.LocalObjectReference{Name: "test-lb"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(claim).Build()
+
+	It("Should enqueue the owner of the claim", func() {
+		mapFunc := ipAddressToClaimOwnerMapFunc(fakeClient, infrav1.GroupVersion.WithKind("HarvesterCluster"))
+
+		Expect(mapFunc(context.TODO(), ipAddress)).To(ConsistOf(reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: "test-hv", Name: "test"},
+		}))
+	})
+
+	It("Should not enqueue anything for an owner of another kind", func() {
+		mapFunc := ipAddressToClaimOwnerMapFunc(fakeClient, infrav1.GroupVersion.WithKind("HarvesterMachine"))
+
+		Expect(mapFunc(context.TODO(), ipAddress)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Detect drift of the load balancer spec", func() {
+	var scope *ClusterScope
+	var desiredLB, existingLB *lbv1beta1.LoadBalancer
+
+	BeforeEach(func() {
+		scope = &ClusterScope{
+			Cluster: &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-hv"},
+			},
+			HarvesterCluster: &infrav1.HarvesterCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-hv", Namespace: "test-hv"},
+				Spec: infrav1.HarvesterClusterSpec{
+					TargetNamespace: "default",
+					LoadBalancerConfig: infrav1.LoadBalancerConfig{
+						IPAM: infrav1.IPAMConfig{Type: infrav1.IPAMTypeDHCP},
+						Listeners: []infrav1.Listener{
+							{Name: "rke2-server", Port: 9345, Protocol: corev1.ProtocolTCP, BackendPort: 9345},
+						},
+					},
+				},
+			},
+		}
+
+		desiredLB = buildLoadBalancer(scope)
+		existingLB = desiredLB.DeepCopy()
+	})
+
+	It("Should not report any drift for an identical load balancer", func() {
+		Expect(getLoadBalancerDrift(desiredLB, existingLB)).To(BeEmpty())
+	})
+
+	It("Should report a changed listener", func() {
+		scope.HarvesterCluster.Spec.LoadBalancerConfig.Listeners[0].Port = 9346
+
+		Expect(getLoadBalancerDrift(buildLoadBalancer(scope), existingLB)).To(ConsistOf("listeners"))
+	})
+
+	It("Should report a changed description", func() {
+		scope.HarvesterCluster.Spec.LoadBalancerConfig.Description = "my load balancer"
+
+		Expect(getLoadBalancerDrift(buildLoadBalancer(scope), existingLB)).To(ConsistOf("description"))
+	})
+
+	It("Should report a changed IPAM and health check", func() {
+		existingLB.Spec.IPAM = lbv1beta1.Pool
+		existingLB.Spec.IPPool = "other-pool"
+		existingLB.Spec.HealthCheck.PeriodSeconds = 5
+
+		Expect(getLoadBalancerDrift(desiredLB, existingLB)).To(ConsistOf("ipam", "healthCheck"))
+	})
+
+	It("Should use the configured API server ports and health check", func() {
+		scope.HarvesterCluster.Spec.LoadBalancerConfig.APIServerPort = 443
+		scope.HarvesterCluster.Spec.LoadBalancerConfig.BackendPort = 9443
+		scope.HarvesterCluster.Spec.LoadBalancerConfig.HealthCheck = &infrav1.HealthCheck{FailureThreshold: 1, PeriodSeconds: 5}
+
+		lb := buildLoadBalancer(scope)
+
+		Expect(lb.Spec.Listeners).To(ContainElement(lbv1beta1.Listener{
+			Name:        apiServerListener,
+			Port:        443,
+			Protocol:    apiServerProtocol,
+			BackendPort: 9443,
+		}))
+		Expect(*lb.Spec.HealthCheck).To(Equal(lbv1beta1.HealthCheck{
+			Port:             9443,
+			SuccessThreshold: infrav1.DefaultHealthCheckSuccessThreshold,
+			FailureThreshold: 1,
+			PeriodSeconds:    5,
+			TimeoutSeconds:   infrav1.DefaultHealthCheckTimeoutSeconds,
+		}))
+	})
+})
+
+var _ = Describe("Reserve the virtual IP of kube-vip", func() {
+	var scope *ClusterScope
+
+	BeforeEach(func() {
+		ipPool := &lbv1beta1.IPPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared-pool"},
+			Spec: lbv1beta1.IPPoolSpec{
+				Ranges: []lbv1beta1.Range{
+					{
+						Subnet:     "172.19.0.0/16",
+						Gateway:    "172.19.0.1",
+						RangeStart: "172.19.10.1",
+						RangeEnd:   "172.19.10.10",
+					},
+				},
+			},
+			Status: lbv1beta1.IPPoolStatus{
+				Available:        10,
+				AllocatedHistory: map[string]string{},
+			},
+		}
+
+		scope = &ClusterScope{
+			Ctx: context.TODO(),
+			HarvesterCluster: &infrav1.HarvesterCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-hv", Namespace: "test-hv"},
+				Spec: infrav1.HarvesterClusterSpec{
+					TargetNamespace: "default",
+					LoadBalancerConfig: infrav1.LoadBalancerConfig{
+						Type: infrav1.LoadBalancerTypeKubeVIP,
+						IPAM: infrav1.IPAMConfig{
+							Type:      infrav1.IPAMTypeIPPoolRef,
+							IPPoolRef: &infrav1.IPPoolReference{Name: "shared-pool"},
+						},
+					},
+				},
+			},
+			HarvesterClient: hvfake.NewSimpleClientset(ipPool),
+		}
+	})
+
+	It("Should allocate the virtual IP from the referenced IP Pool and release it", func() {
+		vip, err := reserveKubeVIPAddress(scope)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vip).To(Equal("172.19.10.1"))
+
+		ipPool, err := scope.HarvesterClient.LoadbalancerV1beta1().IPPools().Get(context.TODO(), "shared-pool", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipPool.Status.Allocated).To(HaveKeyWithValue("172.19.10.1", getKubeVIPOwner(scope.HarvesterCluster)))
+		Expect(ipPool.Status.Available).To(BeEquivalentTo(9))
+
+		Expect(reserveKubeVIPAddress(scope)).To(Equal(vip))
+
+		Expect(releaseAllocatedAddress(scope)).To(Succeed())
+
+		ipPool, err = scope.HarvesterClient.LoadbalancerV1beta1().IPPools().Get(context.TODO(), "shared-pool", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipPool.Status.Allocated).To(BeEmpty())
+		Expect(ipPool.Status.Available).To(BeEquivalentTo(10))
+	})
+
+	It("Should use the hostname with DHCP", func() {
+		scope.HarvesterCluster.Spec.LoadBalancerConfig.IPAM = infrav1.IPAMConfig{Type: infrav1.IPAMTypeDHCP}
+		scope.HarvesterCluster.Spec.LoadBalancerConfig.KubeVIP = &infrav1.KubeVIPConfig{Hostname: "cp.example.com"}
+
+		Expect(reserveKubeVIPAddress(scope)).To(Equal("cp.example.com"))
+	})
+})
+
+var _ = Describe("Allocate the load balancer address of the primary IP family", func() {
+	var scope *ClusterScope
+
+	BeforeEach(func() {
+		ipPool := &lbv1beta1.IPPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "dual-stack-pool"},
+			Spec: lbv1beta1.IPPoolSpec{
+				Ranges: []lbv1beta1.Range{
+					{
+						Subnet:     "172.19.0.0/16",
+						Gateway:    "172.19.0.1",
+						RangeStart: "172.19.10.1",
+						RangeEnd:   "172.19.10.10",
+					},
+					{
+						Subnet:     "fd00:172:19::/64",
+						Gateway:    "fd00:172:19::1",
+						RangeStart: "fd00:172:19::10",
+						RangeEnd:   "fd00:172:19::20",
+					},
+				},
+			},
+			Status: lbv1beta1.IPPoolStatus{
+				Available:        22,
+				AllocatedHistory: map[string]string{},
+			},
+		}
+
+		scope = &ClusterScope{
+			Ctx: context.TODO(),
+			HarvesterCluster: &infrav1.HarvesterCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-hv", Namespace: "test-hv"},
+				Spec: infrav1.HarvesterClusterSpec{
+					TargetNamespace: "default",
+					LoadBalancerConfig: infrav1.LoadBalancerConfig{
+						IPAM: infrav1.IPAMConfig{
+							Type:      infrav1.IPAMTypeIPPoolRef,
+							IPPoolRef: &infrav1.IPPoolReference{Name: "dual-stack-pool"},
+						},
+					},
+				},
+			},
+			HarvesterClient: hvfake.NewSimpleClientset(ipPool),
+		}
+	})
+
+	It("Should allocate an IPv4 address by default", func() {
+		Expect(getIPFromIPPool(scope, "default/test-hv-lb")).To(Equal("172.19.10.1"))
+	})
+
+	It("Should allocate an IPv6 address when IPv6 is the primary IP family", func() {
+		scope.HarvesterCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}
+
+		Expect(getIPFromIPPool(scope, "default/test-hv-lb")).To(Equal("fd00:172:19::10"))
+	})
+
+	It("Should sort the load balancer addresses by IP family", func() {
+		scope.HarvesterCluster.Spec.LoadBalancerConfig.IPFamilies = []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}
+
+		addresses := getLoadBalancerAddresses(scope.HarvesterCluster, []string{"172.19.10.1", "fd00:172:19:0::10", "172.19.10.2"})
+		Expect(addresses).To(Equal([]infrav1.LoadBalancerAddress{
+			{IPFamily: corev1.IPv6Protocol, Address: "fd00:172:19::10"},
+			{IPFamily: corev1.IPv4Protocol, Address: "172.19.10.1"},
+		}))
+		Expect(getPrimaryLoadBalancerAddress(scope.HarvesterCluster, addresses)).To(Equal("fd00:172:19::10"))
+	})
+})
+
+var _ = Describe("Allocate addresses from a shared IP Pool concurrently", func() {
+	var (
+		scope    *ClusterScope
+		hvClient *hvfake.Clientset
+		updates  int
+	)
+
+	BeforeEach(func() {
+		ipPool := &lbv1beta1.IPPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared-pool", ResourceVersion: "1"},
+			Spec: lbv1beta1.IPPoolSpec{
+				Ranges: []lbv1beta1.Range{
+					{
+						Subnet:     "172.19.0.0/16",
+						Gateway:    "172.19.0.1",
+						RangeStart: "172.19.10.1",
+						RangeEnd:   "172.19.10.10",
+					},
+				},
+			},
+			Status: lbv1beta1.IPPoolStatus{
+				Available: 10,
+			},
+		}
+
+		hvClient = hvfake.NewSimpleClientset(ipPool)
+		updates = 0
+
+		// The fake clientset does not check the resource version of the updated objects like the API server does.
+		// Its reactors are run one at a time, so the check and the update of the stored IP Pool are atomic.
+		hvClient.PrependReactor("update", "ippools", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updatedPool := action.(k8stesting.UpdateAction).GetObject().(*lbv1beta1.IPPool).DeepCopy()
+
+			storedPool, err := hvClient.Tracker().Get(action.GetResource(), "", updatedPool.Name)
+			if err != nil {
+				return true, nil, err
+			}
+
+			if storedPool.(*lbv1beta1.IPPool).ResourceVersion != updatedPool.ResourceVersion {
+				return true, nil, apierrors.NewConflict(action.GetResource().GroupResource(), updatedPool.Name,
+					errors.New("the object has been modified"))
+			}
+
+			resourceVersion, err := strconv.Atoi(updatedPool.ResourceVersion)
+			if err != nil {
+				return true, nil, err
+			}
+
+			updatedPool.ResourceVersion = strconv.Itoa(resourceVersion + 1)
+			updates++
+
+			return true, updatedPool, hvClient.Tracker().Update(action.GetResource(), updatedPool, "")
+		})
+
+		scope = &ClusterScope{
+			Ctx: context.TODO(),
+			HarvesterCluster: &infrav1.HarvesterCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-hv", Namespace: "test-hv"},
+				Spec: infrav1.HarvesterClusterSpec{
+					TargetNamespace: "default",
+					LoadBalancerConfig: infrav1.LoadBalancerConfig{
+						IPAM: infrav1.IPAMConfig{
+							Type:      infrav1.IPAMTypeIPPoolRef,
+							IPPoolRef: &infrav1.IPPoolReference{Name: "shared-pool"},
+						},
+					},
+				},
+			},
+			HarvesterClient: hvClient,
+		}
+	})
+
+	It("Should give a distinct address to each applicant", func() {
+		const applicants = 4
+
+		ips := make([]string, applicants)
+		errs := make([]error, applicants)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < applicants; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+				defer GinkgoRecover()
+
+				ips[i], errs[i] = allocateIPFromPool(scope, "shared-pool", fmt.Sprintf("default/test-lb-%d", i))
+			}(i)
+		}
+
+		wg.Wait()
+
+		for i := 0; i < applicants; i++ {
+			Expect(errs[i]).NotTo(HaveOccurred())
+		}
+
+		Expect(ips).To(ConsistOf("172.19.10.1", "172.19.10.2", "172.19.10.3", "172.19.10.4"))
+
+		ipPool, err := hvClient.LoadbalancerV1beta1().IPPools().Get(context.TODO(), "shared-pool", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipPool.Status.Allocated).To(HaveLen(applicants))
+		Expect(ipPool.Status.Available).To(BeEquivalentTo(10 - applicants))
+	})
+
+	It("Should allocate again from the latest IP Pool after a conflict", func() {
+		// Another applicant gets the first address between the read and the update of the IP Pool.
+		conflicted := false
+
+		hvClient.PrependReactor("update", "ippools", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if conflicted {
+				return false, nil, nil
+			}
+
+			conflicted = true
+
+			storedPool, err := hvClient.Tracker().Get(action.GetResource(), "", "shared-pool")
+			if err != nil {
+				return true, nil, err
+			}
+
+			otherPool := storedPool.(*lbv1beta1.IPPool).DeepCopy()
+			otherPool.ResourceVersion = "2"
+
+			if _, err := locutil.NewStore(otherPool).Reserve("default/other-lb", "", net.ParseIP("172.19.10.1"), ""); err != nil {
+				return true, nil, err
+			}
+
+			if err := hvClient.Tracker().Update(action.GetResource(), otherPool, ""); err != nil {
+				return true, nil, err
+			}
+
+			return false, nil, nil
+		})
+
+		Expect(allocateIPFromPool(scope, "shared-pool", "default/test-lb")).To(Equal("172.19.10.2"))
+
+		ipPool, err := hvClient.LoadbalancerV1beta1().IPPools().Get(context.TODO(), "shared-pool", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipPool.Status.Allocated).To(Equal(map[string]string{
+			"172.19.10.1": "default/other-lb",
+			"172.19.10.2": "default/test-lb",
+		}))
+		Expect(ipPool.Status.Available).To(BeEquivalentTo(8))
+	})
+
+	It("Should not allocate a second address to the same applicant", func() {
+		Expect(allocateIPFromPool(scope, "shared-pool", "default/test-lb")).To(Equal("172.19.10.1"))
+		Expect(allocateIPFromPool(scope, "shared-pool", "default/test-lb")).To(Equal("172.19.10.1"))
+		Expect(updates).To(Equal(1))
+	})
+
+	It("Should give the released address back to the same applicant", func() {
+		Expect(allocateIPFromPool(scope, "shared-pool", "default/other-lb")).To(Equal("172.19.10.1"))
+		Expect(allocateIPFromPool(scope, "shared-pool", "default/test-lb")).To(Equal("172.19.10.2"))
+		Expect(releaseIPFromPool(scope, "shared-pool", "default/test-lb")).To(Succeed())
+		Expect(allocateIPFromPool(scope, "shared-pool", "default/third-lb")).To(Equal("172.19.10.3"))
+		Expect(allocateIPFromPool(scope, "shared-pool", "default/test-lb")).To(Equal("172.19.10.2"))
+	})
+
+	It("Should hand the reserved address over to the load balancer while another applicant allocates from the IP Pool", func() {
+		scope.Cluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-hv"}}
+		Expect(getIPFromIPPool(scope, getIPPoolApplicant(scope.HarvesterCluster))).To(Equal("172.19.10.1"))
+
+		// Another applicant allocates an address between the reservation and the creation of the load balancer,
+		// then Harvester only allocates an address to the load balancer if none was handed over in its status.
+		hvClient.PrependReactor("create", "loadbalancers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			storedPool, err := hvClient.Tracker().Get(lbv1beta1.SchemeGroupVersion.WithResource("ippools"), "", "shared-pool")
+			if err != nil {
+				return true, nil, err
+			}
+
+			otherPool := storedPool.(*lbv1beta1.IPPool).DeepCopy()
+			rangeSet, err := getIPPoolRangeSet(otherPool)
+			if err != nil {
+				return true, nil, err
+			}
+
+			store := locutil.NewStore(otherPool)
+			if _, err := allocator.NewIPAllocator(&rangeSet, store, 0).Get("default/other-lb", "", nil); err != nil {
+				return true, nil, err
+			}
+
+			lb := action.(k8stesting.CreateAction).GetObject().(*lbv1beta1.LoadBalancer).DeepCopy()
+			if lb.Status.AllocatedAddress.IPPool != lb.Spec.IPPool {
+				ipConfig, err := allocator.NewIPAllocator(&rangeSet, store, 0).Get(lb.Namespace+"/"+lb.Name, "", nil)
+				if err != nil {
+					return true, nil, err
+				}
+
+				lb.Status.AllocatedAddress = lbv1beta1.AllocatedAddress{IPPool: lb.Spec.IPPool, IP: ipConfig.Address.IP.String()}
+			}
+
+			lb.Status.Address = lb.Status.AllocatedAddress.IP
+
+			if err := hvClient.Tracker().Update(lbv1beta1.SchemeGroupVersion.WithResource("ippools"), otherPool, ""); err != nil {
+				return true, nil, err
+			}
+
+			return true, lb, hvClient.Tracker().Create(action.GetResource(), lb, action.GetNamespace())
+		})
+
+		Expect(reconcileLoadBalancer(scope)).To(Succeed())
+
+		lb, err := hvClient.LoadbalancerV1beta1().LoadBalancers("default").Get(context.TODO(), "test-hv-test-hv-lb", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lb.Status.AllocatedAddress).To(Equal(lbv1beta1.AllocatedAddress{
+			IPPool: "shared-pool", IP: "172.19.10.1", Mask: "255.255.0.0", Gateway: "172.19.0.1",
+		}))
+
+		ipPool, err := hvClient.LoadbalancerV1beta1().IPPools().Get(context.TODO(), "shared-pool", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipPool.Status.Allocated).To(Equal(map[string]string{
+			"172.19.10.1": "default/test-hv-test-hv-lb",
+			"172.19.10.2": "default/other-lb",
+		}))
+
+		addresses, err := getHarvesterLoadBalancerAddresses(scope)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getPrimaryLoadBalancerAddress(scope.HarvesterCluster, addresses)).To(Equal("172.19.10.1"))
+	})
+
+	It("Should detect a load balancer which did not get the reserved address", func() {
+		Expect(getIPFromIPPool(scope, getIPPoolApplicant(scope.HarvesterCluster))).To(Equal("172.19.10.1"))
+
+		err := checkReservedAddress(scope.HarvesterCluster, &lbv1beta1.LoadBalancer{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-hv-test-hv-lb", Namespace: "default"},
+			Spec:       lbv1beta1.LoadBalancerSpec{IPAM: lbv1beta1.Pool, IPPool: "shared-pool"},
+			Status:     lbv1beta1.LoadBalancerStatus{Address: "172.19.10.2"},
+		})
+		Expect(errors.Is(err, errLoadBalancerAddressMismatch)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("got address 172.19.10.2 from IP Pool shared-pool instead of the reserved address 172.19.10.1"))
+	})
+
+	It("Should accept the reserved address given to the load balancer", func() {
+		Expect(getIPFromIPPool(scope, getIPPoolApplicant(scope.HarvesterCluster))).To(Equal("172.19.10.1"))
+
+		Expect(checkReservedAddress(scope.HarvesterCluster, &lbv1beta1.LoadBalancer{
+			Spec:   lbv1beta1.LoadBalancerSpec{IPAM: lbv1beta1.Pool, IPPool: "shared-pool"},
+			Status: lbv1beta1.LoadBalancerStatus{Address: "172.19.10.1"},
+		})).To(Succeed())
+	})
+
+	It("Should release the address of a deleted cluster and keep it in the history", func() {
+		applicant := getIPPoolApplicant(scope.HarvesterCluster)
+		Expect(applicant).To(Equal("default/test-hv-test-hv-lb"))
+
+		Expect(getIPFromIPPool(scope, applicant)).To(Equal("172.19.10.1"))
+		Expect(scope.HarvesterCluster.Status.AllocatedAddress).To(Equal(&infrav1.IPPoolAllocation{
+			IPPool:  "shared-pool",
+			Address: "172.19.10.1",
+		}))
+
+		Expect(releaseAllocatedAddress(scope)).To(Succeed())
+		Expect(scope.HarvesterCluster.Status.AllocatedAddress).To(BeNil())
+
+		ipPool, err := hvClient.LoadbalancerV1beta1().IPPools().Get(context.TODO(), "shared-pool", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipPool.Status.Allocated).To(BeEmpty())
+		Expect(ipPool.Status.AllocatedHistory).To(HaveKeyWithValue("172.19.10.1", applicant))
+		Expect(ipPool.Status.Available).To(BeEquivalentTo(10))
+	})
+})
+
+var _ = Describe("Record the resources created in Harvester", func() {
+	var scope *ClusterScope
+
+	BeforeEach(func() {
+		scope = &ClusterScope{
+			Ctx: context.TODO(),
+			HarvesterCluster: &infrav1.HarvesterCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-hv", Namespace: "test-hv"},
+				Spec: infrav1.HarvesterClusterSpec{
+					TargetNamespace: "default",
+				},
+			},
+		}
+	})
+
+	It("Should get the Harvester version from the labels of its Deployment or its image", func() {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "harvester",
+				Labels: map[string]string{"app.kubernetes.io/version": "v1.4.0"},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: "harvester", Image: "registry.local:5000/rancher/harvester:v1.3.2"},
+						},
+					},
+				},
+			},
+		}
+		Expect(getHarvesterVersion(deployment)).To(Equal("v1.4.0"))
+
+		deployment.Labels = nil
+		Expect(getHarvesterVersion(deployment)).To(Equal("v1.3.2"))
+
+		deployment.Spec.Template.Spec.Containers[0].Image = "registry.local:5000/rancher/harvester"
+		Expect(getHarvesterVersion(deployment)).To(BeEmpty())
+	})
+
+	It("Should keep the address of a recorded load balancer", func() {
+		recordLoadBalancer(scope.HarvesterCluster, &lbv1beta1.LoadBalancer{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-hv-test-hv-lb", Namespace: "default"},
+			Status:     lbv1beta1.LoadBalancerStatus{Address: "172.19.10.1"},
+		})
+		recordLoadBalancer(scope.HarvesterCluster, &lbv1beta1.LoadBalancer{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-hv-test-hv-lb", Namespace: "default"},
+		})
+
+		Expect(scope.HarvesterCluster.Status.LoadBalancer).To(Equal(&infrav1.LoadBalancerReference{
+			Name:      "test-hv-test-hv-lb",
+			Namespace: "default",
+			Address:   "172.19.10.1",
+		}))
+	})
+
+	It("Should use the recorded load balancer as applicant of the IP Pool", func() {
+		Expect(getIPPoolApplicant(scope.HarvesterCluster)).To(Equal("default/test-hv-test-hv-lb"))
+
+		scope.HarvesterCluster.Status.LoadBalancer = &infrav1.LoadBalancerReference{Name: "recorded-lb", Namespace: "other"}
+		Expect(getIPPoolApplicant(scope.HarvesterCluster)).To(Equal("other/recorded-lb"))
+	})
+
+	It("Should delete the recorded resources", func() {
+		scope.HarvesterCluster.Spec.TargetNamespace = "other"
+		scope.HarvesterCluster.Status.LoadBalancer = &infrav1.LoadBalancerReference{Name: "recorded-lb", Namespace: "default"}
+		scope.HarvesterCluster.Status.PlaceholderService = &infrav1.HarvesterResourceReference{Name: "recorded-lb", Namespace: "default"}
+		scope.HarvesterCluster.Status.IPPool = "recorded-pool"
+
+		hvClient := hvfake.NewSimpleClientset(
+			&lbv1beta1.LoadBalancer{ObjectMeta: metav1.ObjectMeta{Name: "recorded-lb", Namespace: "default"}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "recorded-lb", Namespace: "default"}},
+			&lbv1beta1.IPPool{ObjectMeta: metav1.ObjectMeta{Name: "recorded-pool"}},
+		)
+		scope.HarvesterClient = hvClient
+
+		scheme := runtime.NewScheme()
+		Expect(ipamv1.AddToScheme(scheme)).To(Succeed())
+		scope.ReconcileClient = fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		r := &HarvesterClusterReconciler{}
+		Expect(r.ReconcileDelete(scope)).To(Equal(reconcile.Result{}))
+
+		_, err := hvClient.LoadbalancerV1beta1().LoadBalancers("default").Get(context.TODO(), "recorded-lb", metav1.GetOptions{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		_, err = hvClient.CoreV1().Services("default").Get(context.TODO(), "recorded-lb", metav1.GetOptions{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		_, err = hvClient.LoadbalancerV1beta1().IPPools().Get(context.TODO(), "recorded-pool", metav1.GetOptions{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		Expect(scope.HarvesterCluster.Status.LoadBalancer).To(BeNil())
+		Expect(scope.HarvesterCluster.Status.PlaceholderService).To(BeNil())
+		Expect(scope.HarvesterCluster.Status.IPPool).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Set the conditions of the HarvesterCluster", func() {
+	var cluster *infrav1.HarvesterCluster
+
+	BeforeEach(func() {
+		cluster = &infrav1.HarvesterCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-hv", Namespace: "test-hv"},
+			Spec: infrav1.HarvesterClusterSpec{
+				TargetNamespace: "default",
+			},
+		}
+	})
+
+	It("Should not duplicate the condition of the custom IP Pool", func() {
+		recorder := record.NewFakeRecorder(10)
+		scope := &ClusterScope{
+			HarvesterCluster: cluster,
+			HarvesterClient:  hvfake.NewSimpleClientset(),
+			EventRecorder:    recorder,
+		}
+
+		// IP Pools are cluster-scoped, the fake clientset rejects them with a namespace unlike the API server
+		for i := 0; i < 2; i++ {
+			_, err := createIPPoolIfNotExists(scope, lbv1beta1.IPPoolSpec{}, "")
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(Equal("Normal SuccessfulCreate Created IP Pool " + getIPPoolName(cluster) + " in Harvester"))
+		Expect(cluster.Status.Conditions).To(HaveLen(1))
+		Expect(conditions.IsTrue(cluster, infrav1.CustomIPPoolCreatedCondition)).To(BeTrue())
+		Expect(conditions.Get(cluster, infrav1.CustomIPPoolCreatedCondition).LastTransitionTime.IsZero()).To(BeFalse())
+	})
+
+	It("Should summarize the conditions of the load balancer in the Ready condition", func() {
+		conditions.MarkTrue(cluster, infrav1.CloudProviderConfigReadyCondition)
+		conditions.MarkFalse(cluster, infrav1.LoadBalancerReadyCondition, infrav1.LoadBalancerNotReadyReason,
+			clusterv1.ConditionSeverityInfo, "waiting for the address of the load balancer")
+		conditions.SetSummary(cluster, conditions.WithConditions(getReadyConditions(cluster)...))
+
+		Expect(conditions.IsFalse(cluster, clusterv1.ReadyCondition)).To(BeTrue())
+		Expect(conditions.GetReason(cluster, clusterv1.ReadyCondition)).To(Equal(infrav1.LoadBalancerNotReadyReason))
+
+		conditions.MarkTrue(cluster, infrav1.LoadBalancerReadyCondition)
+		conditions.SetSummary(cluster, conditions.WithConditions(getReadyConditions(cluster)...))
+
+		Expect(conditions.IsTrue(cluster, clusterv1.ReadyCondition)).To(BeTrue())
+	})
+
+	It("Should not summarize the reachability of an external endpoint", func() {
+		cluster.Spec.LoadBalancerConfig.Type = infrav1.LoadBalancerTypeExternal
+		conditions.MarkTrue(cluster, infrav1.CloudProviderConfigReadyCondition)
+		conditions.MarkFalse(cluster, infrav1.ControlPlaneEndpointReachableCondition, infrav1.ControlPlaneEndpointUnreachableReason,
+			clusterv1.ConditionSeverityWarning, "connection refused")
+		conditions.SetSummary(cluster, conditions.WithConditions(getReadyConditions(cluster)...))
+
+		Expect(conditions.IsTrue(cluster, clusterv1.ReadyCondition)).To(BeTrue())
+	})
+})
+
+// setConditionLastTransitionTime sets when a condition of a HarvesterCluster last changed, which conditions.Set keeps as long as the status is the same.
+func setConditionLastTransitionTime(cluster *infrav1.HarvesterCluster, conditionType clusterv1.ConditionType, lastTransitionTime time.Time) {
+	for i := range cluster.Status.Conditions {
+		if cluster.Status.Conditions[i].Type == conditionType {
+			cluster.Status.Conditions[i].LastTransitionTime = metav1.NewTime(lastTransitionTime)
+		}
+	}
+}
+
+var _ = Describe("Use an external control plane endpoint", func() {
+	var scope *ClusterScope
+	var listener net.Listener
+
+	BeforeEach(func() {
+		var err error
+
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		scope = &ClusterScope{
+			Ctx: context.TODO(),
+			HarvesterCluster: &infrav1.HarvesterCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-hv", Namespace: "test-hv"},
+				Spec: infrav1.HarvesterClusterSpec{
+					TargetNamespace: "default",
+					ControlPlaneEndpoint: clusterv1.APIEndpoint{
+						Host: "127.0.0.1",
+						Port: int32(listener.Addr().(*net.TCPAddr).Port),
+					},
+					LoadBalancerConfig: infrav1.LoadBalancerConfig{
+						Type: infrav1.LoadBalancerTypeExternal,
+					},
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		_ = listener.Close()
+	})
+
+	It("Should mark a reachable endpoint ready", func() {
+		_, err := (&HarvesterClusterReconciler{}).reconcileExternalEndpoint(scope)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scope.HarvesterCluster.Status.Ready).To(BeTrue())
+		Expect(conditions.IsTrue(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition)).To(BeTrue())
+	})
+
+	It("Should mark an unreachable endpoint ready and report it", func() {
+		Expect(listener.Close()).To(Succeed())
+
+		_, err := (&HarvesterClusterReconciler{}).reconcileExternalEndpoint(scope)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scope.HarvesterCluster.Status.Ready).To(BeTrue())
+		Expect(conditions.IsFalse(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition)).To(BeTrue())
+		Expect(scope.HarvesterCluster.Spec.ControlPlaneEndpoint.Host).To(Equal("127.0.0.1"))
+	})
+
+	It("Should check an unreachable endpoint less often as it stays unreachable", func() {
+		Expect(listener.Close()).To(Succeed())
+
+		res, err := (&HarvesterClusterReconciler{}).reconcileExternalEndpoint(scope)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(requeueTimeShort))
+
+		setConditionLastTransitionTime(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition, time.Now().Add(-2*time.Minute))
+
+		res, err = (&HarvesterClusterReconciler{}).reconcileExternalEndpoint(scope)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeNumerically(">=", 2*time.Minute))
+		Expect(res.RequeueAfter).To(BeNumerically("<", requeueTimeMedium))
+
+		setConditionLastTransitionTime(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition, time.Now().Add(-time.Hour))
+
+		res, err = (&HarvesterClusterReconciler{}).reconcileExternalEndpoint(scope)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(requeueTimeMedium))
+	})
+
+	It("Should not mark the cluster ready when the cloud provider config cannot be generated", func() {
+		scope.HarvesterCluster.Spec.UpdateCloudProviderConfig = infrav1.UpdateCloudProviderConfig{
+			ManifestsConfigMapKey: "manifests",
+		}
+
+		_, err := (&HarvesterClusterReconciler{}).reconcileExternalEndpoint(scope)
+		Expect(err).To(HaveOccurred())
+		Expect(scope.HarvesterCluster.Status.Ready).To(BeFalse())
+		Expect(conditions.IsFalse(scope.HarvesterCluster, infrav1.CloudProviderConfigReadyCondition)).To(BeTrue())
+		Expect(conditions.GetReason(scope.HarvesterCluster, infrav1.CloudProviderConfigReadyCondition)).
+			To(Equal(infrav1.CloudProviderConfigGenerationFailedReason))
+	})
+
+	It("Should report a missing endpoint", func() {
+		scope.HarvesterCluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{}
+
+		_, err := (&HarvesterClusterReconciler{}).reconcileExternalEndpoint(scope)
+		Expect(err).To(HaveOccurred())
+		Expect(scope.HarvesterCluster.Status.Ready).To(BeFalse())
+		Expect(conditions.IsFalse(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition)).To(BeTrue())
+		Expect(conditions.GetReason(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition)).
+			To(Equal(infrav1.InvalidControlPlaneEndpointReason))
+	})
+})
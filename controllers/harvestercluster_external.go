@@ -0,0 +1,109 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
+)
+
+// endpointDialTimeout is the time after which a connection to the external control plane endpoint is considered failed.
+// It is kept short, because the connection is opened in a worker of the controller.
+const endpointDialTimeout = 2 * time.Second
+
+// reconcileExternalEndpoint uses the control plane endpoint set in the HarvesterCluster, without creating anything in Harvester for it.
+// The cluster is ready as soon as the endpoint is set, because an external load balancer usually refuses connections until
+// the first control plane machine is up. Its reachability is only reported in the ControlPlaneEndpointReachableCondition.
+func (r *HarvesterClusterReconciler) reconcileExternalEndpoint(scope *ClusterScope) (ctrl.Result, error) {
+	logger := log.FromContext(scope.Ctx)
+
+	endpoint := scope.HarvesterCluster.Spec.ControlPlaneEndpoint
+	if !endpoint.IsValid() {
+		err := errors.New("the control plane endpoint must be set when the load balancer type is External")
+		conditions.MarkFalse(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition,
+			infrav1.InvalidControlPlaneEndpointReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+		scope.HarvesterCluster.Status.Ready = false
+
+		return ctrl.Result{}, err
+	}
+
+	if err := checkEndpointReachable(scope.Ctx, endpoint); err != nil {
+		logger.Info("the control plane endpoint is not reachable yet", "endpoint", endpoint.String(), "error", err.Error())
+		conditions.MarkFalse(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition,
+			infrav1.ControlPlaneEndpointUnreachableReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+	} else {
+		conditions.MarkTrue(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition)
+	}
+
+	// Reconcile Cloud Provider Config
+	if err := r.reconcileCloudProviderConfig(scope); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "could not reconcile the cloud provider config")
+	}
+
+	scope.HarvesterCluster.Status.Ready = true
+
+	// Requeue to keep the reachability of the endpoint up to date
+	if !conditions.IsTrue(scope.HarvesterCluster, infrav1.ControlPlaneEndpointReachableCondition) {
+		return ctrl.Result{RequeueAfter: getUnreachableEndpointRequeueTime(scope.HarvesterCluster)}, nil
+	}
+
+	return ctrl.Result{RequeueAfter: requeueTimeMedium}, nil
+}
+
+// getUnreachableEndpointRequeueTime returns the time after which an unreachable endpoint is checked again. It backs off
+// from requeueTimeShort to requeueTimeMedium as the endpoint stays unreachable, so that a load balancer which is down for
+// long does not keep the workers of the controller busy.
+func getUnreachableEndpointRequeueTime(cluster *infrav1.HarvesterCluster) time.Duration {
+	condition := conditions.Get(cluster, infrav1.ControlPlaneEndpointReachableCondition)
+	if condition == nil {
+		return requeueTimeShort
+	}
+
+	requeueTime := time.Since(condition.LastTransitionTime.Time)
+
+	switch {
+	case requeueTime < requeueTimeShort:
+		return requeueTimeShort
+	case requeueTime > requeueTimeMedium:
+		return requeueTimeMedium
+	default:
+		return requeueTime
+	}
+}
+
+// checkEndpointReachable checks that a TCP connection can be opened to an API endpoint.
+func checkEndpointReachable(ctx context.Context, endpoint clusterv1.APIEndpoint) error {
+	dialer := &net.Dialer{Timeout: endpointDialTimeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(endpoint.Host, strconv.Itoa(int(endpoint.Port))))
+	if err != nil {
+		return errors.Wrapf(err, "unable to connect to %s", endpoint.String())
+	}
+
+	return conn.Close()
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+
+	lbv1beta1 "github.com/harvester/harvester-load-balancer/pkg/apis/loadbalancer.harvesterhci.io/v1beta1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
+	"sigs.k8s.io/cluster-api/util"
+
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
+	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
+)
+
+// errIPAddressClaimPending is returned while the IPAddressClaim of the load balancer is not fulfilled by the IPAM provider.
+var errIPAddressClaimPending = errors.New("the IPAddressClaim of the load balancer is not fulfilled yet")
+
+// getLoadBalancerClaimName returns the name of the IPAddressClaim of the load balancer of a HarvesterCluster.
+func getLoadBalancerClaimName(cluster *infrav1.HarvesterCluster) string {
+	return cluster.Name + "-lb"
+}
+
+// claimLoadBalancerAddress creates an IPAddressClaim for the load balancer against the pool of a Cluster API IPAM provider,
+// and returns the IPAddress fulfilling it. It returns errIPAddressClaimPending until the claim is fulfilled.
+func claimLoadBalancerAddress(scope *ClusterScope) (*ipamv1.IPAddress, error) {
+	poolRef := scope.HarvesterCluster.Spec.LoadBalancerConfig.IPAM.PoolRef
+	if poolRef == nil {
+		return nil, fmt.Errorf("no IPAM pool is defined, while the IPAM type is set to %s", infrav1.IPAMTypeIPAddressClaim)
+	}
+
+	claim := &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getLoadBalancerClaimName(scope.HarvesterCluster),
+			Namespace: scope.HarvesterCluster.Namespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrPatch(scope.Ctx, scope.ReconcileClient, claim, func() error {
+		if claim.Labels == nil {
+			claim.Labels = make(map[string]string)
+		}
+
+		claim.Labels[clusterv1.ClusterNameLabel] = scope.Cluster.Name
+		claim.OwnerReferences = util.EnsureOwnerRef(claim.OwnerReferences, metav1.OwnerReference{
+			APIVersion: infrav1.GroupVersion.String(),
+			Kind:       "HarvesterCluster",
+			Name:       scope.HarvesterCluster.Name,
+			UID:        scope.HarvesterCluster.UID,
+			Controller: locutil.NewTrue(),
+		})
+		claim.Spec.PoolRef = *poolRef
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create IPAddressClaim %s", claim.Name)
+	}
+
+	if claim.Status.AddressRef.Name == "" {
+		return nil, errIPAddressClaimPending
+	}
+
+	ipAddress := &ipamv1.IPAddress{}
+
+	err = scope.ReconcileClient.Get(scope.Ctx,
+		types.NamespacedName{Namespace: claim.Namespace, Name: claim.Status.AddressRef.Name}, ipAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get IPAddress %s", claim.Status.AddressRef.Name)
+	}
+
+	return ipAddress, nil
+}
+
+// getIPPoolSpecFromIPAddress returns the spec of an IP Pool in Harvester holding only the given address,
+// which is used to give the load balancer the address allocated by the IPAM provider.
+func getIPPoolSpecFromIPAddress(ipAddress *ipamv1.IPAddress) (lbv1beta1.IPPoolSpec, error) {
+	_, subnet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", ipAddress.Spec.Address, ipAddress.Spec.Prefix))
+	if err != nil {
+		return lbv1beta1.IPPoolSpec{}, errors.Wrapf(err, "invalid address in IPAddress %s", ipAddress.Name)
+	}
+
+	return lbv1beta1.IPPoolSpec{
+		Ranges: []lbv1beta1.Range{
+			{
+				Subnet:     subnet.String(),
+				Gateway:    ipAddress.Spec.Gateway,
+				RangeStart: ipAddress.Spec.Address,
+				RangeEnd:   ipAddress.Spec.Address,
+			},
+		},
+	}, nil
+}
+
+// releaseLoadBalancerAddress deletes the IPAddressClaim of the load balancer, so that the IPAM provider can release its address.
+func releaseLoadBalancerAddress(scope *ClusterScope) error {
+	claim := &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getLoadBalancerClaimName(scope.HarvesterCluster),
+			Namespace: scope.HarvesterCluster.Namespace,
+		},
+	}
+
+	if err := scope.ReconcileClient.Delete(scope.Ctx, claim); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to delete IPAddressClaim %s", claim.Name)
+	}
+
+	return nil
+}
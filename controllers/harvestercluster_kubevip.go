@@ -0,0 +1,92 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
+	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
+)
+
+// getKubeVIPOwner returns the applicant recorded in the IP Pool for the virtual IP of a HarvesterCluster using kube-vip.
+func getKubeVIPOwner(cluster *infrav1.HarvesterCluster) string {
+	return cluster.Spec.TargetNamespace + "/" + locutil.GenerateRFC1035Name([]string{cluster.Namespace, cluster.Name, "vip"})
+}
+
+// reconcileKubeVIP reserves the virtual IP announced by kube-vip on the control plane machines and sets it as the control plane endpoint.
+// Nothing is created in Harvester for the endpoint, the kube-vip static pod is added to the cloud-init of the control plane machines.
+func (r *HarvesterClusterReconciler) reconcileKubeVIP(scope *ClusterScope) (ctrl.Result, error) {
+	logger := log.FromContext(scope.Ctx)
+
+	if !conditions.IsTrue(scope.HarvesterCluster, infrav1.KubeVIPAddressReservedCondition) {
+		vip, err := reserveKubeVIPAddress(scope)
+		if errors.Is(err, errIPAddressClaimPending) {
+			logger.Info("waiting for the IPAddressClaim of the virtual IP to be fulfilled ...")
+
+			return ctrl.Result{RequeueAfter: requeueTimeShort}, nil
+		}
+
+		if err != nil {
+			conditions.MarkFalse(scope.HarvesterCluster, infrav1.KubeVIPAddressReservedCondition,
+				infrav1.KubeVIPAddressReservationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+
+			return ctrl.Result{RequeueAfter: requeueTimeShort}, errors.Wrap(err, "could not reserve the virtual IP for kube-vip")
+		}
+
+		scope.HarvesterCluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{
+			Host: vip,
+			Port: getAPIServerPort(scope.HarvesterCluster),
+		}
+		scope.HarvesterCluster.Status.LoadBalancerAddresses = getLoadBalancerAddresses(scope.HarvesterCluster, []string{vip})
+
+		conditions.MarkTrue(scope.HarvesterCluster, infrav1.KubeVIPAddressReservedCondition)
+		logger.Info("virtual IP reserved for kube-vip", "address", vip)
+	}
+
+	// Reconcile Cloud Provider Config
+	if err := r.reconcileCloudProviderConfig(scope); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "could not reconcile the cloud provider config")
+	}
+
+	scope.HarvesterCluster.Status.Ready = true
+
+	return ctrl.Result{}, nil
+}
+
+// reserveKubeVIPAddress returns the virtual IP of the control plane. With DHCP, kube-vip gets the address itself and
+// the hostname registered with dynamic DNS is returned. Otherwise, the address is allocated from the IP Pool and recorded
+// in its status with the kube-vip owner as applicant, so that it is not given to a load balancer in Harvester.
+// The allocation returns the address already recorded for the owner, if a previous reconciliation could not be persisted.
+func reserveKubeVIPAddress(scope *ClusterScope) (string, error) {
+	lbConfig := scope.HarvesterCluster.Spec.LoadBalancerConfig
+
+	if lbConfig.IPAM.Type == infrav1.IPAMTypeDHCP {
+		if lbConfig.KubeVIP == nil || lbConfig.KubeVIP.Hostname == "" {
+			return "", errors.New("a hostname must be set for kube-vip when the IPAM type is DHCP")
+		}
+
+		return lbConfig.KubeVIP.Hostname, nil
+	}
+
+	return getIPFromIPPool(scope, getKubeVIPOwner(scope.HarvesterCluster))
+}
@@ -21,11 +21,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	harvesterv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -34,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -44,21 +47,37 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
 
-	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha1"
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
 	harvclient "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/clientset/versioned"
+	locmetrics "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/metrics"
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/remote"
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/tracing"
 	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
 )
 
 // HarvesterMachineReconciler reconciles a HarvesterMachine object.
 type HarvesterMachineReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// ProvisioningTimeout is the time after which a HarvesterMachine whose VM did not join the workload cluster is marked as failed.
+	// A zero timeout disables the check.
+	ProvisioningTimeout time.Duration
+
+	// ClientCache shares the clients of the Harvester APIs between the reconciliations. When nil, clients are built each time.
+	ClientCache *locutil.ClientCache
+
+	// Tracker watches the VMs in Harvester to reconcile their HarvesterMachines when they change. When nil, the VMs are polled.
+	Tracker *remote.Tracker
 }
 
 // Scope stores context data for the reconciler.
@@ -70,7 +89,19 @@ type Scope struct {
 	HarvesterMachine *infrav1.HarvesterMachine
 	HarvesterClient  *harvclient.Clientset
 	ReconcilerClient client.Client
+	EventRecorder    record.EventRecorder
 	Logger           *logr.Logger
+	// VMWatched is true when the changes of the VM in Harvester trigger the reconciliation, which then does not need to poll.
+	VMWatched bool
+}
+
+// harvesterMachineReadyConditions are the conditions of a HarvesterMachine set by its controller and summarized in its Ready condition.
+var harvesterMachineReadyConditions = []clusterv1.ConditionType{
+	infrav1.VMProvisionedCondition,
+	infrav1.BootstrapDataAppliedCondition,
+	infrav1.VMRunningCondition,
+	infrav1.AddressesReportedCondition,
+	infrav1.NodeRegisteredCondition,
 }
 
 const (
@@ -87,11 +118,16 @@ const (
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=harvestermachines/finalizers,verbs=update
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=harvesterclusters,verbs=get;list
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=clusters;machines,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *HarvesterMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, rerr error) {
 	logger := log.FromContext(ctx)
 	ctx = ctrl.LoggerInto(ctx, logger)
 
+	ctx, span := tracing.StartSpan(ctx, "HarvesterMachine.Reconcile",
+		attribute.String("namespace", req.Namespace), attribute.String("name", req.Name))
+	defer func() { tracing.EndSpan(span, rerr) }()
+
 	logger.Info("Reconciling HarvesterMachine ...")
 
 	hvMachine := &infrav1.HarvesterMachine{}
@@ -115,9 +151,11 @@ func (r *HarvesterMachineReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	// Always attempt to Patch the HarvesterMachine object and status after each reconciliation.
 	defer func() {
+		conditions.SetSummary(hvMachine, conditions.WithConditions(harvesterMachineReadyConditions...))
+
 		if err := patchHelper.Patch(ctx,
 			hvMachine,
-		// conditions.WithOwnedConditions( []clusterv1.ConditionType{ clusterv1.ReadyCondition}),
+			patch.WithOwnedConditions{Conditions: append([]clusterv1.ConditionType{clusterv1.ReadyCondition}, harvesterMachineReadyConditions...)},
 		); err != nil {
 			logger.Error(err, "failed to patch HarvesterMachine")
 
@@ -177,9 +215,11 @@ func (r *HarvesterMachineReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, err
 	}
 
-	hvClient, err := locutil.GetHarvesterClientFromSecret(hvSecret)
+	hvClients, err := r.ClientCache.Get(hvSecret)
 	if err != nil {
 		logger.Error(err, "unable to create Harvester client from Datasource secret "+hvSecret.Name)
+
+		return ctrl.Result{}, err
 	}
 
 	hvScope := Scope{
@@ -188,16 +228,21 @@ func (r *HarvesterMachineReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		Machine:          ownerMachine,
 		HarvesterCluster: hvCluster,
 		HarvesterMachine: hvMachine,
-		HarvesterClient:  hvClient,
+		HarvesterClient:  hvClients.Harvester,
 		ReconcilerClient: r.Client,
+		EventRecorder:    r.Recorder,
 		Logger:           &logger,
 	}
 
 	if !hvMachine.DeletionTimestamp.IsZero() {
+		r.Tracker.UnwatchMachine(hvClients.Identity, hvCluster.Spec.TargetNamespace, hvMachine.Name)
+
 		return r.ReconcileDelete(hvScope)
-	} else {
-		return r.ReconcileNormal(&hvScope) //nolint:contextcheck
 	}
+
+	hvScope.VMWatched = r.Tracker.WatchMachine(hvClients, hvCluster.Spec.TargetNamespace, hvMachine.Name, req.NamespacedName)
+
+	return r.ReconcileNormal(&hvScope) //nolint:contextcheck
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -207,7 +252,7 @@ func (r *HarvesterMachineReconciler) SetupWithManager(ctx context.Context, mgr c
 		return err
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&infrav1.HarvesterMachine{}).
 		Watches(
 			&clusterv1.Machine{},
@@ -219,12 +264,26 @@ func (r *HarvesterMachineReconciler) SetupWithManager(ctx context.Context, mgr c
 			handler.EnqueueRequestsFromMapFunc(clusterToHarvesterMachine),
 			builder.WithPredicates(predicates.ClusterUnpaused(ctrl.LoggerFrom(ctx))),
 		).
-		Complete(r)
+		Owns(&ipamv1.IPAddressClaim{}).
+		Watches(
+			&ipamv1.IPAddress{},
+			handler.EnqueueRequestsFromMapFunc(ipAddressToClaimOwnerMapFunc(mgr.GetClient(), infrav1.GroupVersion.WithKind("HarvesterMachine"))),
+		)
+
+	// Reconcile the HarvesterMachines when their VM changes in Harvester
+	if r.Tracker != nil {
+		controllerBuilder = controllerBuilder.WatchesRawSource(r.Tracker.MachineSource(), &handler.EnqueueRequestForObject{})
+	}
+
+	return controllerBuilder.Complete(r)
 }
 
 func (r *HarvesterMachineReconciler) ReconcileNormal(hvScope *Scope) (res reconcile.Result, rerr error) {
 	logger := log.FromContext(hvScope.Ctx)
 
+	endPhase := tracing.StartPhase(&hvScope.Ctx, "ReconcileNormal")
+	defer func() { endPhase(rerr) }()
+
 	// Return early if the object or Cluster is paused.
 	if annotations.IsPaused(hvScope.Cluster, hvScope.HarvesterMachine) {
 		logger.Info("Reconciliation is paused for this object")
@@ -243,12 +302,29 @@ func (r *HarvesterMachineReconciler) ReconcileNormal(hvScope *Scope) (res reconc
 		return ctrl.Result{}, nil
 	}
 
+	// A failed HarvesterMachine is not reconciled anymore, it is replaced by the remediation of its Machine.
+	if hvScope.HarvesterMachine.Status.FailureReason != "" {
+		logger.Info("HarvesterMachine has failed, waiting for it to be deleted",
+			"reason", hvScope.HarvesterMachine.Status.FailureReason, "message", hvScope.HarvesterMachine.Status.FailureMessage)
+
+		hvScope.HarvesterMachine.Status.Ready = false
+
+		return ctrl.Result{}, nil
+	}
+
+	migrateMachineCreatedCondition(hvScope.HarvesterMachine)
+
 	// Return early if the ownerCluster has infrastructureReady = false
 	if !hvScope.Cluster.Status.InfrastructureReady {
 		logger.Info("Waiting for Infrastructure to be ready ... ")
 
 		hvScope.HarvesterMachine.Status.Ready = false
 
+		if !conditions.IsTrue(hvScope.HarvesterMachine, infrav1.VMProvisionedCondition) {
+			conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.VMProvisionedCondition,
+				infrav1.WaitingForClusterInfrastructureReason, clusterv1.ConditionSeverityInfo, "")
+		}
+
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 	}
 
@@ -258,14 +334,17 @@ func (r *HarvesterMachineReconciler) ReconcileNormal(hvScope *Scope) (res reconc
 
 		hvScope.HarvesterMachine.Status.Ready = false
 
+		if !conditions.IsTrue(hvScope.HarvesterMachine, infrav1.BootstrapDataAppliedCondition) {
+			conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.BootstrapDataAppliedCondition,
+				infrav1.WaitingForBootstrapDataReason, clusterv1.ConditionSeverityInfo, "")
+		}
+
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 	}
 
-	vmExists := false
-
 	// check if Harvester has a machine with the same name and namespace
 	existingVM, err := hvScope.HarvesterClient.KubevirtV1().VirtualMachines(hvScope.HarvesterCluster.Spec.TargetNamespace).Get(
-		context.TODO(), hvScope.HarvesterMachine.Name, metav1.GetOptions{})
+		hvScope.Ctx, hvScope.HarvesterMachine.Name, metav1.GetOptions{})
 	if err != nil && !apierrors.IsNotFound(err) {
 		logger.Error(err, "unable to check existence of VM from Harvester")
 
@@ -275,97 +354,313 @@ func (r *HarvesterMachineReconciler) ReconcileNormal(hvScope *Scope) (res reconc
 	}
 
 	if (existingVM != nil) && (existingVM.Name == hvScope.HarvesterMachine.Name) {
-		vmExists = true
+		endExistingVMPhase := tracing.StartPhase(&hvScope.Ctx, "ReconcileExistingVM")
+		res, err := r.reconcileExistingVM(hvScope, existingVM)
+		endExistingVMPhase(err)
+
+		if err == nil && !hvScope.HarvesterMachine.Status.Ready && hvScope.HarvesterMachine.Spec.ProviderID == "" &&
+			hvScope.HarvesterMachine.Status.FailureReason == "" {
+			if hasProvisioningTimedOut(existingVM, r.ProvisioningTimeout, time.Now()) {
+				message := getProvisioningTimeoutMessage(hvScope.HarvesterMachine, r.ProvisioningTimeout)
+				logger.Info("HarvesterMachine failed to be provisioned in time", "message", message)
+				setMachineFailure(hvScope.HarvesterMachine, capierrors.CreateMachineError, message)
+				recordMachineFailureEvent(hvScope)
+
+				return ctrl.Result{}, nil
+			}
 
-		if *existingVM.Spec.Running {
-			ipAddresses, err := getIPAddressesFromVMI(existingVM, hvScope.HarvesterClient)
-			if err != nil {
-				hvScope.HarvesterMachine.Status.Ready = false
+			// A VM waiting for events may never change, the timeout has to be checked anyway
+			res = requeueBeforeProvisioningTimeout(res, existingVM, r.ProvisioningTimeout, time.Now())
+		}
 
-				if apierrors.IsNotFound(err) {
-					logger.Info("VM is not running yet, waiting for it to be ready")
+		return res, err
+	}
 
-					return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
-				}
+	hvScope.HarvesterMachine.Status.Ready = false
 
-				logger.V(1).Info("unable to get IP addresses from VMI in Harvester, requeuing ...")
+	// The VM was deleted from Harvester outside of Cluster API, the Machine has to be replaced.
+	if conditions.IsTrue(hvScope.HarvesterMachine, infrav1.VMProvisionedCondition) {
+		conditions.MarkFalse(hvScope.HarvesterMachine,
+			infrav1.VMProvisionedCondition, infrav1.MachineNotFoundReason, clusterv1.ConditionSeverityError, "VM not found in Harvester")
+		setMachineFailure(hvScope.HarvesterMachine, capierrors.UpdateMachineError, "the VM was not found in Harvester")
+		recordMachineFailureEvent(hvScope)
 
-				return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
-			}
+		return ctrl.Result{}, nil
+	}
 
-			hvScope.HarvesterMachine.Status.Addresses = ipAddresses
-			hvScope.HarvesterMachine.Status.Ready = false
+	logger.Info("No existing VM found in Harvester, creating a new one ...")
 
-			if len(ipAddresses) == 0 && hvScope.HarvesterMachine.Status.Ready {
-				hvScope.HarvesterMachine.Status.Ready = false
+	endAddressesPhase := tracing.StartPhase(&hvScope.Ctx, "ReconcileStaticAddresses")
+	allocated, err := reconcileStaticAddresses(hvScope)
+	endAddressesPhase(err)
 
-				return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
-			}
+	if err != nil {
+		logger.Error(err, "unable to allocate static addresses to the VM interfaces")
+		conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.VMProvisionedCondition,
+			infrav1.VMProvisioningFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
 
-			if hvScope.HarvesterMachine.Spec.ProviderID == "" {
-				providerID, _ := getProviderIDFromWorkloadCluster(hvScope, existingVM)
+		return ctrl.Result{}, err
+	}
 
-				if providerID != "" {
-					hvScope.HarvesterMachine.Spec.ProviderID = providerID
-					hvScope.HarvesterMachine.Status.Ready = true
-				} else {
-					logger.Info("Waiting for ProviderID to be set on Node resource in Workload Cluster ...")
+	if !allocated {
+		logger.Info("Waiting for static addresses to be allocated to the VM interfaces ...")
+		conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.VMProvisionedCondition,
+			infrav1.WaitingForStaticAddressesReason, clusterv1.ConditionSeverityInfo, "")
 
-					hvScope.HarvesterMachine.Status.Ready = false
+		return ctrl.Result{RequeueAfter: requeueTimeShort}, nil
+	}
 
-					return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
-				}
-			} else {
-				conditions.MarkTrue(hvScope.HarvesterMachine, infrav1.MachineCreatedCondition)
-				hvScope.HarvesterMachine.Status.Ready = true
-			}
-		} else {
-			hvScope.HarvesterMachine.Status.Ready = false
+	endCreateVMPhase := tracing.StartPhase(&hvScope.Ctx, "CreateVM")
+	_, err = createVMFromHarvesterMachine(hvScope)
+	endCreateVMPhase(err)
+
+	if err != nil {
+		logger.Error(err, "unable to create VM from HarvesterMachine information")
+
+		if failure := getMachineFailure(err); failure != nil {
+			conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.VMProvisionedCondition,
+				infrav1.VMProvisioningFailedReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+			setMachineFailure(hvScope.HarvesterMachine, failure.reason, err.Error())
+			recordMachineFailureEvent(hvScope)
 
-			return ctrl.Result{RequeueAfter: requeueTimeShort}, nil
+			return ctrl.Result{}, nil
 		}
+
+		conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.VMProvisionedCondition,
+			infrav1.VMProvisioningFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+
+		return ctrl.Result{}, err
 	}
 
-	if !conditions.IsTrue(hvScope.HarvesterMachine, infrav1.MachineCreatedCondition) {
-		logger.Info("No existing VM found in Harvester, creating a new one ...")
+	conditions.MarkTrue(hvScope.HarvesterMachine, infrav1.VMProvisionedCondition)
+	conditions.MarkTrue(hvScope.HarvesterMachine, infrav1.BootstrapDataAppliedCondition)
+	conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.VMRunningCondition,
+		infrav1.VMInstanceNotFoundReason, clusterv1.ConditionSeverityInfo, "")
+	conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.AddressesReportedCondition,
+		infrav1.WaitingForGuestAgentReason, clusterv1.ConditionSeverityInfo, "")
+	conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.NodeRegisteredCondition,
+		infrav1.WaitingForNodeReason, clusterv1.ConditionSeverityInfo, "")
+
+	// Patch the HarvesterCluster resource with the InitMachineCreatedCondition if it is not already set.
+	if !conditions.IsTrue(hvScope.HarvesterCluster, infrav1.InitMachineCreatedCondition) {
+		hvClusterCopy := hvScope.HarvesterCluster.DeepCopy()
+		conditions.MarkTrue(hvClusterCopy, infrav1.InitMachineCreatedCondition)
+		hvClusterCopy.Status.Ready = hvScope.HarvesterCluster.Status.Ready
+
+		if err := r.Client.Status().Patch(hvScope.Ctx, hvClusterCopy, client.MergeFrom(hvScope.HarvesterCluster)); err != nil {
+			logger.Error(err, "failed to update HarvesterCluster Conditions with InitMachineCreatedCondition")
+		}
+	}
 
-		hvScope.HarvesterMachine.Status.Ready = false
+	return requeueForVMChange(hvScope, requeueTimeShort), nil
+}
+
+// requeueForVMChange returns the result of a reconciliation waiting for the VM to change in Harvester.
+// The VM is only polled when its changes are not watched.
+func requeueForVMChange(hvScope *Scope, pollInterval time.Duration) ctrl.Result {
+	if hvScope.VMWatched {
+		return ctrl.Result{}
+	}
+
+	return ctrl.Result{RequeueAfter: pollInterval}
+}
+
+// reconcileExistingVM follows the lifecycle of the VM of a HarvesterMachine once it exists in Harvester: the VM has to run,
+// its guest agent has to report its addresses and its Node has to register in the workload cluster for the machine to be ready.
+func (r *HarvesterMachineReconciler) reconcileExistingVM(hvScope *Scope, existingVM *kubevirtv1.VirtualMachine) (ctrl.Result, error) {
+	logger := log.FromContext(hvScope.Ctx)
+
+	hvScope.HarvesterMachine.Status.Ready = false
+
+	conditions.MarkTrue(hvScope.HarvesterMachine, infrav1.VMProvisionedCondition)
+	conditions.MarkTrue(hvScope.HarvesterMachine, infrav1.BootstrapDataAppliedCondition)
+
+	vmInstance, err := hvScope.HarvesterClient.KubevirtV1().VirtualMachineInstances(existingVM.Namespace).Get(
+		hvScope.Ctx, existingVM.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logger.V(1).Info("unable to get the VM instance from Harvester, requeuing ...", "error", err.Error())
+
+			return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		}
 
-		_, err = createVMFromHarvesterMachine(hvScope)
+		vmInstance = nil
+	}
+
+	setVMRunningCondition(hvScope.HarvesterMachine, existingVM, vmInstance)
+
+	if !conditions.IsTrue(hvScope.HarvesterMachine, infrav1.VMRunningCondition) {
+		pvcs, err := getVMVolumeClaims(hvScope, existingVM)
 		if err != nil {
-			logger.Error(err, "unable to create VM from HarvesterMachine information")
+			logger.V(1).Info("unable to get the volumes of the VM from Harvester, requeuing ...", "error", err.Error())
 
-			return ctrl.Result{}, err
+			return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 		}
 
-		conditions.MarkTrue(hvScope.HarvesterMachine, infrav1.MachineCreatedCondition)
-		hvScope.HarvesterMachine.Status.Ready = false
+		failure, untilFailure := getVMFailure(hvScope.HarvesterMachine, existingVM, vmInstance, pvcs, time.Now())
+		if failure != nil {
+			logger.Info("VM failed in Harvester", "state", hvScope.HarvesterMachine.Status.VMState, "message", failure.Error())
+			setMachineFailure(hvScope.HarvesterMachine, failure.reason, failure.Error())
+			recordMachineFailureEvent(hvScope)
 
-		// Patch the HarvesterCluster resource with the InitMachineCreatedCondition if it is not already set.
-		if !conditions.IsTrue(hvScope.HarvesterCluster, infrav1.InitMachineCreatedCondition) {
-			hvClusterCopy := hvScope.HarvesterCluster.DeepCopy()
-			conditions.MarkTrue(hvClusterCopy, infrav1.InitMachineCreatedCondition)
-			hvClusterCopy.Status.Ready = hvScope.HarvesterCluster.Status.Ready
+			return ctrl.Result{}, nil
+		}
 
-			if err := r.Client.Status().Patch(hvScope.Ctx, hvClusterCopy, client.MergeFrom(hvScope.HarvesterCluster)); err != nil {
-				logger.Error(err, "failed to update HarvesterCluster Conditions with InitMachineCreatedCondition")
-			}
+		logger.Info("VM is not running yet, waiting for it to be ready", "state", hvScope.HarvesterMachine.Status.VMState)
+
+		res := requeueForVMChange(hvScope, requeueTimeShort)
+		if untilFailure > 0 && (res.RequeueAfter == 0 || untilFailure < res.RequeueAfter) {
+			// A stuck VM may not change anymore, it has to be checked again once it is considered failed
+			res.RequeueAfter = untilFailure
 		}
-	} else {
-		if !vmExists {
-			hvScope.HarvesterMachine.Status.Ready = false
-			conditions.MarkFalse(hvScope.HarvesterMachine,
-				infrav1.MachineCreatedCondition, infrav1.MachineNotFoundReason, clusterv1.ConditionSeverityError, "VM not found in Harvester")
+
+		return res, nil
+	}
+
+	primaryInterface := getInterfaceName(getPrimaryNetworkIndex(hvScope.HarvesterMachine.Spec.Networks))
+	hvScope.HarvesterMachine.Status.Addresses = getMachineAddressesFromInterfaces(vmInstance.Status.Interfaces, primaryInterface)
+
+	if len(hvScope.HarvesterMachine.Status.Addresses) == 0 {
+		logger.Info("Waiting for the guest agent of the VM to report its addresses ...")
+		conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.AddressesReportedCondition,
+			infrav1.WaitingForGuestAgentReason, clusterv1.ConditionSeverityInfo, "")
+
+		return requeueForVMChange(hvScope, 1*time.Minute), nil
+	}
+
+	conditions.MarkTrue(hvScope.HarvesterMachine, infrav1.AddressesReportedCondition)
+
+	if hvScope.HarvesterMachine.Spec.ProviderID == "" {
+		providerID, err := getProviderIDFromWorkloadCluster(hvScope, existingVM)
+		if providerID == "" {
+			logger.Info("Waiting for ProviderID to be set on Node resource in Workload Cluster ...")
+
+			message := ""
+			if err != nil {
+				message = err.Error()
+			}
+
+			conditions.MarkFalse(hvScope.HarvesterMachine, infrav1.NodeRegisteredCondition,
+				infrav1.WaitingForNodeReason, clusterv1.ConditionSeverityInfo, "%s", message)
 
 			return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 		}
+
+		hvScope.HarvesterMachine.Spec.ProviderID = providerID
+		locmetrics.MachineProvisioningDuration.Observe(time.Since(existingVM.CreationTimestamp.Time).Seconds())
 	}
 
+	conditions.MarkTrue(hvScope.HarvesterMachine, infrav1.NodeRegisteredCondition)
 	hvScope.HarvesterMachine.Status.Ready = true
 
 	return ctrl.Result{}, nil
 }
 
+// migrateMachineCreatedCondition replaces the MachineCreatedCondition set by previous versions of the controller
+// with the VMProvisionedCondition, so that a VM already created is not created again.
+func migrateMachineCreatedCondition(harvesterMachine *infrav1.HarvesterMachine) {
+	machineCreated := conditions.Get(harvesterMachine, infrav1.MachineCreatedCondition) //nolint:staticcheck
+	if machineCreated == nil {
+		return
+	}
+
+	if !conditions.Has(harvesterMachine, infrav1.VMProvisionedCondition) {
+		vmProvisioned := machineCreated.DeepCopy()
+		vmProvisioned.Type = infrav1.VMProvisionedCondition
+		conditions.Set(harvesterMachine, vmProvisioned)
+	}
+
+	conditions.Delete(harvesterMachine, infrav1.MachineCreatedCondition) //nolint:staticcheck
+}
+
+// setVMRunningCondition sets the VM state and the VMRunningCondition of a HarvesterMachine from the printable status of its VM
+// and the phase of its VM instance, which is nil when the instance does not exist.
+// The condition has the Error severity when the VM is in an error state, such as ErrorUnschedulable or CrashLoopBackOff.
+func setVMRunningCondition(
+	harvesterMachine *infrav1.HarvesterMachine, vm *kubevirtv1.VirtualMachine, vmInstance *kubevirtv1.VirtualMachineInstance,
+) {
+	phase := ""
+	if vmInstance != nil {
+		phase = string(vmInstance.Status.Phase)
+	}
+
+	state := string(vm.Status.PrintableStatus)
+	if state == "" {
+		state = phase
+	}
+
+	harvesterMachine.Status.VMState = state
+
+	if phase == string(kubevirtv1.Running) && vm.Status.PrintableStatus != kubevirtv1.VirtualMachineStatusPaused {
+		conditions.MarkTrue(harvesterMachine, infrav1.VMRunningCondition)
+
+		return
+	}
+
+	reason := state
+	if reason == "" {
+		reason = infrav1.VMInstanceNotFoundReason
+	}
+
+	severity := clusterv1.ConditionSeverityInfo
+	if isVMErrorState(vm.Status.PrintableStatus) || phase == string(kubevirtv1.Failed) {
+		severity = clusterv1.ConditionSeverityError
+	}
+
+	message := ""
+
+	for _, condition := range vm.Status.Conditions {
+		if condition.Type == kubevirtv1.VirtualMachineFailure && condition.Status == v1.ConditionTrue {
+			message = condition.Message
+		}
+	}
+
+	conditions.MarkFalse(harvesterMachine, infrav1.VMRunningCondition, reason, severity, "%s", message)
+}
+
+// getVMVolumeClaims returns the PVCs of the volumes of a VM in Harvester. The PVCs which do not exist are skipped,
+// KubeVirt reports them in the ErrorPvcNotFound printable status of the VM.
+func getVMVolumeClaims(hvScope *Scope, vm *kubevirtv1.VirtualMachine) ([]*v1.PersistentVolumeClaim, error) {
+	if vm.Spec.Template == nil {
+		return nil, nil
+	}
+
+	pvcs := make([]*v1.PersistentVolumeClaim, 0, len(vm.Spec.Template.Spec.Volumes))
+
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		pvc, err := hvScope.HarvesterClient.CoreV1().PersistentVolumeClaims(vm.Namespace).Get(
+			hvScope.Ctx, volume.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, errors.Wrapf(err, "unable to get PVC %s/%s", vm.Namespace, volume.PersistentVolumeClaim.ClaimName)
+		}
+
+		pvcs = append(pvcs, pvc)
+	}
+
+	return pvcs, nil
+}
+
+// isVMErrorState returns true for the printable statuses of a VM which need an action to be resolved.
+// KubeVirt prefixes most of them with Err, like ErrorUnschedulable, ErrImagePull or ErrorPvcNotFound.
+func isVMErrorState(status kubevirtv1.VirtualMachinePrintableStatus) bool {
+	switch status {
+	case kubevirtv1.VirtualMachineStatusCrashLoopBackOff,
+		kubevirtv1.VirtualMachineStatusImagePullBackOff,
+		kubevirtv1.VirtualMachineStatusDataVolumeError:
+		return true
+	}
+
+	return strings.HasPrefix(string(status), "Err")
+}
+
 func getProviderIDFromWorkloadCluster(hvScope *Scope, existingVM *kubevirtv1.VirtualMachine) (string, error) {
 	var workloadConfig *rest.Config
 
@@ -423,25 +718,31 @@ func getWorkloadClusterConfig(hvScope *Scope) (*rest.Config, error) {
 	return workloadConfig, nil
 }
 
-func getIPAddressesFromVMI(existingVM *kubevirtv1.VirtualMachine, hvClient *harvclient.Clientset) ([]clusterv1.MachineAddress, error) {
+// getMachineAddressesFromInterfaces returns the addresses of the VMI interfaces, starting with the ones of the primary interface.
+func getMachineAddressesFromInterfaces(
+	interfaces []kubevirtv1.VirtualMachineInstanceNetworkInterface, primaryInterface string,
+) []clusterv1.MachineAddress {
 	ipAddresses := []clusterv1.MachineAddress{}
+	otherAddresses := []clusterv1.MachineAddress{}
 
-	vmInstance, err := hvClient.KubevirtV1().VirtualMachineInstances(existingVM.Namespace).Get(context.TODO(), existingVM.Name, metav1.GetOptions{})
-	if err != nil {
-		// if apierrors.IsNotFound(err) {
-		// 	return ipAddresses, fmt.Errorf("no VM instance found for VM %s", existingVM.Name)
-		// }
-		return ipAddresses, err
-	}
+	for _, nic := range interfaces {
+		if nic.IP == "" {
+			continue
+		}
 
-	for _, nic := range vmInstance.Status.Interfaces {
-		ipAddresses = append(ipAddresses, clusterv1.MachineAddress{
+		address := clusterv1.MachineAddress{
 			Type:    clusterv1.MachineExternalIP,
 			Address: nic.IP,
-		})
+		}
+
+		if nic.Name == primaryInterface {
+			ipAddresses = append(ipAddresses, address)
+		} else {
+			otherAddresses = append(otherAddresses, address)
+		}
 	}
 
-	return ipAddresses, nil
+	return append(ipAddresses, otherAddresses...)
 }
 
 func createVMFromHarvesterMachine(hvScope *Scope) (*kubevirtv1.VirtualMachine, error) {
@@ -461,26 +762,18 @@ func createVMFromHarvesterMachine(hvScope *Scope) (*kubevirtv1.VirtualMachine, e
 
 	vmiLabels["harvesterhci.io/vmName"] = vmName
 	vmiLabels["harvesterhci.io/vmNamePrefix"] = vmName
-	diskRandomID := locutil.RandomID()
-	pvcName := vmName + "-disk-0-" + diskRandomID
-
-	hasVMIMageName := func(volume infrav1.Volume) bool { return volume.ImageName != "" }
-
-	// Supposing that the imageName field in HarvesterMachine.Spec.Volumes has the format "<NAMESPACE>/<NAME>",
-	// we use the following to get vmImageNS and vmImageName
-	imageVolumes := locutil.Filter[infrav1.Volume](hvScope.HarvesterMachine.Spec.Volumes, hasVMIMageName)
 
-	vmImage, err := getImageFromHarvesterMachine(imageVolumes, hvScope)
+	pvcs, disks, volumes, err := buildVMDisks(hvScope, vmName)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to find VM image reference in HarvesterMachine")
+		return nil, errors.Wrap(err, "unable to build VM disks from HarvesterMachine volumes")
 	}
 
-	pvcAnnotation, err := buildPVCAnnotationFromImageID(&imageVolumes[0], pvcName, hvScope.HarvesterCluster.Spec.TargetNamespace, vmImage)
+	pvcAnnotation, err := json.Marshal(pvcs)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to generate PVC annotation on VM")
 	}
 
-	vmTemplate, err := buildVMTemplate(hvScope, pvcName, vmiLabels)
+	vmTemplate, err := buildVMTemplate(hvScope, pvcs, disks, volumes, vmiLabels)
 	if err != nil {
 		return &kubevirtv1.VirtualMachine{}, errors.Wrap(err, "unable to build VM definition")
 	}
@@ -494,7 +787,7 @@ func createVMFromHarvesterMachine(hvScope *Scope) (*kubevirtv1.VirtualMachine, e
 			Name:      vmName,
 			Namespace: hvScope.HarvesterCluster.Spec.TargetNamespace,
 			Annotations: map[string]string{
-				vmAnnotationPVC:        pvcAnnotation,
+				vmAnnotationPVC:        string(pvcAnnotation),
 				vmAnnotationNetworkIps: "[]",
 			},
 			Labels: vmLabels,
@@ -507,9 +800,12 @@ func createVMFromHarvesterMachine(hvScope *Scope) (*kubevirtv1.VirtualMachine, e
 	}
 
 	hvCreatedMachine, err := hvScope.HarvesterClient.KubevirtV1().VirtualMachines(hvScope.HarvesterCluster.Spec.TargetNamespace).Create(
-		context.TODO(),
+		hvScope.Ctx,
 		ubuntuVM,
 		metav1.CreateOptions{})
+	recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionCreate,
+		"VM "+ubuntuVM.Namespace+"/"+ubuntuVM.Name, err)
+
 	if err != nil {
 		return hvCreatedMachine, err
 	}
@@ -517,21 +813,99 @@ func createVMFromHarvesterMachine(hvScope *Scope) (*kubevirtv1.VirtualMachine, e
 	return hvCreatedMachine, nil
 }
 
-func buildPVCAnnotationFromImageID(
-	imageVolume *infrav1.Volume,
+// buildVMDisks creates a PVC template and a matching pair of KubeVirt Disk and Volume for each volume of the HarvesterMachine.
+func buildVMDisks(hvScope *Scope, vmName string) (
+	pvcs []*v1.PersistentVolumeClaim, disks []kubevirtv1.Disk, volumes []kubevirtv1.Volume, err error,
+) {
+	namespace := hvScope.HarvesterCluster.Spec.TargetNamespace
+	bootOrders := getBootOrders(hvScope.HarvesterMachine.Spec.Volumes)
+
+	for i := range hvScope.HarvesterMachine.Spec.Volumes {
+		volume := &hvScope.HarvesterMachine.Spec.Volumes[i]
+		diskName := "disk-" + strconv.Itoa(i)
+		pvcName := vmName + "-" + diskName + "-" + locutil.RandomID()
+
+		var vmImage *harvesterv1beta1.VirtualMachineImage
+
+		if volume.VolumeType == infrav1.VolumeTypeImage {
+			vmImage, err = getImageFromVolume(volume, hvScope)
+			if err != nil {
+				return nil, nil, nil, errors.Wrapf(err, "unable to find VM image referenced by volume %d", i)
+			}
+		}
+
+		pvcs = append(pvcs, buildPVCFromVolume(volume, pvcName, namespace, vmImage))
+
+		disks = append(disks, kubevirtv1.Disk{
+			Name:      diskName,
+			BootOrder: &bootOrders[i],
+			DiskDevice: kubevirtv1.DiskDevice{
+				Disk: &kubevirtv1.DiskTarget{
+					Bus: "virtio",
+				},
+			},
+		})
+
+		volumes = append(volumes, kubevirtv1.Volume{
+			Name: diskName,
+			VolumeSource: kubevirtv1.VolumeSource{
+				PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+					PersistentVolumeClaimVolumeSource: v1.PersistentVolumeClaimVolumeSource{
+						ClaimName: pvcName,
+					},
+				},
+			},
+		})
+	}
+
+	return pvcs, disks, volumes, nil
+}
+
+// getBootOrders returns the boot order of each volume. Volumes with an explicit BootOrder keep it,
+// the others are booted after them in the sequence with which they appear in the list.
+func getBootOrders(volumes []infrav1.Volume) []uint {
+	bootOrders := make([]uint, len(volumes))
+	next := uint(0)
+
+	for _, volume := range volumes {
+		if volume.BootOrder > 0 && uint(volume.BootOrder) > next {
+			next = uint(volume.BootOrder)
+		}
+	}
+
+	for i, volume := range volumes {
+		if volume.BootOrder > 0 {
+			bootOrders[i] = uint(volume.BootOrder)
+
+			continue
+		}
+
+		next++
+		bootOrders[i] = next
+	}
+
+	return bootOrders
+}
+
+// buildPVCFromVolume creates the PVC template for a volume. Volumes created from an image use the storage class
+// Harvester creates for the image, the others are blank volumes using the storage class of the volume.
+func buildPVCFromVolume(
+	volume *infrav1.Volume,
 	pvcName string,
 	pvcNamespace string,
 	vmImage *harvesterv1beta1.VirtualMachineImage,
-) (string, error) {
+) *v1.PersistentVolumeClaim {
 	block := v1.PersistentVolumeBlock
-	scName := "longhorn-" + vmImage.Name
+
+	volumeSize := resource.MustParse(infrav1.DefaultVolumeSize)
+	if volume.VolumeSize != nil {
+		volumeSize = *volume.VolumeSize
+	}
+
 	pvc := &v1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pvcName,
 			Namespace: pvcNamespace,
-			Annotations: map[string]string{
-				hvAnnotationImageID: vmImage.Namespace + "/" + vmImage.Name,
-			},
 		},
 		Spec: v1.PersistentVolumeClaimSpec{
 			AccessModes: []v1.PersistentVolumeAccessMode{
@@ -539,39 +913,42 @@ func buildPVCAnnotationFromImageID(
 			},
 			Resources: v1.ResourceRequirements{
 				Requests: v1.ResourceList{
-					"storage": *imageVolume.VolumeSize,
+					"storage": volumeSize,
 				},
 			},
-			VolumeMode:       &block,
-			StorageClassName: &scName,
+			VolumeMode: &block,
 		},
 	}
 
-	pvcJsonString, err := json.Marshal([]*v1.PersistentVolumeClaim{pvc})
-	if err != nil {
-		return "", err
+	if vmImage != nil {
+		scName := "longhorn-" + vmImage.Name
+		pvc.Annotations = map[string]string{
+			hvAnnotationImageID: vmImage.Namespace + "/" + vmImage.Name,
+		}
+		pvc.Spec.StorageClassName = &scName
+	} else if volume.StorageClass != "" {
+		scName := volume.StorageClass
+		pvc.Spec.StorageClassName = &scName
 	}
 
-	return string(pvcJsonString), nil
+	return pvc
 }
 
-func getImageFromHarvesterMachine(imageVolumes []infrav1.Volume, hvScope *Scope) (image *harvesterv1beta1.VirtualMachineImage, err error) {
-	vmImageNamespacedString := imageVolumes[0].ImageName
-
-	err, vmImageNamespacedName := locutil.GetNamespacedName(vmImageNamespacedString, hvScope.HarvesterCluster.Spec.TargetNamespace)
-	if err != nil {
-		return &harvesterv1beta1.VirtualMachineImage{}, fmt.Errorf("ImageName is HarvesterMachine is Malformed, expecting <NAMESPACE>/<NAME> format")
+func getImageFromVolume(volume *infrav1.Volume, hvScope *Scope) (image *harvesterv1beta1.VirtualMachineImage, err error) {
+	if volume.Image == nil {
+		return &harvesterv1beta1.VirtualMachineImage{}, fmt.Errorf("no image referenced by volume of type image")
 	}
 
+	vmImageNamespacedName := volume.Image.NamespacedName(hvScope.HarvesterCluster.Spec.TargetNamespace)
 	foundImages, err := hvScope.HarvesterClient.HarvesterhciV1beta1().VirtualMachineImages(vmImageNamespacedName.Namespace).List(
-		context.TODO(), metav1.ListOptions{})
+		hvScope.Ctx, metav1.ListOptions{})
 	if err != nil {
 		return &harvesterv1beta1.VirtualMachineImage{}, err
 	}
 
 	if len(foundImages.Items) == 0 {
-		return &harvesterv1beta1.VirtualMachineImage{}, fmt.Errorf(
-			"impossible to find any VM image referenced namespace %s", vmImageNamespacedName.Namespace)
+		return &harvesterv1beta1.VirtualMachineImage{}, newMachineFailure(capierrors.InvalidConfigurationMachineError, fmt.Errorf(
+			"impossible to find any VM image referenced namespace %s", vmImageNamespacedName.Namespace))
 	}
 
 	for _, image := range foundImages.Items {
@@ -580,25 +957,21 @@ func getImageFromHarvesterMachine(imageVolumes []infrav1.Volume, hvScope *Scope)
 		}
 	}
 
-	return &harvesterv1beta1.VirtualMachineImage{}, fmt.Errorf(
-		"impossible to find VM image %s in namespace %s", vmImageNamespacedName.Name, vmImageNamespacedName.Namespace)
+	return &harvesterv1beta1.VirtualMachineImage{}, newMachineFailure(capierrors.InvalidConfigurationMachineError, fmt.Errorf(
+		"impossible to find VM image %s in namespace %s", vmImageNamespacedName.Name, vmImageNamespacedName.Namespace))
 }
 
 // buildVMTemplate creates a *kubevirtv1.VirtualMachineInstanceTemplateSpec from the CLI Flags and some computed values.
 func buildVMTemplate(hvScope *Scope,
-	pvcName string, vmiLabels map[string]string,
+	pvcs []*v1.PersistentVolumeClaim, disks []kubevirtv1.Disk, volumes []kubevirtv1.Volume, vmiLabels map[string]string,
 ) (vmTemplate *kubevirtv1.VirtualMachineInstanceTemplateSpec, err error) {
 	var sshKey *harvesterv1beta1.KeyPair
 
-	keyName := hvScope.HarvesterMachine.Spec.SSHKeyPair
-
-	err, keyPairFullName := locutil.GetNamespacedName(keyName, hvScope.HarvesterCluster.Spec.TargetNamespace)
-	if err != nil {
-		return nil, fmt.Errorf("SSHKeyPair is HarvesterMachine is Malformed, expecting <NAMESPACE>/<NAME> format or simple DNS name without slash")
-	}
+	keyName := hvScope.HarvesterMachine.Spec.SSHKeyPair.String()
+	keyPairFullName := hvScope.HarvesterMachine.Spec.SSHKeyPair.NamespacedName(hvScope.HarvesterCluster.Spec.TargetNamespace)
 
 	sshKey, err = hvScope.HarvesterClient.HarvesterhciV1beta1().KeyPairs(keyPairFullName.Namespace).Get(
-		context.TODO(), keyPairFullName.Name, metav1.GetOptions{})
+		hvScope.Ctx, keyPairFullName.Name, metav1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			err = fmt.Errorf(
@@ -606,7 +979,7 @@ func buildVMTemplate(hvScope *Scope,
 try to specify the namespace using the format <NAMESPACE>/<NAME>`,
 				keyPairFullName.Namespace, keyName)
 
-			return nil, err
+			return nil, newMachineFailure(capierrors.InvalidConfigurationMachineError, err)
 		}
 
 		err = fmt.Errorf("error during getting keypair from Harvester: %w", err)
@@ -634,13 +1007,25 @@ runcmd:
 		return nil, err
 	}
 
-	finalCloudInit, err := locutil.MergeCloudInitData(cloudInitBase, cloudInitSSHSection, cloudInitUserData)
+	cloudInitKubeVIP, err := getKubeVIPCloudInit(hvScope)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate the kube-vip cloud-init")
+	}
+
+	finalCloudInit, err := locutil.MergeCloudInitData(cloudInitBase, cloudInitSSHSection, cloudInitUserData, cloudInitKubeVIP)
 	if err != nil {
 		err = fmt.Errorf("error during merging cloud init user data from Harvester: %w", err)
 
 		return nil, err
 	}
 
+	interfaces := getKubevirtInterfacesFromHarvesterMachine(hvScope.HarvesterMachine)
+
+	networkData, err := getNetworkData(hvScope.HarvesterMachine, interfaces)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate cloud-init network data")
+	}
+
 	// create cloud-init secret for reference in Harvester.
 	cloudInitSecret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -653,33 +1038,58 @@ runcmd:
 		},
 	}
 
+	var networkDataSecretRef *v1.LocalObjectReference
+
+	if networkData != nil {
+		cloudInitSecret.Data[cloudInitNetworkDataKey] = networkData
+		networkDataSecretRef = &v1.LocalObjectReference{
+			Name: cloudInitSecret.Name,
+		}
+	}
+
 	hvScope.Logger.V(5).Info("cloud-init final value is " + string(finalCloudInit)) //nolint:mnd
 
 	// check if secret already exists
 	_, err = hvScope.HarvesterClient.CoreV1().Secrets(hvScope.HarvesterCluster.Spec.TargetNamespace).Get(
-		context.TODO(), hvScope.HarvesterMachine.Name+"-cloud-init", metav1.GetOptions{})
+		hvScope.Ctx, hvScope.HarvesterMachine.Name+"-cloud-init", metav1.GetOptions{})
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
 			hvScope.Logger.V(3).Info("unable to get cloud-init secret, error was different than NotFound")
 		} else {
 			_, err = hvScope.HarvesterClient.CoreV1().Secrets(hvScope.HarvesterCluster.Spec.TargetNamespace).Create(
-				context.TODO(), cloudInitSecret, metav1.CreateOptions{})
+				hvScope.Ctx, cloudInitSecret, metav1.CreateOptions{})
+			recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionCreate,
+				"cloud-init secret "+cloudInitSecret.Namespace+"/"+cloudInitSecret.Name, err)
+
 			if err != nil {
 				return nil, errors.Wrap(err, "unable to create cloud-init secret")
 			}
 		}
 	} else {
 		_, err = hvScope.HarvesterClient.CoreV1().Secrets(hvScope.HarvesterCluster.Spec.TargetNamespace).Update(
-			context.TODO(), cloudInitSecret, metav1.UpdateOptions{})
+			hvScope.Ctx, cloudInitSecret, metav1.UpdateOptions{})
+		recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionUpdate,
+			"cloud-init secret "+cloudInitSecret.Namespace+"/"+cloudInitSecret.Name, err)
+
 		if err != nil {
 			return nil, errors.Wrap(err, "unable to update cloud-init secret")
 		}
 	}
 
+	pvcNames := make([]string, 0, len(pvcs))
+	for _, pvc := range pvcs {
+		pvcNames = append(pvcNames, pvc.Name)
+	}
+
+	diskNames, err := json.Marshal(pvcNames)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate disk names annotation")
+	}
+
 	vmTemplate = &kubevirtv1.VirtualMachineInstanceTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
 			Annotations: map[string]string{
-				hvAnnotationDiskNames: "[\"" + pvcName + "\"]",
+				hvAnnotationDiskNames: string(diskNames),
 				hvAnnotationSSH:       "[\"" + sshKey.GetName() + "\"]",
 				cpVMLabelKey:          cpVMLabelValuePrefix + "-" + hvScope.Cluster.Name,
 			},
@@ -688,47 +1098,19 @@ runcmd:
 		Spec: kubevirtv1.VirtualMachineInstanceSpec{
 			Hostname: hvScope.HarvesterMachine.Name,
 			Networks: getKubevirtNetworksFromHarvesterMachine(hvScope.HarvesterMachine),
-
-			// Networks: []kubevirtv1.Network{
-
-			// 	{
-			// 		Name: "nic-1",
-
-			// 		NetworkSource: kubevirtv1.NetworkSource{
-			// 			Multus: &kubevirtv1.MultusNetwork{
-			// 				NetworkName: "vlan1",
-			// 			},
-			// 		},
-			// 	},
-			// },
-			Volumes: []kubevirtv1.Volume{
-				{
-					Name: "disk-0",
-					VolumeSource: kubevirtv1.VolumeSource{
-						PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
-							PersistentVolumeClaimVolumeSource: v1.PersistentVolumeClaimVolumeSource{
-								ClaimName: pvcName,
-							},
-						},
-					},
-				},
-				{
-					Name: "cloudinitdisk",
-					VolumeSource: kubevirtv1.VolumeSource{
-						CloudInitNoCloud: &kubevirtv1.CloudInitNoCloudSource{
-							UserDataSecretRef: &v1.LocalObjectReference{
-								Name: hvScope.HarvesterMachine.Name + "-cloud-init",
-							},
+			Volumes: append(volumes, kubevirtv1.Volume{
+				Name: "cloudinitdisk",
+				VolumeSource: kubevirtv1.VolumeSource{
+					CloudInitNoCloud: &kubevirtv1.CloudInitNoCloudSource{
+						UserDataSecretRef: &v1.LocalObjectReference{
+							Name: hvScope.HarvesterMachine.Name + "-cloud-init",
 						},
+						NetworkDataSecretRef: networkDataSecretRef,
 					},
 				},
-			},
+			}),
 			Domain: kubevirtv1.DomainSpec{
-				CPU: &kubevirtv1.CPU{
-					Cores:   uint32(hvScope.HarvesterMachine.Spec.CPU),
-					Sockets: uint32(hvScope.HarvesterMachine.Spec.CPU),
-					Threads: uint32(hvScope.HarvesterMachine.Spec.CPU),
-				},
+				CPU: buildVMCPU(hvScope.HarvesterMachine.Spec.CPU),
 				Devices: kubevirtv1.Devices{
 					Inputs: []kubevirtv1.Input{
 						{
@@ -737,37 +1119,17 @@ runcmd:
 							Name: "tablet",
 						},
 					},
-					Interfaces: []kubevirtv1.Interface{
-						{
-							Name:                   "nic-1",
-							Model:                  "virtio",
-							InterfaceBindingMethod: kubevirtv1.DefaultBridgeNetworkInterface().InterfaceBindingMethod,
-						},
-					},
-					Disks: []kubevirtv1.Disk{
-						{
-							Name: "disk-0",
-							DiskDevice: kubevirtv1.DiskDevice{
-								Disk: &kubevirtv1.DiskTarget{
-									Bus: "virtio",
-								},
-							},
-						},
-						{
-							Name: "cloudinitdisk",
-							DiskDevice: kubevirtv1.DiskDevice{
-								Disk: &kubevirtv1.DiskTarget{
-									Bus: "virtio",
-								},
+					Interfaces: interfaces,
+					Disks: append(disks, kubevirtv1.Disk{
+						Name: "cloudinitdisk",
+						DiskDevice: kubevirtv1.DiskDevice{
+							Disk: &kubevirtv1.DiskTarget{
+								Bus: "virtio",
 							},
 						},
-					},
-				},
-				Resources: kubevirtv1.ResourceRequirements{
-					Requests: v1.ResourceList{
-						"memory": resource.MustParse(hvScope.HarvesterMachine.Spec.Memory),
-					},
+					}),
 				},
+				Resources: buildVMResources(hvScope.HarvesterMachine.Spec.CPU, hvScope.HarvesterMachine.Spec.Memory),
 			},
 			Affinity: &v1.Affinity{
 				PodAntiAffinity: &v1.PodAntiAffinity{
@@ -792,22 +1154,119 @@ runcmd:
 	return vmTemplate, nil
 }
 
+// buildVMCPU converts the CPU topology of a HarvesterMachine to a KubeVirt CPU, counting unset sockets and threads as 1.
+func buildVMCPU(cpu infrav1.CPU) *kubevirtv1.CPU {
+	vmCPU := &kubevirtv1.CPU{
+		Cores:                 uint32(cpu.Cores),
+		Sockets:               1,
+		Threads:               1,
+		Model:                 cpu.Model,
+		DedicatedCPUPlacement: cpu.DedicatedCPUPlacement,
+	}
+
+	if cpu.Sockets > 0 {
+		vmCPU.Sockets = uint32(cpu.Sockets)
+	}
+
+	if cpu.Threads > 0 {
+		vmCPU.Threads = uint32(cpu.Threads)
+	}
+
+	return vmCPU
+}
+
+// buildVMResources sets the CPU and memory limits of the VM to its vCPUs and memory, like Harvester does for the VMs it creates.
+// Dedicated CPU placement additionally requires the requests to match the limits.
+func buildVMResources(cpu infrav1.CPU, memory resource.Quantity) kubevirtv1.ResourceRequirements {
+	vcpus := *resource.NewQuantity(cpu.VCPUs(), resource.DecimalSI)
+
+	resources := kubevirtv1.ResourceRequirements{
+		Requests: v1.ResourceList{
+			v1.ResourceMemory: memory,
+		},
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    vcpus,
+			v1.ResourceMemory: memory,
+		},
+	}
+
+	if cpu.DedicatedCPUPlacement {
+		resources.Requests[v1.ResourceCPU] = vcpus
+	}
+
+	return resources
+}
+
 func getKubevirtNetworksFromHarvesterMachine(harvesterMachine *infrav1.HarvesterMachine) []kubevirtv1.Network {
 	networks := []kubevirtv1.Network{}
+
 	for i, network := range harvesterMachine.Spec.Networks {
-		networks = append(networks, kubevirtv1.Network{
-			Name: "nic-" + strconv.Itoa(i+1),
-			NetworkSource: kubevirtv1.NetworkSource{
-				Multus: &kubevirtv1.MultusNetwork{
-					NetworkName: network,
-				},
-			},
-		})
+		kvNetwork := kubevirtv1.Network{
+			Name: getInterfaceName(i),
+		}
+
+		if network.Binding == infrav1.InterfaceBindingMasquerade {
+			kvNetwork.NetworkSource.Pod = &kubevirtv1.PodNetwork{}
+		} else if network.VMNetwork != nil {
+			kvNetwork.NetworkSource.Multus = &kubevirtv1.MultusNetwork{
+				NetworkName: network.VMNetwork.String(),
+			}
+		}
+
+		networks = append(networks, kvNetwork)
 	}
 
 	return networks
 }
 
+// getKubevirtInterfacesFromHarvesterMachine creates one interface per network of the HarvesterMachine,
+// in the same order and with the same names as getKubevirtNetworksFromHarvesterMachine.
+func getKubevirtInterfacesFromHarvesterMachine(harvesterMachine *infrav1.HarvesterMachine) []kubevirtv1.Interface {
+	interfaces := []kubevirtv1.Interface{}
+
+	for i, network := range harvesterMachine.Spec.Networks {
+		kvInterface := kubevirtv1.Interface{
+			Name:       getInterfaceName(i),
+			Model:      network.Model,
+			MacAddress: network.MACAddress,
+		}
+
+		switch network.Binding {
+		case infrav1.InterfaceBindingMasquerade:
+			kvInterface.InterfaceBindingMethod.Masquerade = &kubevirtv1.InterfaceMasquerade{}
+		case infrav1.InterfaceBindingSRIOV:
+			kvInterface.InterfaceBindingMethod.SRIOV = &kubevirtv1.InterfaceSRIOV{}
+		default:
+			kvInterface.InterfaceBindingMethod.Bridge = &kubevirtv1.InterfaceBridge{}
+		}
+
+		// SR-IOV interfaces are not emulated, so they have no model.
+		if network.Binding != infrav1.InterfaceBindingSRIOV && kvInterface.Model == "" {
+			kvInterface.Model = infrav1.DefaultInterfaceModel
+		}
+
+		interfaces = append(interfaces, kvInterface)
+	}
+
+	return interfaces
+}
+
+// getPrimaryNetworkIndex returns the index of the network marked as primary, or of the first network if none is.
+func getPrimaryNetworkIndex(networks []infrav1.Network) int {
+	for i, network := range networks {
+		if network.Primary {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// getInterfaceName returns the name of the VM interface and network created for the network at the given index.
+func getInterfaceName(index int) string {
+	return "nic-" + strconv.Itoa(index+1)
+}
+
 func getCloudInitData(hvScope *Scope) (string, error) {
 	dataSecretNamespacedName := types.NamespacedName{
 		Namespace: hvScope.Machine.Namespace,
@@ -829,21 +1288,70 @@ func getCloudInitData(hvScope *Scope) (string, error) {
 	return string(userData), nil
 }
 
+// getKubeVIPCloudInit returns the cloud-init section writing the kube-vip static pod on a control plane machine,
+// or an empty section if the machine is not part of the control plane or the cluster does not use kube-vip.
+func getKubeVIPCloudInit(hvScope *Scope) (string, error) {
+	lbConfig := hvScope.HarvesterCluster.Spec.LoadBalancerConfig
+
+	if lbConfig.Type != infrav1.LoadBalancerTypeKubeVIP {
+		return "", nil
+	}
+
+	if _, ok := hvScope.HarvesterMachine.Labels[clusterv1.MachineControlPlaneLabel]; !ok {
+		return "", nil
+	}
+
+	kubeVIP := infrav1.KubeVIPConfig{}
+	if lbConfig.KubeVIP != nil {
+		kubeVIP = *lbConfig.KubeVIP
+	}
+
+	if kubeVIP.Image == "" {
+		kubeVIP.Image = infrav1.DefaultKubeVIPImage
+	}
+
+	if kubeVIP.ManifestPath == "" {
+		kubeVIP.ManifestPath = infrav1.DefaultKubeVIPManifestPath
+	}
+
+	if kubeVIP.KubeconfigPath == "" {
+		kubeVIP.KubeconfigPath = infrav1.DefaultKubeVIPKubeconfigPath
+	}
+
+	return locutil.RenderKubeVIPCloudInit(locutil.KubeVIPConfig{
+		Image:          kubeVIP.Image,
+		Address:        hvScope.HarvesterCluster.Spec.ControlPlaneEndpoint.Host,
+		Port:           hvScope.HarvesterCluster.Spec.ControlPlaneEndpoint.Port,
+		Interface:      kubeVIP.Interface,
+		DDNS:           lbConfig.IPAM.Type == infrav1.IPAMTypeDHCP,
+		ManifestPath:   kubeVIP.ManifestPath,
+		KubeconfigPath: kubeVIP.KubeconfigPath,
+	})
+}
+
 // ReconcileDelete deletes a HarvesterMachine with all its dependencies.
 func (r *HarvesterMachineReconciler) ReconcileDelete(hvScope Scope) (res ctrl.Result, rerr error) {
 	logger := log.FromContext(hvScope.Ctx)
 	logger.Info("Deleting HarvesterMachine ...")
 
+	endPhase := tracing.StartPhase(&hvScope.Ctx, "ReconcileDelete")
+	defer func() { endPhase(rerr) }()
+
 	err := hvScope.HarvesterClient.CoreV1().Secrets(hvScope.HarvesterCluster.Spec.TargetNamespace).Delete(
 		hvScope.Ctx, hvScope.HarvesterMachine.Name+"-cloud-init", metav1.DeleteOptions{})
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
 			logger.Error(err, "unable to delete cloud-init secret, error was different than NotFound")
+			recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionDelete,
+				"cloud-init secret "+hvScope.HarvesterCluster.Spec.TargetNamespace+"/"+hvScope.HarvesterMachine.Name+"-cloud-init", err)
 
 			return ctrl.Result{Requeue: true}, err
 		}
 
 		logger.Info("cloud-init secret not found, doing nothing")
+	} else {
+		recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionDelete,
+			"cloud-init secret "+hvScope.HarvesterCluster.Spec.TargetNamespace+"/"+hvScope.HarvesterMachine.Name+"-cloud-init", nil)
 	}
 
 	logger.V(5).Info("cloud-init secret deleted successfully: " + hvScope.HarvesterMachine.Name + "-cloud-init")
@@ -877,12 +1385,17 @@ func (r *HarvesterMachineReconciler) ReconcileDelete(hvScope Scope) (res ctrl.Re
 			if err != nil {
 				if !apierrors.IsNotFound(err) {
 					logger.Error(err, "unable to delete VM, error was different than NotFound")
+					recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionDelete,
+						"VM "+vm.Namespace+"/"+vm.Name, err)
 
 					return ctrl.Result{Requeue: true}, err
 				}
 
 				logger.Info("VM not found, doing nothing")
 				time.Sleep(time.Second)
+			} else {
+				recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionDelete,
+					"VM "+vm.Namespace+"/"+vm.Name, nil)
 			}
 
 			logger.V(5).Info("VM deleted successfully: " + hvScope.HarvesterMachine.Name)
@@ -893,11 +1406,16 @@ func (r *HarvesterMachineReconciler) ReconcileDelete(hvScope Scope) (res ctrl.Re
 				if err != nil {
 					if !apierrors.IsNotFound(err) {
 						logger.Error(err, "unable to delete PVC, error was different than NotFound")
+						recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionDelete,
+							"PVC "+hvScope.HarvesterCluster.Spec.TargetNamespace+"/"+pvc.Name, err)
 
 						return ctrl.Result{Requeue: true}, err
 					}
 
 					logger.Info("attached PVC not found, continuing")
+				} else {
+					recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionDelete,
+						"PVC "+hvScope.HarvesterCluster.Spec.TargetNamespace+"/"+pvc.Name, nil)
 				}
 
 				logger.V(5).Info("PVC deleted successfully: " + pvc.Name)
@@ -905,6 +1423,12 @@ func (r *HarvesterMachineReconciler) ReconcileDelete(hvScope Scope) (res ctrl.Re
 		}
 	}
 
+	if err := releaseStaticAddresses(&hvScope); err != nil {
+		logger.Error(err, "unable to release static addresses of the VM interfaces")
+
+		return ctrl.Result{Requeue: true}, err
+	}
+
 	if ok := controllerutil.RemoveFinalizer(hvScope.HarvesterMachine, infrav1.MachineFinalizer); !ok {
 		return ctrl.Result{}, fmt.Errorf("unable to remove finalizer %s from HarvesterMachine %s/%s",
 			infrav1.MachineFinalizer,
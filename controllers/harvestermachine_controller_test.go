@@ -1,22 +1,39 @@
 package controllers
 
 import (
+	"fmt"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	harvesterv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
-	"github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
 )
 
 var _ = Describe("Convert HarvesterMachine networks to Kubevirt Networks", func() {
-	var hvMachineNetworks *v1alpha1.HarvesterMachine
+	var hvMachineNetworks *v1alpha2.HarvesterMachine
 	var kvNetworks []kubevirtv1.Network
 
 	BeforeEach(func() {
-		hvMachineNetworks = &v1alpha1.HarvesterMachine{
-			Spec: v1alpha1.HarvesterMachineSpec{
-				Networks: []string{"network1", "network2"},
+		hvMachineNetworks = &v1alpha2.HarvesterMachine{
+			Spec: v1alpha2.HarvesterMachineSpec{
+				Networks: []v1alpha2.Network{
+					{VMNetwork: &v1alpha2.ObjectReference{Name: "network1"}},
+					{VMNetwork: &v1alpha2.ObjectReference{Namespace: "default", Name: "network2"}},
+					{Binding: v1alpha2.InterfaceBindingMasquerade},
+				},
 			},
 		}
 
@@ -33,10 +50,16 @@ var _ = Describe("Convert HarvesterMachine networks to Kubevirt Networks", func(
 				Name: "nic-2",
 				NetworkSource: kubevirtv1.NetworkSource{
 					Multus: &kubevirtv1.MultusNetwork{
-						NetworkName: "network2",
+						NetworkName: "default/network2",
 					},
 				},
 			},
+			{
+				Name: "nic-3",
+				NetworkSource: kubevirtv1.NetworkSource{
+					Pod: &kubevirtv1.PodNetwork{},
+				},
+			},
 		}
 	})
 	Context("When we provide a list of HarvesterMachine networks", func() {
@@ -45,3 +68,515 @@ var _ = Describe("Convert HarvesterMachine networks to Kubevirt Networks", func(
 		})
 	})
 })
+
+var _ = Describe("Convert HarvesterMachine networks to Kubevirt Interfaces", func() {
+	Context("When we provide networks with different bindings", func() {
+		It("Should return one interface per network", func() {
+			hvMachine := &v1alpha2.HarvesterMachine{
+				Spec: v1alpha2.HarvesterMachineSpec{
+					Networks: []v1alpha2.Network{
+						{VMNetwork: &v1alpha2.ObjectReference{Name: "network1"}, MACAddress: "02:00:00:00:00:01"},
+						{VMNetwork: &v1alpha2.ObjectReference{Name: "sriov"}, Binding: v1alpha2.InterfaceBindingSRIOV},
+						{Binding: v1alpha2.InterfaceBindingMasquerade, Model: "e1000"},
+					},
+				},
+			}
+
+			Expect(getKubevirtInterfacesFromHarvesterMachine(hvMachine)).To(Equal([]kubevirtv1.Interface{
+				{
+					Name:                   "nic-1",
+					Model:                  "virtio",
+					MacAddress:             "02:00:00:00:00:01",
+					InterfaceBindingMethod: kubevirtv1.InterfaceBindingMethod{Bridge: &kubevirtv1.InterfaceBridge{}},
+				},
+				{
+					Name:                   "nic-2",
+					InterfaceBindingMethod: kubevirtv1.InterfaceBindingMethod{SRIOV: &kubevirtv1.InterfaceSRIOV{}},
+				},
+				{
+					Name:                   "nic-3",
+					Model:                  "e1000",
+					InterfaceBindingMethod: kubevirtv1.InterfaceBindingMethod{Masquerade: &kubevirtv1.InterfaceMasquerade{}},
+				},
+			}))
+		})
+	})
+})
+
+var _ = Describe("Get Machine addresses from Kubevirt interfaces", func() {
+	Context("When the second network is primary", func() {
+		It("Should return the addresses of the primary interface first", func() {
+			networks := []v1alpha2.Network{{}, {Primary: true}}
+			interfaces := []kubevirtv1.VirtualMachineInstanceNetworkInterface{
+				{Name: "nic-1", IP: "10.0.0.10"},
+				{Name: "nic-2", IP: "192.168.1.10"},
+				{Name: "nic-3"},
+			}
+
+			primaryInterface := getInterfaceName(getPrimaryNetworkIndex(networks))
+			Expect(getMachineAddressesFromInterfaces(interfaces, primaryInterface)).To(Equal([]clusterv1.MachineAddress{
+				{Type: clusterv1.MachineExternalIP, Address: "192.168.1.10"},
+				{Type: clusterv1.MachineExternalIP, Address: "10.0.0.10"},
+			}))
+		})
+	})
+})
+
+var _ = Describe("Set the lifecycle conditions of a HarvesterMachine", func() {
+	var (
+		harvesterMachine *v1alpha2.HarvesterMachine
+		vm               *kubevirtv1.VirtualMachine
+		vmInstance       *kubevirtv1.VirtualMachineInstance
+	)
+
+	BeforeEach(func() {
+		harvesterMachine = &v1alpha2.HarvesterMachine{}
+		vm = &kubevirtv1.VirtualMachine{
+			Status: kubevirtv1.VirtualMachineStatus{PrintableStatus: kubevirtv1.VirtualMachineStatusRunning},
+		}
+		vmInstance = &kubevirtv1.VirtualMachineInstance{
+			Status: kubevirtv1.VirtualMachineInstanceStatus{Phase: kubevirtv1.Running},
+		}
+	})
+
+	It("Should mark the VM running when its instance runs", func() {
+		setVMRunningCondition(harvesterMachine, vm, vmInstance)
+
+		Expect(conditions.IsTrue(harvesterMachine, v1alpha2.VMRunningCondition)).To(BeTrue())
+		Expect(harvesterMachine.Status.VMState).To(Equal("Running"))
+	})
+
+	It("Should use the printable status of the VM as reason", func() {
+		vm.Status.PrintableStatus = kubevirtv1.VirtualMachineStatusStopped
+		setVMRunningCondition(harvesterMachine, vm, nil)
+
+		Expect(conditions.GetReason(harvesterMachine, v1alpha2.VMRunningCondition)).To(Equal("Stopped"))
+		Expect(conditions.GetSeverity(harvesterMachine, v1alpha2.VMRunningCondition)).
+			To(HaveValue(Equal(clusterv1.ConditionSeverityInfo)))
+		Expect(harvesterMachine.Status.VMState).To(Equal("Stopped"))
+	})
+
+	It("Should report the errors of the VM with the Error severity", func() {
+		vm.Status.PrintableStatus = "ErrorUnschedulable"
+		vm.Status.Conditions = []kubevirtv1.VirtualMachineCondition{{
+			Type:    kubevirtv1.VirtualMachineFailure,
+			Status:  corev1.ConditionTrue,
+			Message: "0/3 nodes are available: 3 Insufficient memory.",
+		}}
+		vmInstance.Status.Phase = kubevirtv1.Pending
+		setVMRunningCondition(harvesterMachine, vm, vmInstance)
+
+		Expect(conditions.GetReason(harvesterMachine, v1alpha2.VMRunningCondition)).To(Equal("ErrorUnschedulable"))
+		Expect(conditions.GetSeverity(harvesterMachine, v1alpha2.VMRunningCondition)).
+			To(HaveValue(Equal(clusterv1.ConditionSeverityError)))
+		Expect(conditions.GetMessage(harvesterMachine, v1alpha2.VMRunningCondition)).To(ContainSubstring("Insufficient memory"))
+	})
+
+	It("Should fall back to the phase of the VM instance", func() {
+		vm.Status.PrintableStatus = ""
+		vmInstance.Status.Phase = kubevirtv1.Scheduling
+		setVMRunningCondition(harvesterMachine, vm, vmInstance)
+
+		Expect(conditions.GetReason(harvesterMachine, v1alpha2.VMRunningCondition)).To(Equal("Scheduling"))
+		Expect(harvesterMachine.Status.VMState).To(Equal("Scheduling"))
+
+		setVMRunningCondition(harvesterMachine, vm, nil)
+
+		Expect(conditions.GetReason(harvesterMachine, v1alpha2.VMRunningCondition)).To(Equal(v1alpha2.VMInstanceNotFoundReason))
+	})
+
+	It("Should replace the MachineCreated condition of previous versions", func() {
+		conditions.MarkTrue(harvesterMachine, v1alpha2.MachineCreatedCondition)
+		migrateMachineCreatedCondition(harvesterMachine)
+
+		Expect(conditions.Has(harvesterMachine, v1alpha2.MachineCreatedCondition)).To(BeFalse())
+		Expect(conditions.IsTrue(harvesterMachine, v1alpha2.VMProvisionedCondition)).To(BeTrue())
+	})
+
+	It("Should summarize the lifecycle conditions in the Ready condition", func() {
+		conditions.MarkTrue(harvesterMachine, v1alpha2.VMProvisionedCondition)
+		conditions.MarkTrue(harvesterMachine, v1alpha2.BootstrapDataAppliedCondition)
+		conditions.MarkTrue(harvesterMachine, v1alpha2.VMRunningCondition)
+		conditions.MarkFalse(harvesterMachine, v1alpha2.AddressesReportedCondition,
+			v1alpha2.WaitingForGuestAgentReason, clusterv1.ConditionSeverityInfo, "")
+		conditions.MarkFalse(harvesterMachine, v1alpha2.NodeRegisteredCondition,
+			v1alpha2.WaitingForNodeReason, clusterv1.ConditionSeverityInfo, "")
+		conditions.SetSummary(harvesterMachine, conditions.WithConditions(harvesterMachineReadyConditions...))
+
+		Expect(conditions.IsFalse(harvesterMachine, clusterv1.ReadyCondition)).To(BeTrue())
+		Expect(conditions.GetReason(harvesterMachine, clusterv1.ReadyCondition)).To(Equal(v1alpha2.WaitingForGuestAgentReason))
+
+		conditions.MarkTrue(harvesterMachine, v1alpha2.AddressesReportedCondition)
+		conditions.MarkTrue(harvesterMachine, v1alpha2.NodeRegisteredCondition)
+		conditions.SetSummary(harvesterMachine, conditions.WithConditions(harvesterMachineReadyConditions...))
+
+		Expect(conditions.IsTrue(harvesterMachine, clusterv1.ReadyCondition)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Detect the terminal failures of a HarvesterMachine", func() {
+	It("Should find a terminal failure in wrapped errors", func() {
+		err := errors.Wrap(fmt.Errorf("unable to build VM: %w",
+			newMachineFailure(capierrors.InvalidConfigurationMachineError, errors.New("impossible to find VM image"))),
+			"unable to create VM")
+
+		failure := getMachineFailure(err)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.reason).To(Equal(capierrors.InvalidConfigurationMachineError))
+		Expect(err.Error()).To(ContainSubstring("impossible to find VM image"))
+	})
+
+	It("Should not find a terminal failure in transient errors", func() {
+		Expect(getMachineFailure(errors.Wrap(errors.New("connection refused"), "unable to list VM images"))).To(BeNil())
+	})
+
+	It("Should record the failure in the HarvesterMachine status", func() {
+		harvesterMachine := &v1alpha2.HarvesterMachine{Status: v1alpha2.HarvesterMachineStatus{Ready: true}}
+		setMachineFailure(harvesterMachine, capierrors.UpdateMachineError, "the VM was not found in Harvester")
+
+		Expect(harvesterMachine.Status.FailureReason).To(Equal("UpdateError"))
+		Expect(harvesterMachine.Status.FailureMessage).To(Equal("the VM was not found in Harvester"))
+		Expect(harvesterMachine.Status.Ready).To(BeFalse())
+	})
+
+	It("Should record the failure in a Warning event", func() {
+		recorder := record.NewFakeRecorder(1)
+		hvScope := &Scope{HarvesterMachine: &v1alpha2.HarvesterMachine{}, EventRecorder: recorder}
+		setMachineFailure(hvScope.HarvesterMachine, capierrors.InvalidConfigurationMachineError, "impossible to find VM image")
+		recordMachineFailureEvent(hvScope)
+
+		Expect(<-recorder.Events).To(Equal("Warning MachineFailed InvalidConfiguration: impossible to find VM image"))
+	})
+
+	It("Should record the actions done in Harvester", func() {
+		recorder := record.NewFakeRecorder(2)
+		harvesterMachine := &v1alpha2.HarvesterMachine{}
+		recordHarvesterEvent(recorder, harvesterMachine, harvesterActionCreate, "VM default/test", nil)
+		recordHarvesterEvent(recorder, harvesterMachine, harvesterActionDelete, "PVC default/test-disk-0", errors.New("forbidden"))
+
+		Expect(<-recorder.Events).To(Equal("Normal SuccessfulCreate Created VM default/test in Harvester"))
+		Expect(<-recorder.Events).To(Equal("Warning FailedDelete Failed to delete PVC default/test-disk-0 in Harvester: forbidden"))
+		Expect(func() { recordHarvesterEvent(nil, harvesterMachine, harvesterActionUpdate, "VM default/test", nil) }).NotTo(Panic())
+	})
+
+	It("Should time out the provisioning of a VM", func() {
+		now := time.Now()
+		vm := &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+		}
+
+		Expect(hasProvisioningTimedOut(vm, 30*time.Minute, now)).To(BeTrue())
+		Expect(hasProvisioningTimedOut(vm, 2*time.Hour, now)).To(BeFalse())
+		Expect(hasProvisioningTimedOut(vm, 0, now)).To(BeFalse())
+		Expect(getProvisioningTimeoutMessage(&v1alpha2.HarvesterMachine{
+			Status: v1alpha2.HarvesterMachineStatus{VMState: "ErrorUnschedulable"},
+		}, 30*time.Minute)).To(ContainSubstring("ErrorUnschedulable"))
+	})
+
+	It("Should requeue a watched VM when its provisioning times out", func() {
+		now := time.Now()
+		vm := &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-20 * time.Minute))},
+		}
+
+		watched := requeueForVMChange(&Scope{VMWatched: true}, time.Minute)
+		Expect(watched.RequeueAfter).To(BeZero())
+		Expect(requeueBeforeProvisioningTimeout(watched, vm, 30*time.Minute, now).RequeueAfter).To(Equal(10*time.Minute + time.Second))
+
+		polled := requeueForVMChange(&Scope{}, time.Minute)
+		Expect(requeueBeforeProvisioningTimeout(polled, vm, 30*time.Minute, now).RequeueAfter).To(Equal(time.Minute))
+		Expect(requeueBeforeProvisioningTimeout(watched, vm, 0, now).RequeueAfter).To(BeZero())
+	})
+})
+
+var _ = Describe("Detect the terminal failures of a VM in Harvester", func() {
+	var (
+		harvesterMachine *v1alpha2.HarvesterMachine
+		vm               *kubevirtv1.VirtualMachine
+		vmInstance       *kubevirtv1.VirtualMachineInstance
+		now              time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Now()
+		harvesterMachine = &v1alpha2.HarvesterMachine{}
+		vm = &kubevirtv1.VirtualMachine{
+			Status: kubevirtv1.VirtualMachineStatus{PrintableStatus: kubevirtv1.VirtualMachineStatusStarting},
+		}
+		vmInstance = &kubevirtv1.VirtualMachineInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", CreationTimestamp: metav1.NewTime(now.Add(-time.Minute))},
+			Status:     kubevirtv1.VirtualMachineInstanceStatus{Phase: kubevirtv1.Scheduling},
+		}
+	})
+
+	unschedulableSince := func(since time.Time) kubevirtv1.VirtualMachineInstanceCondition {
+		return kubevirtv1.VirtualMachineInstanceCondition{
+			Type:               kubevirtv1.VirtualMachineInstanceConditionType(corev1.PodScheduled),
+			Status:             corev1.ConditionFalse,
+			Reason:             corev1.PodReasonUnschedulable,
+			Message:            "0/3 nodes are available: 3 Insufficient memory.",
+			LastTransitionTime: metav1.NewTime(since),
+		}
+	}
+
+	pvcInPhase := func(phase corev1.PersistentVolumeClaimPhase, created time.Time) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-disk-0", Namespace: "default", CreationTimestamp: metav1.NewTime(created)},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+
+	It("Should not report a failure for a VM which is starting", func() {
+		failure, untilFailure := getVMFailure(harvesterMachine, vm, vmInstance,
+			[]*corev1.PersistentVolumeClaim{pvcInPhase(corev1.ClaimBound, now.Add(-time.Hour))}, now)
+		Expect(failure).To(BeNil())
+		Expect(untilFailure).To(BeZero())
+
+		failure, _ = getVMFailure(harvesterMachine, vm, nil, nil, now)
+		Expect(failure).To(BeNil())
+	})
+
+	It("Should report a failed VM instance as a creation error until the machine is provisioned", func() {
+		vmInstance.Status.Phase = kubevirtv1.Failed
+
+		failure, _ := getVMFailure(harvesterMachine, vm, vmInstance, nil, now)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.reason).To(Equal(capierrors.CreateMachineError))
+		Expect(failure.Error()).To(Equal("the VM instance default/test failed"))
+
+		harvesterMachine.Spec.ProviderID = "harvester://1234"
+		failure, _ = getVMFailure(harvesterMachine, vm, vmInstance, nil, now)
+		Expect(failure.reason).To(Equal(capierrors.UpdateMachineError))
+	})
+
+	It("Should report a VM which cannot be scheduled for too long", func() {
+		vm.Status.PrintableStatus = kubevirtv1.VirtualMachineStatusUnschedulable
+		vmInstance.Status.Conditions = []kubevirtv1.VirtualMachineInstanceCondition{unschedulableSince(now.Add(-10 * time.Minute))}
+
+		failure, _ := getVMFailure(harvesterMachine, vm, vmInstance, nil, now)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.reason).To(Equal(capierrors.InsufficientResourcesMachineError))
+		Expect(failure.Error()).To(Equal("the VM could not be scheduled for 10m0s: 0/3 nodes are available: 3 Insufficient memory."))
+	})
+
+	It("Should wait for a VM which cannot be scheduled yet to be scheduled", func() {
+		vm.Status.PrintableStatus = kubevirtv1.VirtualMachineStatusUnschedulable
+		vmInstance.Status.Conditions = []kubevirtv1.VirtualMachineInstanceCondition{unschedulableSince(now.Add(-2 * time.Minute))}
+
+		failure, untilFailure := getVMFailure(harvesterMachine, vm, vmInstance, nil, now)
+		Expect(failure).To(BeNil())
+		Expect(untilFailure).To(Equal(3*time.Minute + time.Second))
+	})
+
+	It("Should fall back to the printable status of an unschedulable VM", func() {
+		vm.Status.PrintableStatus = kubevirtv1.VirtualMachineStatusUnschedulable
+		vmInstance.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+
+		failure, _ := getVMFailure(harvesterMachine, vm, vmInstance, nil, now)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.reason).To(Equal(capierrors.InsufficientResourcesMachineError))
+	})
+
+	It("Should report a volume which lost its persistent volume", func() {
+		failure, _ := getVMFailure(harvesterMachine, vm, vmInstance,
+			[]*corev1.PersistentVolumeClaim{pvcInPhase(corev1.ClaimLost, now.Add(-time.Hour))}, now)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.reason).To(Equal(capierrors.CreateMachineError))
+		Expect(failure.Error()).To(Equal("the volume default/test-disk-0 of the VM lost its persistent volume"))
+	})
+
+	It("Should report a volume which is not bound for too long", func() {
+		failure, _ := getVMFailure(harvesterMachine, vm, vmInstance,
+			[]*corev1.PersistentVolumeClaim{pvcInPhase(corev1.ClaimPending, now.Add(-10*time.Minute))}, now)
+		Expect(failure).NotTo(BeNil())
+		Expect(failure.reason).To(Equal(capierrors.InvalidConfigurationMachineError))
+		Expect(failure.Error()).To(Equal("the volume default/test-disk-0 of the VM was not bound within 10m0s"))
+	})
+
+	It("Should wait for a pending volume to be bound", func() {
+		failure, untilFailure := getVMFailure(harvesterMachine, vm, vmInstance,
+			[]*corev1.PersistentVolumeClaim{pvcInPhase(corev1.ClaimPending, now.Add(-4*time.Minute))}, now)
+		Expect(failure).To(BeNil())
+		Expect(untilFailure).To(Equal(time.Minute + time.Second))
+	})
+})
+
+var _ = Describe("Compute the boot order of HarvesterMachine volumes", func() {
+	Context("When no volume has a boot order", func() {
+		It("Should follow the sequence of the volumes", func() {
+			volumes := []v1alpha2.Volume{{}, {}, {}}
+			Expect(getBootOrders(volumes)).To(Equal([]uint{1, 2, 3}))
+		})
+	})
+
+	Context("When some volumes have a boot order", func() {
+		It("Should boot the other volumes after them", func() {
+			volumes := []v1alpha2.Volume{{}, {BootOrder: 2}, {}, {BootOrder: 1}}
+			Expect(getBootOrders(volumes)).To(Equal([]uint{3, 2, 4, 1}))
+		})
+	})
+})
+
+var _ = Describe("Build PVC templates from HarvesterMachine volumes", func() {
+	var volumeSize resource.Quantity
+
+	BeforeEach(func() {
+		volumeSize = resource.MustParse("10Gi")
+	})
+
+	Context("When the volume is created from an image", func() {
+		It("Should use the storage class of the image", func() {
+			volume := &v1alpha2.Volume{
+				VolumeType: v1alpha2.VolumeTypeImage,
+				Image:      &v1alpha2.ObjectReference{Name: "ubuntu"},
+				VolumeSize: &volumeSize,
+			}
+			vmImage := &harvesterv1beta1.VirtualMachineImage{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image-abcde"},
+			}
+
+			pvc := buildPVCFromVolume(volume, "vm-disk-0-xyz", "default", vmImage)
+			Expect(pvc.Name).To(Equal("vm-disk-0-xyz"))
+			Expect(pvc.Annotations).To(HaveKeyWithValue(hvAnnotationImageID, "default/image-abcde"))
+			Expect(*pvc.Spec.StorageClassName).To(Equal("longhorn-image-abcde"))
+			Expect(pvc.Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(volumeSize))
+		})
+	})
+
+	Context("When the volume is a blank storageClass volume", func() {
+		It("Should use the storage class of the volume", func() {
+			volume := &v1alpha2.Volume{
+				VolumeType:   v1alpha2.VolumeTypeStorageClass,
+				StorageClass: "harvester-longhorn",
+			}
+
+			pvc := buildPVCFromVolume(volume, "vm-disk-1-xyz", "default", nil)
+			Expect(pvc.Annotations).NotTo(HaveKey(hvAnnotationImageID))
+			Expect(*pvc.Spec.StorageClassName).To(Equal("harvester-longhorn"))
+			Expect(pvc.Spec.Resources.Requests[corev1.ResourceStorage]).To(Equal(resource.MustParse(v1alpha2.DefaultVolumeSize)))
+		})
+	})
+})
+
+var _ = Describe("Convert HarvesterMachine CPU topology to Kubevirt CPU", func() {
+	Context("When only cores are set", func() {
+		It("Should use a single socket and thread", func() {
+			cpu := v1alpha2.CPU{Cores: 4}
+
+			Expect(buildVMCPU(cpu)).To(Equal(&kubevirtv1.CPU{Cores: 4, Sockets: 1, Threads: 1}))
+
+			resources := buildVMResources(cpu, resource.MustParse("8Gi"))
+			Expect(resources.Limits[corev1.ResourceCPU]).To(Equal(*resource.NewQuantity(4, resource.DecimalSI)))
+			Expect(resources.Limits[corev1.ResourceMemory]).To(Equal(resource.MustParse("8Gi")))
+			Expect(resources.Requests).NotTo(HaveKey(corev1.ResourceCPU))
+		})
+	})
+
+	Context("When a full topology with dedicated CPUs is set", func() {
+		It("Should request and limit all vCPUs", func() {
+			cpu := v1alpha2.CPU{Cores: 2, Sockets: 2, Threads: 2, DedicatedCPUPlacement: true, Model: "host-passthrough"}
+
+			Expect(buildVMCPU(cpu)).To(Equal(&kubevirtv1.CPU{
+				Cores:                 2,
+				Sockets:               2,
+				Threads:               2,
+				Model:                 "host-passthrough",
+				DedicatedCPUPlacement: true,
+			}))
+
+			resources := buildVMResources(cpu, resource.MustParse("8Gi"))
+			Expect(resources.Limits[corev1.ResourceCPU]).To(Equal(*resource.NewQuantity(8, resource.DecimalSI)))
+			Expect(resources.Requests[corev1.ResourceCPU]).To(Equal(*resource.NewQuantity(8, resource.DecimalSI)))
+		})
+	})
+})
+
+var _ = Describe("Generate cloud-init network data for static addresses", func() {
+	var hvMachine *v1alpha2.HarvesterMachine
+	var interfaces []kubevirtv1.Interface
+
+	BeforeEach(func() {
+		hvMachine = &v1alpha2.HarvesterMachine{
+			Spec: v1alpha2.HarvesterMachineSpec{
+				Networks: []v1alpha2.Network{
+					{
+						VMNetwork: &v1alpha2.ObjectReference{Name: "network1"},
+						IPAM: &v1alpha2.NetworkIPAM{
+							Type:        v1alpha2.NetworkIPAMTypeIPPool,
+							IPPoolRef:   &v1alpha2.IPPoolReference{Name: "pool"},
+							Nameservers: []string{"10.0.0.2"},
+						},
+					},
+					{VMNetwork: &v1alpha2.ObjectReference{Name: "network2"}},
+				},
+			},
+		}
+
+		interfaces = getKubevirtInterfacesFromHarvesterMachine(hvMachine)
+	})
+
+	Context("When no interface has a static address", func() {
+		It("Should not generate network data", func() {
+			Expect(getNetworkData(hvMachine, interfaces)).To(BeNil())
+			Expect(interfaces[0].MacAddress).To(BeEmpty())
+		})
+	})
+
+	Context("When an interface has a static address", func() {
+		It("Should configure it and generate MAC addresses", func() {
+			hvMachine.Status.StaticAddresses = []v1alpha2.StaticAddress{
+				{Interface: "nic-1", Address: "10.0.0.10", Prefix: 24, Gateway: "10.0.0.1", IPPool: "pool"},
+			}
+
+			networkData, err := getNetworkData(hvMachine, interfaces)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(interfaces[0].MacAddress).NotTo(BeEmpty())
+			Expect(interfaces[1].MacAddress).NotTo(BeEmpty())
+			Expect(string(networkData)).To(ContainSubstring(`"10.0.0.10/24"`))
+			Expect(string(networkData)).To(ContainSubstring(`"via": "10.0.0.1"`))
+			Expect(string(networkData)).To(ContainSubstring(`"macaddress": "` + interfaces[1].MacAddress + `"`))
+		})
+	})
+})
+
+var _ = Describe("Generate the kube-vip cloud-init of a HarvesterMachine", func() {
+	var hvScope *Scope
+
+	BeforeEach(func() {
+		hvScope = &Scope{
+			HarvesterCluster: &v1alpha2.HarvesterCluster{
+				Spec: v1alpha2.HarvesterClusterSpec{
+					ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: "172.19.10.1", Port: 6443},
+					LoadBalancerConfig: v1alpha2.LoadBalancerConfig{
+						Type: v1alpha2.LoadBalancerTypeKubeVIP,
+						IPAM: v1alpha2.IPAMConfig{Type: v1alpha2.IPAMTypeIPPoolRef},
+					},
+				},
+			},
+			HarvesterMachine: &v1alpha2.HarvesterMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{clusterv1.MachineControlPlaneLabel: ""},
+				},
+			},
+		}
+	})
+
+	It("Should write the static pod on control plane machines", func() {
+		cloudInit, err := getKubeVIPCloudInit(hvScope)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cloudInit).To(ContainSubstring(v1alpha2.DefaultKubeVIPManifestPath))
+		Expect(cloudInit).To(ContainSubstring("172.19.10.1"))
+	})
+
+	It("Should not write anything on worker machines", func() {
+		hvScope.HarvesterMachine.Labels = nil
+
+		Expect(getKubeVIPCloudInit(hvScope)).To(BeEmpty())
+	})
+
+	It("Should not write anything when the cluster uses a load balancer", func() {
+		hvScope.HarvesterCluster.Spec.LoadBalancerConfig.Type = v1alpha2.LoadBalancerTypeHarvester
+
+		Expect(getKubeVIPCloudInit(hvScope)).To(BeEmpty())
+	})
+})
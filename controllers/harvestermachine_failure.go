@@ -0,0 +1,211 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	capierrors "sigs.k8s.io/cluster-api/errors"
+
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
+)
+
+const (
+	// DefaultMachineProvisioningTimeout is the default time after which a HarvesterMachine whose VM did not join
+	// the workload cluster is marked as failed.
+	DefaultMachineProvisioningTimeout = 30 * time.Minute
+	// vmStuckTimeout is the time after which a VM which cannot be scheduled, or whose volume is not bound, is marked as failed.
+	// Both can be solved by Harvester on its own when resources are freed, they are not terminal right away.
+	vmStuckTimeout = 5 * time.Minute
+)
+
+// machineFailure is an error which is not solved by requeuing the HarvesterMachine, like a reference to a missing VM image.
+// It is reported in the FailureReason and FailureMessage of the HarvesterMachine, so that its Machine is remediated.
+type machineFailure struct {
+	reason capierrors.MachineStatusError
+	err    error
+}
+
+// newMachineFailure returns a terminal error with the reason reported in the HarvesterMachine status.
+func newMachineFailure(reason capierrors.MachineStatusError, err error) error {
+	return &machineFailure{reason: reason, err: err}
+}
+
+func (f *machineFailure) Error() string {
+	return f.err.Error()
+}
+
+func (f *machineFailure) Unwrap() error {
+	return f.err
+}
+
+// getMachineFailure returns the terminal error wrapped in an error, or nil if the error is transient.
+func getMachineFailure(err error) *machineFailure {
+	failure := &machineFailure{}
+	if errors.As(err, &failure) {
+		return failure
+	}
+
+	return nil
+}
+
+// setMachineFailure records a terminal failure in the status of a HarvesterMachine, the HarvesterMachine is not reconciled anymore.
+func setMachineFailure(harvesterMachine *infrav1.HarvesterMachine, reason capierrors.MachineStatusError, message string) {
+	harvesterMachine.Status.FailureReason = string(reason)
+	harvesterMachine.Status.FailureMessage = message
+	harvesterMachine.Status.Ready = false
+}
+
+// recordMachineFailureEvent records a Warning event with the terminal failure of a HarvesterMachine.
+func recordMachineFailureEvent(hvScope *Scope) {
+	if hvScope.EventRecorder == nil {
+		return
+	}
+
+	hvScope.EventRecorder.Eventf(hvScope.HarvesterMachine, corev1.EventTypeWarning, machineFailedEventReason,
+		"%s: %s", hvScope.HarvesterMachine.Status.FailureReason, hvScope.HarvesterMachine.Status.FailureMessage)
+}
+
+// hasProvisioningTimedOut returns true when the VM was created more than the provisioning timeout ago.
+// A zero timeout disables the check.
+func hasProvisioningTimedOut(vm *kubevirtv1.VirtualMachine, timeout time.Duration, now time.Time) bool {
+	if timeout <= 0 || vm.CreationTimestamp.IsZero() {
+		return false
+	}
+
+	return now.Sub(vm.CreationTimestamp.Time) > timeout
+}
+
+// requeueBeforeProvisioningTimeout makes sure that a VM which is not provisioned yet is reconciled again when its provisioning
+// times out, even when the reconciliation only waits for the VM to change.
+func requeueBeforeProvisioningTimeout(res ctrl.Result, vm *kubevirtv1.VirtualMachine, timeout time.Duration, now time.Time) ctrl.Result {
+	if timeout <= 0 || vm.CreationTimestamp.IsZero() || res.Requeue {
+		return res
+	}
+
+	// Requeue just after the deadline, so that hasProvisioningTimedOut is true
+	untilTimeout := vm.CreationTimestamp.Add(timeout).Sub(now) + time.Second
+	if res.RequeueAfter == 0 || untilTimeout < res.RequeueAfter {
+		res.RequeueAfter = untilTimeout
+	}
+
+	return res
+}
+
+// getProvisioningTimeoutMessage describes the state of a VM which did not join the workload cluster in time.
+func getProvisioningTimeoutMessage(harvesterMachine *infrav1.HarvesterMachine, timeout time.Duration) string {
+	state := harvesterMachine.Status.VMState
+	if state == "" {
+		state = "unknown"
+	}
+
+	return fmt.Sprintf("the VM did not join the workload cluster within %s, its state is %s", timeout, state)
+}
+
+// getVMFailure returns the terminal failure of a VM which is not running: its VM instance failed, it could not be scheduled
+// for vmStuckTimeout, or one of its volumes was lost or not bound within vmStuckTimeout. The VM instance is nil when it does
+// not exist. When the VM is stuck but not for long enough yet, it returns the time after which it is considered failed.
+func getVMFailure(
+	harvesterMachine *infrav1.HarvesterMachine, vm *kubevirtv1.VirtualMachine, vmInstance *kubevirtv1.VirtualMachineInstance,
+	pvcs []*corev1.PersistentVolumeClaim, now time.Time,
+) (*machineFailure, time.Duration) {
+	var untilFailure time.Duration
+
+	if vmInstance != nil {
+		if vmInstance.Status.Phase == kubevirtv1.Failed {
+			return &machineFailure{
+				reason: getVMErrorReason(harvesterMachine),
+				err:    fmt.Errorf("the VM instance %s/%s failed", vmInstance.Namespace, vmInstance.Name),
+			}, 0
+		}
+
+		if since, message, unschedulable := getUnschedulableCondition(vm, vmInstance); unschedulable {
+			stuckFor := now.Sub(since)
+			if stuckFor > vmStuckTimeout {
+				return &machineFailure{
+					reason: capierrors.InsufficientResourcesMachineError,
+					err:    fmt.Errorf("the VM could not be scheduled for %s: %s", stuckFor.Round(time.Second), message),
+				}, 0
+			}
+
+			untilFailure = vmStuckTimeout - stuckFor + time.Second
+		}
+	}
+
+	for _, pvc := range pvcs {
+		switch pvc.Status.Phase {
+		case corev1.ClaimLost:
+			return &machineFailure{
+				reason: getVMErrorReason(harvesterMachine),
+				err:    fmt.Errorf("the volume %s/%s of the VM lost its persistent volume", pvc.Namespace, pvc.Name),
+			}, 0
+		case corev1.ClaimPending:
+			if pvc.CreationTimestamp.IsZero() {
+				continue
+			}
+
+			pendingFor := now.Sub(pvc.CreationTimestamp.Time)
+			if pendingFor > vmStuckTimeout {
+				return &machineFailure{
+					reason: capierrors.InvalidConfigurationMachineError,
+					err: fmt.Errorf("the volume %s/%s of the VM was not bound within %s",
+						pvc.Namespace, pvc.Name, pendingFor.Round(time.Second)),
+				}, 0
+			}
+
+			if untilPending := vmStuckTimeout - pendingFor + time.Second; untilFailure == 0 || untilPending < untilFailure {
+				untilFailure = untilPending
+			}
+		}
+	}
+
+	return nil, untilFailure
+}
+
+// getUnschedulableCondition returns since when the VM instance cannot be scheduled and why, when it cannot be scheduled.
+// KubeVirt reports it in the PodScheduled condition of the instance, and in the ErrorUnschedulable printable status of the VM.
+func getUnschedulableCondition(
+	vm *kubevirtv1.VirtualMachine, vmInstance *kubevirtv1.VirtualMachineInstance,
+) (time.Time, string, bool) {
+	for _, condition := range vmInstance.Status.Conditions {
+		if condition.Type == kubevirtv1.VirtualMachineInstanceConditionType(corev1.PodScheduled) &&
+			condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+			return condition.LastTransitionTime.Time, condition.Message, !condition.LastTransitionTime.IsZero()
+		}
+	}
+
+	if vm.Status.PrintableStatus == kubevirtv1.VirtualMachineStatusUnschedulable && !vmInstance.CreationTimestamp.IsZero() {
+		return vmInstance.CreationTimestamp.Time, string(vm.Status.PrintableStatus), true
+	}
+
+	return time.Time{}, "", false
+}
+
+// getVMErrorReason returns the reason of the failure of a VM, which is a creation error until the VM joined the workload cluster.
+func getVMErrorReason(harvesterMachine *infrav1.HarvesterMachine) capierrors.MachineStatusError {
+	if harvesterMachine.Spec.ProviderID == "" {
+		return capierrors.CreateMachineError
+	}
+
+	return capierrors.UpdateMachineError
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	current "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/containernetworking/plugins/plugins/ipam/host-local/backend/allocator"
+	lbv1beta1 "github.com/harvester/harvester-load-balancer/pkg/apis/loadbalancer.harvesterhci.io/v1beta1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
+	"sigs.k8s.io/cluster-api/util"
+
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
+	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
+)
+
+const cloudInitNetworkDataKey = "networkData"
+
+// reconcileStaticAddresses allocates a static address to each interface of the VM which does not use DHCP,
+// and records it in the status of the HarvesterMachine.
+// It returns false if some addresses are not allocated yet, because their IPAddressClaim is not fulfilled.
+func reconcileStaticAddresses(hvScope *Scope) (bool, error) {
+	allocated := true
+
+	for i, network := range hvScope.HarvesterMachine.Spec.Networks {
+		if network.IPAM == nil || network.IPAM.Type == infrav1.NetworkIPAMTypeDHCP {
+			continue
+		}
+
+		interfaceName := getInterfaceName(i)
+		if getStaticAddress(hvScope.HarvesterMachine, interfaceName) != nil {
+			continue
+		}
+
+		var (
+			address *infrav1.StaticAddress
+			err     error
+		)
+
+		switch network.IPAM.Type {
+		case infrav1.NetworkIPAMTypeIPPool:
+			if network.IPAM.IPPoolRef == nil {
+				return false, fmt.Errorf("no IP Pool referenced by network %d", i)
+			}
+
+			address, err = allocateStaticAddressFromIPPool(hvScope, interfaceName, network.IPAM.IPPoolRef.Name)
+		case infrav1.NetworkIPAMTypeIPAddressClaim:
+			if network.IPAM.PoolRef == nil {
+				return false, fmt.Errorf("no IPAM pool referenced by network %d", i)
+			}
+
+			address, err = claimStaticAddress(hvScope, interfaceName, network.IPAM.PoolRef)
+		default:
+			return false, fmt.Errorf("unsupported IPAM type %s for network %d", network.IPAM.Type, i)
+		}
+
+		if err != nil {
+			return false, errors.Wrapf(err, "unable to allocate a static address to interface %s", interfaceName)
+		}
+
+		if address == nil {
+			allocated = false
+
+			continue
+		}
+
+		hvScope.HarvesterMachine.Status.StaticAddresses = append(hvScope.HarvesterMachine.Status.StaticAddresses, *address)
+	}
+
+	return allocated, nil
+}
+
+// getStaticAddress returns the static address allocated to an interface of the VM, if any.
+func getStaticAddress(harvesterMachine *infrav1.HarvesterMachine, interfaceName string) *infrav1.StaticAddress {
+	for i := range harvesterMachine.Status.StaticAddresses {
+		if harvesterMachine.Status.StaticAddresses[i].Interface == interfaceName {
+			return &harvesterMachine.Status.StaticAddresses[i]
+		}
+	}
+
+	return nil
+}
+
+// getStaticAddressApplicant returns the ID under which the address of an interface is allocated in an IP Pool.
+func getStaticAddressApplicant(hvScope *Scope, interfaceName string) string {
+	return hvScope.HarvesterCluster.Spec.TargetNamespace + "/" + hvScope.HarvesterMachine.Name + "-" + interfaceName
+}
+
+// allocateStaticAddressFromIPPool allocates an address from an IP Pool in Harvester, or returns the one
+// already allocated to the interface if the status of the HarvesterMachine could not be saved after a previous allocation.
+// The allocation is retried on the latest version of the IP Pool when it conflicts with another one.
+func allocateStaticAddressFromIPPool(hvScope *Scope, interfaceName string, poolName string) (*infrav1.StaticAddress, error) {
+	applicant := getStaticAddressApplicant(hvScope, interfaceName)
+
+	var (
+		ipConfig  *current.IPConfig
+		allocated bool
+	)
+
+	err := updateIPPool(hvScope.Ctx, hvScope.HarvesterClient, poolName, func(pool *lbv1beta1.IPPool) (bool, error) {
+		ipConfig = nil
+		allocated = false
+
+		rangeSet, err := getIPPoolRangeSet(pool)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid ranges in IP Pool %s", poolName)
+		}
+
+		store := locutil.NewStore(pool)
+
+		if ips := store.GetByID(applicant, interfaceName); len(ips) > 0 {
+			r, err := rangeSet.RangeFor(ips[0])
+			if err != nil {
+				return false, err
+			}
+
+			ipConfig = &current.IPConfig{
+				Address: net.IPNet{IP: ips[0], Mask: r.Subnet.Mask},
+				Gateway: r.Gateway,
+			}
+
+			return false, nil
+		}
+
+		if pool.Status.Available == 0 {
+			return false, fmt.Errorf("IP Pool %s does not have available addresses", poolName)
+		}
+
+		ipConfig, err = allocator.NewIPAllocator(&rangeSet, store, 0).Get(applicant, interfaceName, nil)
+		if err != nil {
+			return false, err
+		}
+
+		allocated = true
+
+		return true, nil
+	})
+	if err != nil {
+		recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionAllocate,
+			"an address for interface "+interfaceName+" from IP Pool "+poolName, err)
+
+		return nil, errors.Wrapf(err, "could not allocate an address from IP Pool %s", poolName)
+	}
+
+	if allocated {
+		recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionAllocate,
+			"address "+ipConfig.Address.IP.String()+" for interface "+interfaceName+" from IP Pool "+poolName, nil)
+	}
+
+	prefix, _ := ipConfig.Address.Mask.Size()
+
+	address := &infrav1.StaticAddress{
+		Interface: interfaceName,
+		Address:   ipConfig.Address.IP.String(),
+		Prefix:    int32(prefix),
+		IPPool:    poolName,
+	}
+
+	if ipConfig.Gateway != nil {
+		address.Gateway = ipConfig.Gateway.String()
+	}
+
+	return address, nil
+}
+
+// claimStaticAddress creates an IPAddressClaim for an interface against a pool of a Cluster API IPAM provider.
+// It returns nil until the claim is fulfilled by the IPAM provider.
+func claimStaticAddress(hvScope *Scope, interfaceName string, poolRef *corev1.TypedLocalObjectReference) (*infrav1.StaticAddress, error) {
+	claim := &ipamv1.IPAddressClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hvScope.HarvesterMachine.Name + "-" + interfaceName,
+			Namespace: hvScope.HarvesterMachine.Namespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrPatch(hvScope.Ctx, hvScope.ReconcilerClient, claim, func() error {
+		if claim.Labels == nil {
+			claim.Labels = make(map[string]string)
+		}
+
+		claim.Labels[clusterv1.ClusterNameLabel] = hvScope.Cluster.Name
+		claim.OwnerReferences = util.EnsureOwnerRef(claim.OwnerReferences, metav1.OwnerReference{
+			APIVersion: infrav1.GroupVersion.String(),
+			Kind:       "HarvesterMachine",
+			Name:       hvScope.HarvesterMachine.Name,
+			UID:        hvScope.HarvesterMachine.UID,
+			Controller: locutil.NewTrue(),
+		})
+		claim.Spec.PoolRef = *poolRef
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create IPAddressClaim %s", claim.Name)
+	}
+
+	if claim.Status.AddressRef.Name == "" {
+		hvScope.Logger.Info("Waiting for IPAddressClaim to be fulfilled", "claim", claim.Name)
+
+		return nil, nil
+	}
+
+	ipAddress := &ipamv1.IPAddress{}
+
+	err = hvScope.ReconcilerClient.Get(hvScope.Ctx,
+		types.NamespacedName{Namespace: claim.Namespace, Name: claim.Status.AddressRef.Name}, ipAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get IPAddress %s", claim.Status.AddressRef.Name)
+	}
+
+	return &infrav1.StaticAddress{
+		Interface:      interfaceName,
+		Address:        ipAddress.Spec.Address,
+		Prefix:         int32(ipAddress.Spec.Prefix),
+		Gateway:        ipAddress.Spec.Gateway,
+		IPAddressClaim: claim.Name,
+	}, nil
+}
+
+// releaseStaticAddresses releases the static addresses allocated to the interfaces of the VM.
+func releaseStaticAddresses(hvScope *Scope) error {
+	for _, address := range hvScope.HarvesterMachine.Status.StaticAddresses {
+		switch {
+		case address.IPPool != "":
+			if err := releaseStaticAddressFromIPPool(hvScope, address); err != nil {
+				return err
+			}
+		case address.IPAddressClaim != "":
+			claim := &ipamv1.IPAddressClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      address.IPAddressClaim,
+					Namespace: hvScope.HarvesterMachine.Namespace,
+				},
+			}
+
+			if err := hvScope.ReconcilerClient.Delete(hvScope.Ctx, claim); err != nil && !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "unable to delete IPAddressClaim %s", address.IPAddressClaim)
+			}
+		}
+	}
+
+	hvScope.HarvesterMachine.Status.StaticAddresses = nil
+
+	return nil
+}
+
+// releaseStaticAddressFromIPPool releases the address of an interface from its IP Pool in Harvester.
+func releaseStaticAddressFromIPPool(hvScope *Scope, address infrav1.StaticAddress) error {
+	applicant := getStaticAddressApplicant(hvScope, address.Interface)
+
+	released := false
+
+	err := updateIPPool(hvScope.Ctx, hvScope.HarvesterClient, address.IPPool, func(pool *lbv1beta1.IPPool) (bool, error) {
+		store := locutil.NewStore(pool)
+		if len(store.GetByID(applicant, address.Interface)) == 0 {
+			return false, nil
+		}
+
+		released = true
+
+		return true, store.ReleaseByID(applicant, address.Interface)
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionRelease,
+			"address "+address.Address+" from IP Pool "+address.IPPool, err)
+
+		return errors.Wrapf(err, "unable to release address %s in IP Pool %s", address.Address, address.IPPool)
+	}
+
+	if err == nil && released {
+		recordHarvesterEvent(hvScope.EventRecorder, hvScope.HarvesterMachine, harvesterActionRelease,
+			"address "+address.Address+" from IP Pool "+address.IPPool, nil)
+	}
+
+	return nil
+}
+
+// getNetworkData renders the cloud-init network config of the VM if some of its interfaces have a static address.
+// As the config matches the interfaces by MAC address, a MAC address is generated for the interfaces which do not have one.
+// It returns nil if all interfaces use DHCP.
+func getNetworkData(harvesterMachine *infrav1.HarvesterMachine, interfaces []kubevirtv1.Interface) ([]byte, error) {
+	if len(harvesterMachine.Status.StaticAddresses) == 0 {
+		return nil, nil
+	}
+
+	primaryInterface := getInterfaceName(getPrimaryNetworkIndex(harvesterMachine.Spec.Networks))
+	networkDataInterfaces := make([]locutil.NetworkDataInterface, 0, len(interfaces))
+
+	for i := range interfaces {
+		if interfaces[i].MacAddress == "" {
+			macAddress, err := locutil.RandomMACAddress()
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to generate a MAC address for interface %s", interfaces[i].Name)
+			}
+
+			interfaces[i].MacAddress = macAddress
+		}
+
+		networkDataInterface := locutil.NetworkDataInterface{
+			Name:       interfaces[i].Name,
+			MACAddress: interfaces[i].MacAddress,
+		}
+
+		if address := getStaticAddress(harvesterMachine, interfaces[i].Name); address != nil {
+			networkDataInterface.Addresses = []string{address.Address + "/" + strconv.Itoa(int(address.Prefix))}
+
+			if interfaces[i].Name == primaryInterface {
+				networkDataInterface.Gateway = address.Gateway
+			}
+
+			if ipam := harvesterMachine.Spec.Networks[i].IPAM; ipam != nil {
+				networkDataInterface.Nameservers = ipam.Nameservers
+			}
+		}
+
+		networkDataInterfaces = append(networkDataInterfaces, networkDataInterface)
+	}
+
+	return locutil.RenderNetworkData(networkDataInterfaces)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	lbv1beta1 "github.com/harvester/harvester-load-balancer/pkg/apis/loadbalancer.harvesterhci.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
+
+	lbclient "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/clientset/versioned"
+	locmetrics "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/metrics"
+)
+
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddressclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=ipam.cluster.x-k8s.io,resources=ipaddresses,verbs=get;list;watch
+
+// ipAddressToClaimOwnerMapFunc returns a handler.MapFunc that maps an IPAddress to the object of the given kind
+// controlling the IPAddressClaim it fulfills, so that the object is reconciled as soon as its address is allocated.
+func ipAddressToClaimOwnerMapFunc(c client.Client, gvk schema.GroupVersionKind) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		ipAddress, ok := o.(*ipamv1.IPAddress)
+		if !ok || ipAddress.Spec.ClaimRef.Name == "" {
+			return nil
+		}
+
+		claim := &ipamv1.IPAddressClaim{}
+
+		err := c.Get(ctx, types.NamespacedName{Namespace: ipAddress.Namespace, Name: ipAddress.Spec.ClaimRef.Name}, claim)
+		if err != nil {
+			return nil
+		}
+
+		owner := metav1.GetControllerOf(claim)
+		if owner == nil || owner.Kind != gvk.Kind {
+			return nil
+		}
+
+		ownerGV, err := schema.ParseGroupVersion(owner.APIVersion)
+		if err != nil || ownerGV.Group != gvk.Group {
+			return nil
+		}
+
+		return []reconcile.Request{
+			{
+				NamespacedName: types.NamespacedName{
+					Namespace: claim.Namespace,
+					Name:      owner.Name,
+				},
+			},
+		}
+	}
+}
+
+// updateIPPool applies a change to an IP Pool in Harvester, such as an allocation, and updates it if the change function
+// reports that the IP Pool was modified. IP Pools can be shared by several clusters and controllers, so when the update
+// conflicts with another one, the IP Pool is read again and the change applied again to its latest version.
+func updateIPPool(ctx context.Context, hvClient lbclient.Interface, name string, change func(*lbv1beta1.IPPool) (bool, error)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ipPool, err := hvClient.LoadbalancerV1beta1().IPPools().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		changed, err := change(ipPool)
+		if err != nil || !changed {
+			locmetrics.IPPoolAvailableAddresses.WithLabelValues(name).Set(float64(ipPool.Status.Available))
+
+			return err
+		}
+
+		updatedIPPool, err := hvClient.LoadbalancerV1beta1().IPPools().Update(ctx, ipPool, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+
+		locmetrics.IPPoolAvailableAddresses.WithLabelValues(name).Set(float64(updatedIPPool.Status.Available))
+
+		return nil
+	})
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -30,16 +32,25 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ipamv1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1alpha1"
 
 	infrastructurev1alpha1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha1"
+	infrastructurev1alpha2 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
 	"github.com/rancher-sandbox/cluster-api-provider-harvester/controllers"
+	locmetrics "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/metrics"
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/remote"
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/tracing"
+	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
 )
 
 const (
-	webhookPort = 9443
+	webhookPort            = 9443
+	tracingShutdownTimeout = 10 * time.Second
+	defaultTracingSampling = 1.0
 )
 
 var (
@@ -52,7 +63,9 @@ func init() {
 	//+kubebuilder:scaffold:scheme
 
 	utilruntime.Must(infrastructurev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(infrastructurev1alpha2.AddToScheme(scheme))
 	utilruntime.Must(clusterv1.AddToScheme(scheme))
+	utilruntime.Must(ipamv1.AddToScheme(scheme))
 }
 
 func main() {
@@ -62,11 +75,32 @@ func main() {
 
 	var probeAddr string
 
+	var machineProvisioningTimeout time.Duration
+
+	var tracingOpts tracing.Options
+
+	var harvesterClientQPS float64
+
+	var harvesterClientBurst int
+
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":9440", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&machineProvisioningTimeout, "machine-provisioning-timeout", controllers.DefaultMachineProvisioningTimeout,
+		"The time after which a HarvesterMachine whose VM did not join the workload cluster is marked as failed. "+
+			"Zero disables the timeout.")
+	flag.StringVar(&tracingOpts.Endpoint, "otlp-endpoint", "",
+		"The address of the OTLP gRPC collector to which the traces of the reconciliations are exported. "+
+			"Tracing is disabled when empty.")
+	flag.BoolVar(&tracingOpts.Insecure, "otlp-insecure", false, "Disable TLS on the connection to the OTLP collector.")
+	flag.Float64Var(&tracingOpts.SamplingRatio, "tracing-sampling-ratio", defaultTracingSampling,
+		"The ratio of the reconciliations which are traced, between 0 and 1.")
+	flag.Float64Var(&harvesterClientQPS, "harvester-client-qps", locutil.DefaultHarvesterClientQPS,
+		"The maximum rate of the requests to each Harvester API, shared by all the clusters and machines using the same identity secret.")
+	flag.IntVar(&harvesterClientBurst, "harvester-client-burst", locutil.DefaultHarvesterClientBurst,
+		"The maximum burst of the requests to each Harvester API, above the rate set by --harvester-client-qps.")
 
 	opts := zap.Options{
 		Development: true,
@@ -93,24 +127,66 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Report the phases of the Machines backed by a HarvesterMachine along with the controller-runtime metrics
+	metrics.Registry.MustRegister(locmetrics.NewMachinePhaseCollector(mgr.GetClient()))
+
 	// Setup the context to be used for the controllers and manager
 	ctx := ctrl.SetupSignalHandler()
 
+	shutdownTracing, err := tracing.Setup(ctx, tracingOpts)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
+	// The clients of the Harvester APIs, and the informers watching the resources created in Harvester, are shared by both controllers
+	clientCache := locutil.NewClientCache(float32(harvesterClientQPS), harvesterClientBurst)
+	tracker := remote.NewTracker()
+
 	if err = (&controllers.HarvesterMachineReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		Recorder:            mgr.GetEventRecorderFor("harvestermachine-controller"),
+		ProvisioningTimeout: machineProvisioningTimeout,
+		ClientCache:         clientCache,
+		Tracker:             tracker,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HarvesterMachine")
 		os.Exit(1)
 	}
 
 	if err = (&controllers.HarvesterClusterReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		Recorder:    mgr.GetEventRecorderFor("harvestercluster-controller"),
+		ClientCache: clientCache,
+		Tracker:     tracker,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HarvesterCluster")
 		os.Exit(1)
 	}
+
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&infrastructurev1alpha2.HarvesterCluster{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "HarvesterCluster")
+			os.Exit(1)
+		}
+
+		if err = (&infrastructurev1alpha2.HarvesterClusterTemplateWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "HarvesterClusterTemplate")
+			os.Exit(1)
+		}
+
+		if err = (&infrastructurev1alpha2.HarvesterMachine{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "HarvesterMachine")
+			os.Exit(1)
+		}
+
+		if err = (&infrastructurev1alpha2.HarvesterMachineTemplateWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "HarvesterMachineTemplate")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -125,8 +201,18 @@ func main() {
 
 	setupLog.Info("starting manager")
 
-	if err := mgr.Start(ctx); err != nil {
-		setupLog.Error(err, "problem running manager")
+	startErr := mgr.Start(ctx)
+
+	// Export the spans which are still buffered, the context of the manager is already cancelled at this point
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		setupLog.Error(err, "unable to flush the traces")
+	}
+
+	cancel()
+
+	if startErr != nil {
+		setupLog.Error(startErr, "problem running manager")
 		os.Exit(1)
 	}
 }
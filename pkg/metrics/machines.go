@@ -0,0 +1,122 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	harvesterMachineKind  = "HarvesterMachine"
+	machineListingTimeout = 10 * time.Second
+)
+
+// machinePhases are the phases of a Machine, reported for each cluster even when no Machine is in them.
+var machinePhases = []clusterv1.MachinePhase{
+	clusterv1.MachinePhasePending,
+	clusterv1.MachinePhaseProvisioning,
+	clusterv1.MachinePhaseProvisioned,
+	clusterv1.MachinePhaseRunning,
+	clusterv1.MachinePhaseDeleting,
+	clusterv1.MachinePhaseDeleted,
+	clusterv1.MachinePhaseFailed,
+	clusterv1.MachinePhaseUnknown,
+}
+
+// machinePhaseCollector reports the number of Machines backed by a HarvesterMachine in each phase, for each cluster.
+// The Machines are listed when the metrics are scraped, so that deleted clusters do not leave stale series.
+type machinePhaseCollector struct {
+	reader client.Reader
+	desc   *prometheus.Desc
+}
+
+// machinePhaseKey identifies the series of the Machines of a cluster in a phase.
+type machinePhaseKey struct {
+	namespace string
+	cluster   string
+	phase     string
+}
+
+// NewMachinePhaseCollector returns a collector of the phases of the Machines backed by a HarvesterMachine.
+// The reader is usually the cached client of the manager.
+func NewMachinePhaseCollector(reader client.Reader) prometheus.Collector {
+	return &machinePhaseCollector{
+		reader: reader,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "cluster_machines"),
+			"Number of Machines of a cluster backed by a HarvesterMachine, by phase.",
+			[]string{"namespace", "cluster", "phase"}, nil,
+		),
+	}
+}
+
+func (c *machinePhaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *machinePhaseCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), machineListingTimeout)
+	defer cancel()
+
+	machines := &clusterv1.MachineList{}
+	if err := c.reader.List(ctx, machines); err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+
+		return
+	}
+
+	for key, count := range countMachinesByPhase(machines.Items) {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), key.namespace, key.cluster, key.phase)
+	}
+}
+
+// countMachinesByPhase counts the Machines backed by a HarvesterMachine in each phase, for each cluster.
+func countMachinesByPhase(machines []clusterv1.Machine) map[machinePhaseKey]int {
+	counts := map[machinePhaseKey]int{}
+	clusters := map[machinePhaseKey]bool{}
+
+	for i := range machines {
+		machine := &machines[i]
+		if machine.Spec.InfrastructureRef.Kind != harvesterMachineKind {
+			continue
+		}
+
+		cluster := machinePhaseKey{namespace: machine.Namespace, cluster: machine.Spec.ClusterName}
+		if !clusters[cluster] {
+			clusters[cluster] = true
+
+			for _, phase := range machinePhases {
+				counts[machinePhaseKey{namespace: cluster.namespace, cluster: cluster.cluster, phase: string(phase)}] = 0
+			}
+		}
+
+		phase := machine.Status.Phase
+		if phase == "" {
+			phase = string(clusterv1.MachinePhaseUnknown)
+		}
+
+		counts[machinePhaseKey{namespace: cluster.namespace, cluster: cluster.cluster, phase: phase}]++
+	}
+
+	return counts
+}
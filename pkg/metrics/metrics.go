@@ -0,0 +1,76 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the Prometheus metrics of the provider. They are registered in the controller-runtime registry,
+// and exposed on the metrics endpoint of the manager with the controller-runtime metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const metricsNamespace = "caphv"
+
+var (
+	// MachineProvisioningDuration is the time from the creation of the VM of a HarvesterMachine to its provider ID being set.
+	MachineProvisioningDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "machine_provisioning_duration_seconds",
+		Help:      "Time from the creation of the VM of a HarvesterMachine in Harvester to its Node registering with a provider ID.",
+		Buckets:   prometheus.ExponentialBuckets(30, 2, 8), //nolint:mnd
+	})
+
+	// LoadBalancerIPAllocationDuration is the time taken to allocate the address of the control plane from an IP Pool,
+	// retries on conflicts included.
+	LoadBalancerIPAllocationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "loadbalancer_ip_allocation_duration_seconds",
+		Help:      "Time taken to allocate the control plane address of a HarvesterCluster from an IP Pool in Harvester.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"ippool"})
+
+	// HarvesterAPIRequests counts the requests made to the Harvester API.
+	HarvesterAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "harvester_api_requests_total",
+		Help:      "Number of requests made to the Harvester API, by resource, verb and status code.",
+	}, []string{"resource", "verb", "code"})
+
+	// HarvesterAPIRequestErrors counts the requests to the Harvester API which failed.
+	HarvesterAPIRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "harvester_api_request_errors_total",
+		Help:      "Number of requests to the Harvester API which failed, by resource and verb. NotFound and Conflict responses are not counted.",
+	}, []string{"resource", "verb"})
+
+	// IPPoolAvailableAddresses is the number of addresses which can still be allocated from an IP Pool in Harvester.
+	IPPoolAvailableAddresses = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "ippool_available_addresses",
+		Help:      "Number of available addresses in an IP Pool in Harvester, as last seen by the provider.",
+	}, []string{"ippool"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		MachineProvisioningDuration,
+		LoadBalancerIPAllocationDuration,
+		HarvesterAPIRequests,
+		HarvesterAPIRequestErrors,
+		IPPoolAvailableAddresses,
+	)
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+var _ = Describe("Get the resource and verb of Harvester API requests", func() {
+	DescribeTable("Should parse the path and method of the request",
+		func(method string, url string, resource string, verb string) {
+			req := httptest.NewRequest(method, url, nil)
+
+			gotResource, gotVerb := GetRequestResourceAndVerb(req)
+			Expect(gotResource).To(Equal(resource))
+			Expect(gotVerb).To(Equal(verb))
+		},
+		Entry("a namespaced resource", http.MethodGet,
+			"https://harvester/apis/kubevirt.io/v1/namespaces/default/virtualmachines/test", "virtualmachines", "get"),
+		Entry("a list", http.MethodGet,
+			"https://harvester/apis/harvesterhci.io/v1beta1/namespaces/default/virtualmachineimages", "virtualmachineimages", "list"),
+		Entry("a watch", http.MethodGet,
+			"https://harvester/api/v1/namespaces/default/secrets?watch=true", "secrets", "watch"),
+		Entry("a cluster-scoped resource", http.MethodPut,
+			"https://harvester/apis/loadbalancer.harvesterhci.io/v1beta1/ippools/pool", "ippools", "update"),
+		Entry("a namespace", http.MethodPost, "https://harvester/api/v1/namespaces", "namespaces", "create"),
+		Entry("a subresource", http.MethodPatch,
+			"https://harvester/api/v1/namespaces/default/services/lb/status", "services/status", "patch"),
+		Entry("a deletion", http.MethodDelete,
+			"https://harvester/api/v1/namespaces/default/persistentvolumeclaims/disk-0", "persistentvolumeclaims", "delete"),
+		Entry("a discovery request", http.MethodGet, "https://harvester/version", "unknown", "get"),
+	)
+})
+
+var _ = Describe("Count the requests made to the Harvester API", func() {
+	It("Should count the failed requests as errors, except NotFound", func() {
+		errorsBefore := getCounterValue(HarvesterAPIRequestErrors.WithLabelValues("loadbalancers", "create"))
+		notFoundBefore := getCounterValue(HarvesterAPIRequests.WithLabelValues("loadbalancers", "get", "404"))
+
+		statusCode := http.StatusNotFound
+		rt := &instrumentedRoundTripper{next: roundTripperFunc(func(_ *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: statusCode}, nil
+		})}
+
+		_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet,
+			"https://harvester/apis/loadbalancer.harvesterhci.io/v1beta1/namespaces/default/loadbalancers/lb", nil))
+		Expect(err).NotTo(HaveOccurred())
+
+		statusCode = http.StatusForbidden
+		_, err = rt.RoundTrip(httptest.NewRequest(http.MethodPost,
+			"https://harvester/apis/loadbalancer.harvesterhci.io/v1beta1/namespaces/default/loadbalancers", nil))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(getCounterValue(HarvesterAPIRequests.WithLabelValues("loadbalancers", "get", "404"))).To(Equal(notFoundBefore + 1))
+		Expect(getCounterValue(HarvesterAPIRequestErrors.WithLabelValues("loadbalancers", "create"))).To(Equal(errorsBefore + 1))
+	})
+
+	It("Should count the requests without response as errors", func() {
+		rt := &instrumentedRoundTripper{next: roundTripperFunc(func(_ *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})}
+
+		errorsBefore := getCounterValue(HarvesterAPIRequestErrors.WithLabelValues("virtualmachines", "list"))
+
+		_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "https://harvester/apis/kubevirt.io/v1/virtualmachines", nil))
+		Expect(err).To(HaveOccurred())
+		Expect(getCounterValue(HarvesterAPIRequests.WithLabelValues("virtualmachines", "list", "error"))).To(BeNumerically(">=", 1))
+		Expect(getCounterValue(HarvesterAPIRequestErrors.WithLabelValues("virtualmachines", "list"))).To(Equal(errorsBefore + 1))
+	})
+})
+
+var _ = Describe("Count the Machines of each cluster by phase", func() {
+	It("Should only count the Machines backed by a HarvesterMachine", func() {
+		newMachine := func(cluster string, kind string, phase clusterv1.MachinePhase) clusterv1.Machine {
+			return clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+				Spec: clusterv1.MachineSpec{
+					ClusterName:       cluster,
+					InfrastructureRef: corev1.ObjectReference{Kind: kind},
+				},
+				Status: clusterv1.MachineStatus{Phase: string(phase)},
+			}
+		}
+
+		counts := countMachinesByPhase([]clusterv1.Machine{
+			newMachine("test", harvesterMachineKind, clusterv1.MachinePhaseRunning),
+			newMachine("test", harvesterMachineKind, clusterv1.MachinePhaseRunning),
+			newMachine("test", harvesterMachineKind, clusterv1.MachinePhaseProvisioning),
+			newMachine("test", harvesterMachineKind, ""),
+			newMachine("other", "DockerMachine", clusterv1.MachinePhaseRunning),
+		})
+
+		Expect(counts).To(HaveLen(len(machinePhases)))
+		Expect(counts[machinePhaseKey{namespace: "default", cluster: "test", phase: "Running"}]).To(Equal(2))
+		Expect(counts[machinePhaseKey{namespace: "default", cluster: "test", phase: "Provisioning"}]).To(Equal(1))
+		Expect(counts[machinePhaseKey{namespace: "default", cluster: "test", phase: "Unknown"}]).To(Equal(1))
+		Expect(counts[machinePhaseKey{namespace: "default", cluster: "test", phase: "Failed"}]).To(Equal(0))
+	})
+})
+
+// getCounterValue returns the current value of a counter.
+func getCounterValue(counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	Expect(counter.Write(metric)).To(Succeed())
+
+	return metric.GetCounter().GetValue()
+}
+
+// roundTripperFunc is an http.RoundTripper implemented by a function.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
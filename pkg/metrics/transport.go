@@ -0,0 +1,136 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+const unknownLabelValue = "unknown"
+
+// InstrumentRESTConfig counts the requests made to the Harvester API by the clients built from a REST config.
+func InstrumentRESTConfig(config *rest.Config) {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &instrumentedRoundTripper{next: rt}
+	})
+}
+
+// instrumentedRoundTripper records the requests it sends in HarvesterAPIRequests and HarvesterAPIRequestErrors.
+type instrumentedRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (t *instrumentedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+
+	resource, verb := GetRequestResourceAndVerb(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+
+	HarvesterAPIRequests.WithLabelValues(resource, verb, code).Inc()
+
+	if IsRequestError(resp, err) {
+		HarvesterAPIRequestErrors.WithLabelValues(resource, verb).Inc()
+	}
+
+	return resp, err
+}
+
+// IsRequestError returns true when a request to the Harvester API failed. NotFound and Conflict responses are part of the normal
+// reconciliation, as the controllers check the existence of resources and retry the updates of shared IP Pools.
+func IsRequestError(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusConflict:
+		return false
+	}
+
+	return resp.StatusCode >= http.StatusBadRequest
+}
+
+// GetRequestResourceAndVerb returns the resource and the Kubernetes verb of a request to the API of Harvester,
+// from its path such as /apis/<group>/<version>/namespaces/<namespace>/<resource>/<name>/<subresource>.
+func GetRequestResourceAndVerb(req *http.Request) (string, string) {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		segments = segments[2:]
+	case len(segments) >= 3 && segments[0] == "apis":
+		segments = segments[3:]
+	default:
+		return unknownLabelValue, strings.ToLower(req.Method)
+	}
+
+	// Namespaced resources, the namespaces themselves are a cluster-scoped resource
+	if len(segments) >= 3 && segments[0] == "namespaces" {
+		segments = segments[2:]
+	}
+
+	if len(segments) == 0 {
+		return unknownLabelValue, strings.ToLower(req.Method)
+	}
+
+	resource := segments[0]
+	if len(segments) >= 3 {
+		resource += "/" + segments[2]
+	}
+
+	hasName := len(segments) >= 2
+
+	return resource, getRequestVerb(req, hasName)
+}
+
+// getRequestVerb returns the Kubernetes verb of a request from its method.
+func getRequestVerb(req *http.Request, hasName bool) string {
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("watch") == "true" {
+			return "watch"
+		}
+
+		if hasName {
+			return "get"
+		}
+
+		return "list"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if hasName {
+			return "delete"
+		}
+
+		return "deletecollection"
+	}
+
+	return strings.ToLower(req.Method)
+}
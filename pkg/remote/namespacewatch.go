@@ -0,0 +1,235 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"sync"
+
+	lbv1beta1 "github.com/harvester/harvester-load-balancer/pkg/apis/loadbalancer.harvesterhci.io/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
+	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
+)
+
+// namespaceWatch holds the informers of a target namespace in a Harvester cluster, and the objects registered in them.
+type namespaceWatch struct {
+	clients   *locutil.HarvesterClients
+	namespace string
+
+	machineEvents *eventSource
+	clusterEvents *eventSource
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	informers []cache.SharedIndexInformer
+
+	lock sync.RWMutex
+	// machines are the HarvesterMachines owning the VMs of the namespace, by name of VM
+	machines map[string]types.NamespacedName
+	// clusters are the HarvesterClusters owning the LoadBalancers and Services of the namespace, by name of resource
+	clusters map[string]types.NamespacedName
+}
+
+// newNamespaceWatch creates the informers of a namespace, without starting them.
+func newNamespaceWatch(clients *locutil.HarvesterClients, namespace string,
+	machineEvents *eventSource, clusterEvents *eventSource,
+) *namespaceWatch {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &namespaceWatch{
+		clients:       clients,
+		namespace:     namespace,
+		machineEvents: machineEvents,
+		clusterEvents: clusterEvents,
+		ctx:           ctx,
+		cancel:        cancel,
+		machines:      map[string]types.NamespacedName{},
+		clusters:      map[string]types.NamespacedName{},
+	}
+
+	vms := clients.Harvester.KubevirtV1().VirtualMachines(namespace)
+	vmis := clients.Harvester.KubevirtV1().VirtualMachineInstances(namespace)
+	loadBalancers := clients.Harvester.LoadbalancerV1beta1().LoadBalancers(namespace)
+	services := clients.Harvester.CoreV1().Services(namespace)
+
+	w.informers = []cache.SharedIndexInformer{
+		w.newInformer(&cache.ListWatch{
+			ListFunc:  func(opts metav1.ListOptions) (runtime.Object, error) { return vms.List(ctx, opts) },
+			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) { return vms.Watch(ctx, opts) },
+		}, &kubevirtv1.VirtualMachine{}, w.notifyMachine),
+		w.newInformer(&cache.ListWatch{
+			ListFunc:  func(opts metav1.ListOptions) (runtime.Object, error) { return vmis.List(ctx, opts) },
+			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) { return vmis.Watch(ctx, opts) },
+		}, &kubevirtv1.VirtualMachineInstance{}, w.notifyMachine),
+		w.newInformer(&cache.ListWatch{
+			ListFunc:  func(opts metav1.ListOptions) (runtime.Object, error) { return loadBalancers.List(ctx, opts) },
+			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) { return loadBalancers.Watch(ctx, opts) },
+		}, &lbv1beta1.LoadBalancer{}, w.notifyCluster),
+		w.newInformer(&cache.ListWatch{
+			ListFunc:  func(opts metav1.ListOptions) (runtime.Object, error) { return services.List(ctx, opts) },
+			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) { return services.Watch(ctx, opts) },
+		}, &corev1.Service{}, w.notifyCluster),
+	}
+
+	return w
+}
+
+// newInformer creates an informer calling notify with the name of each object which is added, updated or deleted.
+func (w *namespaceWatch) newInformer(lw cache.ListerWatcher, object runtime.Object, notify func(string)) cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(lw, object, resyncPeriod, cache.Indexers{})
+
+	notifyObject := func(obj interface{}) {
+		// Deleted objects can be wrapped in a DeletedFinalStateUnknown, which this key function handles
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return
+		}
+
+		if _, name, err := cache.SplitMetaNamespaceKey(key); err == nil {
+			notify(name)
+		}
+	}
+
+	// Adding a handler only fails once the informer is stopped
+	_, _ = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    notifyObject,
+		UpdateFunc: func(_, obj interface{}) { notifyObject(obj) },
+		DeleteFunc: notifyObject,
+	})
+
+	return informer
+}
+
+func (w *namespaceWatch) start() {
+	for _, informer := range w.informers {
+		go informer.Run(w.ctx.Done())
+	}
+}
+
+func (w *namespaceWatch) stop() {
+	w.cancel()
+}
+
+func (w *namespaceWatch) hasSynced() bool {
+	for _, informer := range w.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// notifyMachine sends an event for the HarvesterMachine owning a VM, if it is registered.
+func (w *namespaceWatch) notifyMachine(vmName string) {
+	w.lock.RLock()
+	machine, ok := w.machines[vmName]
+	w.lock.RUnlock()
+
+	if ok {
+		w.machineEvents.send(&infrav1.HarvesterMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: machine.Name, Namespace: machine.Namespace},
+		})
+	}
+}
+
+// notifyCluster sends an event for the HarvesterCluster owning a LoadBalancer or a Service, if it is registered.
+func (w *namespaceWatch) notifyCluster(name string) {
+	w.lock.RLock()
+	cluster, ok := w.clusters[name]
+	w.lock.RUnlock()
+
+	if ok {
+		w.clusterEvents.send(&infrav1.HarvesterCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: cluster.Name, Namespace: cluster.Namespace},
+		})
+	}
+}
+
+func (w *namespaceWatch) registerMachine(vmName string, machine types.NamespacedName) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.machines[vmName] = machine
+}
+
+func (w *namespaceWatch) unregisterMachine(vmName string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	delete(w.machines, vmName)
+}
+
+// registerCluster registers the resources of a HarvesterCluster, replacing the ones it registered before.
+func (w *namespaceWatch) registerCluster(names []string, cluster types.NamespacedName) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.deleteCluster(cluster)
+
+	for _, name := range names {
+		w.clusters[name] = cluster
+	}
+}
+
+func (w *namespaceWatch) unregisterCluster(cluster types.NamespacedName) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.deleteCluster(cluster)
+}
+
+// deleteCluster removes the resources registered by a HarvesterCluster. The lock must be held.
+func (w *namespaceWatch) deleteCluster(cluster types.NamespacedName) {
+	for name, owner := range w.clusters {
+		if owner == cluster {
+			delete(w.clusters, name)
+		}
+	}
+}
+
+// copyRegistrations registers the objects of the informers which are replaced.
+func (w *namespaceWatch) copyRegistrations(previous *namespaceWatch) {
+	previous.lock.RLock()
+	defer previous.lock.RUnlock()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for vmName, machine := range previous.machines {
+		w.machines[vmName] = machine
+	}
+
+	for name, cluster := range previous.clusters {
+		w.clusters[name] = cluster
+	}
+}
+
+func (w *namespaceWatch) isUnused() bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return len(w.machines) == 0 && len(w.clusters) == 0
+}
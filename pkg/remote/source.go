@@ -0,0 +1,98 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// eventSource delivers the events of the informers to the queue of a controller. The queue is not bounded and holds each
+// object once, so the informers never wait for the controller and no event is lost when the controller is behind.
+// The events sent before the controller starts the source are kept until it does.
+type eventSource struct {
+	lock       sync.Mutex
+	ctx        context.Context
+	handler    handler.EventHandler
+	queue      workqueue.RateLimitingInterface
+	predicates []predicate.Predicate
+	// pending are the objects whose events were sent before the source was started
+	pending map[types.NamespacedName]client.Object
+}
+
+func newEventSource() *eventSource {
+	return &eventSource{pending: map[types.NamespacedName]client.Object{}}
+}
+
+// source returns the source to watch in the controller.
+func (s *eventSource) source() source.Source {
+	return source.Func(s.start)
+}
+
+func (s *eventSource) start(ctx context.Context, eventHandler handler.EventHandler, queue workqueue.RateLimitingInterface,
+	predicates ...predicate.Predicate,
+) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.ctx = ctx
+	s.handler = eventHandler
+	s.queue = queue
+	s.predicates = predicates
+
+	for key, object := range s.pending {
+		s.enqueue(object)
+		delete(s.pending, key)
+	}
+
+	return nil
+}
+
+// send sends the event of an object to the controller, without waiting for the controller to handle it.
+func (s *eventSource) send(object client.Object) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.queue == nil {
+		s.pending[client.ObjectKeyFromObject(object)] = object
+
+		return
+	}
+
+	s.enqueue(object)
+}
+
+// enqueue adds the event of an object to the queue of the controller. The lock must be held.
+func (s *eventSource) enqueue(object client.Object) {
+	evt := event.GenericEvent{Object: object}
+
+	for _, p := range s.predicates {
+		if !p.Generic(evt) {
+			return
+		}
+	}
+
+	s.handler.Generic(s.ctx, evt, s.queue)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package remote watches the resources created by the provider in Harvester, so that their changes trigger the reconciliation
+// of the HarvesterClusters and HarvesterMachines owning them instead of waiting for a requeue.
+package remote
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
+)
+
+// resyncPeriod is the period at which the informers deliver all the watched resources again,
+// as a safety net for the events which could have been missed.
+const resyncPeriod = 10 * time.Minute
+
+// Tracker runs informers on the VirtualMachines, VirtualMachineInstances, LoadBalancers and Services of the target namespaces
+// in Harvester, one set of informers for each identity secret and target namespace, similarly to the ClusterCacheTracker
+// of Cluster API for the workload clusters. Their events are turned into events of the HarvesterMachines and HarvesterClusters
+// which registered the resources, and the informers are stopped once no object is registered in their namespace anymore.
+// The informers are restarted when the clients of their identity secret change, so the clients have to come from a ClientCache.
+// A nil Tracker watches nothing.
+type Tracker struct {
+	machineEvents *eventSource
+	clusterEvents *eventSource
+
+	lock    sync.Mutex
+	watches map[watchKey]*namespaceWatch
+}
+
+// watchKey identifies the informers of a target namespace in a Harvester cluster.
+type watchKey struct {
+	identity  types.UID
+	namespace string
+}
+
+// NewTracker returns a Tracker without any informer, they are started when the first object of their namespace is registered.
+func NewTracker() *Tracker {
+	return &Tracker{
+		machineEvents: newEventSource(),
+		clusterEvents: newEventSource(),
+		watches:       map[watchKey]*namespaceWatch{},
+	}
+}
+
+// MachineSource is the source of the events of the registered HarvesterMachines, for the HarvesterMachine controller.
+// The events are added to the queue of the controller, which holds each HarvesterMachine once however late the controller is.
+func (t *Tracker) MachineSource() source.Source {
+	return t.machineEvents.source()
+}
+
+// ClusterSource is the source of the events of the registered HarvesterClusters, for the HarvesterCluster controller.
+// The events are added to the queue of the controller, which holds each HarvesterCluster once however late the controller is.
+func (t *Tracker) ClusterSource() source.Source {
+	return t.clusterEvents.source()
+}
+
+// WatchMachine registers the VM of a HarvesterMachine, so that the changes of the VM and of its VM instance trigger the
+// reconciliation of the HarvesterMachine. It returns true once the informers are synced, from which point every change
+// of the VM is queued for the HarvesterMachine controller, so that it does not need to poll the VM anymore.
+func (t *Tracker) WatchMachine(clients *locutil.HarvesterClients, namespace string, vmName string, machine types.NamespacedName) bool {
+	if t == nil {
+		return false
+	}
+
+	watch := t.getOrStartWatch(clients, namespace, func(watch *namespaceWatch) {
+		watch.registerMachine(vmName, machine)
+	})
+
+	return watch.hasSynced()
+}
+
+// UnwatchMachine removes the registration of the VM of a HarvesterMachine.
+func (t *Tracker) UnwatchMachine(identity types.UID, namespace string, vmName string) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := watchKey{identity: identity, namespace: namespace}
+	if watch, ok := t.watches[key]; ok {
+		watch.unregisterMachine(vmName)
+		t.stopIfUnused(key, watch)
+	}
+}
+
+// WatchCluster registers the load balancer and the Services of a HarvesterCluster, so that their changes trigger the
+// reconciliation of the HarvesterCluster. It returns true once the informers are synced, from which point every change
+// of the load balancer is queued for the HarvesterCluster controller, so that it does not need to poll the load balancer anymore.
+func (t *Tracker) WatchCluster(clients *locutil.HarvesterClients, namespace string, names []string, cluster types.NamespacedName) bool {
+	if t == nil {
+		return false
+	}
+
+	watch := t.getOrStartWatch(clients, namespace, func(watch *namespaceWatch) {
+		watch.registerCluster(names, cluster)
+	})
+
+	return watch.hasSynced()
+}
+
+// UnwatchCluster removes the registrations of a HarvesterCluster.
+func (t *Tracker) UnwatchCluster(identity types.UID, namespace string, cluster types.NamespacedName) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := watchKey{identity: identity, namespace: namespace}
+	if watch, ok := t.watches[key]; ok {
+		watch.unregisterCluster(cluster)
+		t.stopIfUnused(key, watch)
+	}
+}
+
+// getOrStartWatch returns the informers of a namespace, starting them if needed, after registering an object in them.
+// The registration is done with the lock held, so that the informers are not stopped concurrently as unused.
+// The informers are started again with the new clients when the identity secret changed, keeping the registered objects.
+func (t *Tracker) getOrStartWatch(clients *locutil.HarvesterClients, namespace string, register func(*namespaceWatch)) *namespaceWatch {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := watchKey{identity: clients.Identity, namespace: namespace}
+
+	watch, ok := t.watches[key]
+	if ok && watch.clients == clients {
+		register(watch)
+
+		return watch
+	}
+
+	newWatch := newNamespaceWatch(clients, namespace, t.machineEvents, t.clusterEvents)
+
+	if ok {
+		watch.stop()
+		newWatch.copyRegistrations(watch)
+	}
+
+	register(newWatch)
+	newWatch.start()
+	t.watches[key] = newWatch
+
+	return newWatch
+}
+
+// stopIfUnused stops the informers of a namespace in which no object is registered anymore. The lock of the Tracker must be held.
+func (t *Tracker) stopIfUnused(key watchKey, watch *namespaceWatch) {
+	if watch.isUnused() {
+		watch.stop()
+		delete(t.watches, key)
+	}
+}
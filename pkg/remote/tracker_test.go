@@ -0,0 +1,204 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	locutil "github.com/rancher-sandbox/cluster-api-provider-harvester/util"
+)
+
+// newTestClients builds clients of a Harvester API which answers NotFound to every request, so that the informers never sync.
+func newTestClients(identity types.UID) *locutil.HarvesterClients {
+	server := httptest.NewServer(http.NotFoundHandler())
+	DeferCleanup(server.Close)
+
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: harvester
+  cluster:
+    server: ` + server.URL + `
+contexts:
+- name: harvester
+  context:
+    cluster: harvester
+    user: harvester
+current-context: harvester
+users:
+- name: harvester
+  user:
+    token: test
+`
+
+	clients, err := locutil.NewHarvesterClients(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hv-identity", Namespace: "default", UID: identity},
+		Data:       map[string][]byte{locutil.ConfigSecretDataKey: []byte(kubeconfig)},
+	}, 0, 0)
+	Expect(err).NotTo(HaveOccurred())
+
+	return clients
+}
+
+var _ = Describe("Route the events of the Harvester resources", func() {
+	var machineQueue, clusterQueue workqueue.RateLimitingInterface
+	var watch *namespaceWatch
+
+	machine := types.NamespacedName{Namespace: "example", Name: "cp-0"}
+	cluster := types.NamespacedName{Namespace: "example", Name: "hv-cluster"}
+
+	BeforeEach(func() {
+		machineQueue = newTestQueue()
+		clusterQueue = newTestQueue()
+		watch = &namespaceWatch{
+			machineEvents: startTestSource(machineQueue),
+			clusterEvents: startTestSource(clusterQueue),
+			machines:      map[string]types.NamespacedName{},
+			clusters:      map[string]types.NamespacedName{},
+		}
+	})
+
+	It("Should queue the HarvesterMachine owning a VM", func() {
+		watch.registerMachine("cp-0", machine)
+
+		watch.notifyMachine("cp-0")
+		watch.notifyMachine("another-vm")
+
+		Expect(machineQueue.Len()).To(Equal(1))
+		Expect(clusterQueue.Len()).To(BeZero())
+		Expect(machineQueue.Get()).To(Equal(reconcile.Request{NamespacedName: machine}))
+	})
+
+	It("Should replace the resources previously registered by a HarvesterCluster", func() {
+		watch.registerCluster([]string{"placeholder"}, cluster)
+		watch.registerCluster([]string{"example-hv-cluster-lb"}, cluster)
+
+		watch.notifyCluster("placeholder")
+		watch.notifyCluster("example-hv-cluster-lb")
+
+		Expect(clusterQueue.Len()).To(Equal(1))
+		Expect(clusterQueue.Get()).To(Equal(reconcile.Request{NamespacedName: cluster}))
+	})
+
+	It("Should queue the events of many objects without waiting for the controller", func() {
+		for i := 0; i < 5000; i++ {
+			vmName := fmt.Sprintf("vm-%d", i)
+			watch.registerMachine(vmName, types.NamespacedName{Namespace: "example", Name: vmName})
+			watch.notifyMachine(vmName)
+			watch.notifyMachine(vmName)
+		}
+
+		Expect(machineQueue.Len()).To(Equal(5000))
+	})
+
+	It("Should keep the events sent before the controller starts the source", func() {
+		events := newEventSource()
+		watch.machineEvents = events
+		watch.registerMachine("cp-0", machine)
+
+		watch.notifyMachine("cp-0")
+		watch.notifyMachine("cp-0")
+
+		queue := newTestQueue()
+		Expect(events.source().Start(context.Background(), &handler.EnqueueRequestForObject{}, queue)).To(Succeed())
+		Expect(queue.Len()).To(Equal(1))
+		Expect(queue.Get()).To(Equal(reconcile.Request{NamespacedName: machine}))
+	})
+
+	It("Should not be used anymore once its objects are unregistered", func() {
+		watch.registerMachine("cp-0", machine)
+		watch.registerCluster([]string{"example-hv-cluster-lb"}, cluster)
+
+		watch.unregisterMachine("cp-0")
+		Expect(watch.isUnused()).To(BeFalse())
+
+		watch.unregisterCluster(cluster)
+		Expect(watch.isUnused()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Track the informers of the Harvester clusters", func() {
+	var tracker *Tracker
+
+	machine := types.NamespacedName{Namespace: "example", Name: "cp-0"}
+
+	BeforeEach(func() {
+		tracker = NewTracker()
+	})
+
+	It("Should not report the VM as watched until the informers are synced", func() {
+		clients := newTestClients("1234")
+
+		Expect(tracker.WatchMachine(clients, "default", "cp-0", machine)).To(BeFalse())
+		Expect(tracker.watches).To(HaveLen(1))
+
+		tracker.UnwatchMachine(clients.Identity, "default", "cp-0")
+		Expect(tracker.watches).To(BeEmpty())
+	})
+
+	It("Should restart the informers with the new clients of an identity, keeping the registrations", func() {
+		clients := newTestClients("1234")
+		tracker.WatchMachine(clients, "default", "cp-0", machine)
+		DeferCleanup(tracker.UnwatchMachine, clients.Identity, "default", "cp-0")
+		DeferCleanup(tracker.UnwatchMachine, clients.Identity, "default", "cp-1")
+
+		key := watchKey{identity: "1234", namespace: "default"}
+		previous := tracker.watches[key]
+
+		tracker.WatchMachine(newTestClients("1234"), "default", "cp-1", types.NamespacedName{Namespace: "example", Name: "cp-1"})
+
+		Expect(tracker.watches).To(HaveLen(1))
+		Expect(tracker.watches[key]).NotTo(BeIdenticalTo(previous))
+		Expect(previous.ctx.Err()).To(HaveOccurred())
+		Expect(tracker.watches[key].machines).To(HaveLen(2))
+	})
+
+	It("Should watch nothing without a tracker", func() {
+		var nilTracker *Tracker
+
+		Expect(nilTracker.WatchMachine(newTestClients("1234"), "default", "cp-0", machine)).To(BeFalse())
+		nilTracker.UnwatchMachine("1234", "default", "cp-0")
+	})
+})
+
+// newTestQueue returns a queue like the ones of the controllers, which is shut down at the end of the test.
+func newTestQueue() workqueue.RateLimitingInterface {
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	DeferCleanup(queue.ShutDown)
+
+	return queue
+}
+
+// startTestSource returns an event source started like by a controller enqueuing the objects of the events.
+func startTestSource(queue workqueue.RateLimitingInterface) *eventSource {
+	events := newEventSource()
+	Expect(events.source().Start(context.Background(), &handler.EnqueueRequestForObject{}, queue)).To(Succeed())
+
+	return events
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing creates the OpenTelemetry spans of the reconciliations and of the requests to the Harvester API.
+// Spans are only exported when an OTLP endpoint is configured, the global no-op tracer provider is used otherwise.
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName  = "github.com/rancher-sandbox/cluster-api-provider-harvester"
+	serviceName = "cluster-api-provider-harvester"
+)
+
+// Options configures the export of the spans.
+type Options struct {
+	// Endpoint is the address of the OTLP gRPC collector. Tracing is disabled when it is empty.
+	Endpoint string
+	// Insecure disables TLS on the connection to the collector.
+	Insecure bool
+	// SamplingRatio is the ratio of the reconciliations which are traced, between 0 and 1.
+	SamplingRatio float64
+}
+
+// Setup registers a tracer provider exporting the spans to an OTLP collector. The returned function flushes
+// the spans which were not exported yet, it has to be called before the manager exits.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporterOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(opts.Endpoint)}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create the OTLP exporter for %s", opts.Endpoint)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SamplingRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tracerProvider.Shutdown, nil
+}
+
+// StartSpan starts a span as a child of the span of the context, if any.
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan ends a span, recording the error which ended it if any.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// StartPhase starts the span of a phase of a reconciliation and replaces the context of the reconciliation with the
+// context of the span, so that the requests made during the phase are its children. The returned function ends the span
+// with the error of the phase and restores the context of the reconciliation.
+func StartPhase(ctx *context.Context, name string, attributes ...attribute.KeyValue) func(error) {
+	parent := *ctx
+
+	phaseCtx, span := StartSpan(parent, name, attributes...)
+	*ctx = phaseCtx
+
+	return func(err error) {
+		EndSpan(span, err)
+		*ctx = parent
+	}
+}
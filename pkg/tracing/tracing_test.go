@@ -0,0 +1,131 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setTestTracerProvider records the spans in memory for the duration of a test.
+func setTestTracerProvider() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	DeferCleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+var _ = Describe("Trace the phases of a reconciliation", func() {
+	var recorder *tracetest.SpanRecorder
+
+	BeforeEach(func() {
+		recorder = setTestTracerProvider()
+	})
+
+	It("Should make the phase a child of the reconciliation and restore the context", func() {
+		reconcileCtx, reconcileSpan := StartSpan(context.Background(), "HarvesterMachine.Reconcile")
+		ctx := reconcileCtx
+
+		endPhase := StartPhase(&ctx, "CreateVM")
+		phaseSpanContext := trace.SpanContextFromContext(ctx)
+		Expect(phaseSpanContext.SpanID()).NotTo(Equal(reconcileSpan.SpanContext().SpanID()))
+
+		endPhase(nil)
+		Expect(ctx).To(Equal(reconcileCtx))
+
+		Expect(recorder.Ended()).To(HaveLen(1))
+		phase := recorder.Ended()[0]
+		Expect(phase.Name()).To(Equal("CreateVM"))
+		Expect(phase.Parent().SpanID()).To(Equal(reconcileSpan.SpanContext().SpanID()))
+		Expect(phase.Status().Code).To(Equal(codes.Unset))
+	})
+
+	It("Should record the error which ended the phase", func() {
+		ctx := context.Background()
+
+		endPhase := StartPhase(&ctx, "ReconcileLoadBalancer")
+		endPhase(errors.New("IP Pool exhausted"))
+
+		Expect(recorder.Ended()).To(HaveLen(1))
+		Expect(recorder.Ended()[0].Status().Code).To(Equal(codes.Error))
+		Expect(recorder.Ended()[0].Status().Description).To(Equal("IP Pool exhausted"))
+	})
+})
+
+var _ = Describe("Trace the requests to the Harvester API", func() {
+	var recorder *tracetest.SpanRecorder
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		recorder = setTestTracerProvider()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if strings.HasSuffix(req.URL.Path, "/missing") {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	sendRequest := func(ctx context.Context, path string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := (&tracedRoundTripper{next: http.DefaultTransport}).RoundTrip(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Body.Close()).To(Succeed())
+	}
+
+	It("Should create a span for the request as a child of the span of its context", func() {
+		ctx, parent := StartSpan(context.Background(), "ReconcileExistingVM")
+
+		sendRequest(ctx, "/apis/kubevirt.io/v1/namespaces/default/virtualmachineinstances/missing")
+
+		Expect(recorder.Ended()).To(HaveLen(1))
+		span := recorder.Ended()[0]
+		Expect(span.Name()).To(Equal("Harvester get virtualmachineinstances"))
+		Expect(span.Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+		Expect(span.Attributes()).To(ContainElement(attribute.Int("http.status_code", http.StatusNotFound)))
+		Expect(span.Status().Code).To(Equal(codes.Unset))
+	})
+
+	It("Should mark the span of a failed request as an error", func() {
+		sendRequest(context.Background(), "/apis/loadbalancer.harvesterhci.io/v1beta1/ippools/pool")
+
+		Expect(recorder.Ended()).To(HaveLen(1))
+		Expect(recorder.Ended()[0].Name()).To(Equal("Harvester get ippools"))
+		Expect(recorder.Ended()[0].Status().Code).To(Equal(codes.Error))
+	})
+})
@@ -0,0 +1,70 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"k8s.io/client-go/rest"
+
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/metrics"
+)
+
+// InstrumentRESTConfig creates a span for each request made to the Harvester API by the clients built from a REST config.
+// The span is a child of the span of the context given to the client, such as the span of a reconciliation phase.
+func InstrumentRESTConfig(config *rest.Config) {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &tracedRoundTripper{next: rt}
+	})
+}
+
+// tracedRoundTripper wraps each request in a client span named after its Kubernetes verb and resource.
+type tracedRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (t *tracedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource, verb := metrics.GetRequestResourceAndVerb(req)
+
+	ctx, span := StartSpan(req.Context(), fmt.Sprintf("Harvester %s %s", verb, resource),
+		attribute.String("http.method", req.Method),
+		attribute.String("http.target", req.URL.Path),
+		attribute.String("k8s.resource", resource),
+		attribute.String("k8s.verb", verb),
+	)
+	defer span.End()
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return resp, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	// The same responses as in the metrics are errors, NotFound and Conflict are expected during the reconciliations
+	if metrics.IsRequestError(resp, nil) {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	hvclientset "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/clientset/versioned"
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/metrics"
+	"github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/tracing"
+)
+
+const (
+	// DefaultHarvesterClientQPS is the default rate of the requests to a Harvester API, shared by all its HarvesterClusters
+	// and HarvesterMachines.
+	DefaultHarvesterClientQPS = 20
+	// DefaultHarvesterClientBurst is the default number of requests to a Harvester API which can exceed DefaultHarvesterClientQPS.
+	DefaultHarvesterClientBurst = 30
+)
+
+// HarvesterClients are the clients of a Harvester API, built from an identity secret. They share the same connections.
+type HarvesterClients struct {
+	// Harvester is the clientset of the resources of Harvester and of the core Kubernetes resources it manages.
+	Harvester *hvclientset.Clientset
+	// Kubernetes is the clientset of the other Kubernetes resources, like the Deployment of Harvester.
+	Kubernetes kubeclient.Interface
+	// Identity is the UID of the identity secret the clients were built from.
+	Identity types.UID
+
+	httpClient *http.Client
+}
+
+// ClientCache shares the clients of the Harvester APIs between the reconciliations, instead of parsing the identity secret
+// and opening new connections each time. The clients are built again when the identity secret changes.
+// A nil ClientCache builds new clients each time.
+type ClientCache struct {
+	qps   float32
+	burst int
+
+	lock    sync.Mutex
+	entries map[types.UID]*clientCacheEntry
+}
+
+// clientCacheEntry holds the clients built from a version of an identity secret.
+type clientCacheEntry struct {
+	resourceVersion string
+	clients         *HarvesterClients
+}
+
+// NewClientCache returns an empty ClientCache, whose clients are limited to qps requests per second with the given burst.
+// Zero values keep the defaults of client-go.
+func NewClientCache(qps float32, burst int) *ClientCache {
+	return &ClientCache{
+		qps:     qps,
+		burst:   burst,
+		entries: map[types.UID]*clientCacheEntry{},
+	}
+}
+
+// Get returns the clients built from an identity secret. They are built if the secret was not seen before,
+// or if its resourceVersion changed since.
+func (c *ClientCache) Get(secret *corev1.Secret) (*HarvesterClients, error) {
+	if c == nil {
+		return NewHarvesterClients(secret, 0, 0)
+	}
+
+	// Secrets which do not come from the API server cannot be told apart
+	if secret.UID == "" {
+		return NewHarvesterClients(secret, c.qps, c.burst)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[secret.UID]
+	if ok && entry.resourceVersion == secret.ResourceVersion {
+		return entry.clients, nil
+	}
+
+	clients, err := NewHarvesterClients(secret, c.qps, c.burst)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		entry.clients.close()
+	}
+
+	c.entries[secret.UID] = &clientCacheEntry{resourceVersion: secret.ResourceVersion, clients: clients}
+
+	return clients, nil
+}
+
+// Invalidate removes the clients built from an identity secret, they are built again on their next use.
+func (c *ClientCache) Invalidate(uid types.UID) {
+	if c == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if entry, ok := c.entries[uid]; ok {
+		entry.clients.close()
+		delete(c.entries, uid)
+	}
+}
+
+// NewHarvesterClients builds the clients of the Harvester API from the kubeconfig of an identity secret.
+// Zero qps and burst keep the defaults of client-go.
+func NewHarvesterClients(secret *corev1.Secret, qps float32, burst int) (*HarvesterClients, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(secret.Data[ConfigSecretDataKey])
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the kubeconfig of secret %s/%s", secret.Namespace, secret.Name)
+	}
+
+	if qps > 0 {
+		config.QPS = qps
+	}
+
+	if burst > 0 {
+		config.Burst = burst
+	}
+
+	metrics.InstrumentRESTConfig(config)
+	tracing.InstrumentRESTConfig(config)
+
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the HTTP client of the Harvester API")
+	}
+
+	hvClient, err := hvclientset.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the Harvester client")
+	}
+
+	kubeClient, err := kubeclient.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the Kubernetes client of Harvester")
+	}
+
+	return &HarvesterClients{Harvester: hvClient, Kubernetes: kubeClient, Identity: secret.UID, httpClient: httpClient}, nil
+}
+
+// close releases the idle connections of clients which are not used anymore. Requests in flight are not interrupted.
+func (c *HarvesterClients) close() {
+	c.httpClient.CloseIdleConnections()
+}
@@ -0,0 +1,80 @@
+package util
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testHarvesterKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: harvester
+  cluster:
+    server: https://harvester.example.com:6443
+contexts:
+- name: harvester
+  context:
+    cluster: harvester
+    user: harvester
+current-context: harvester
+users:
+- name: harvester
+  user:
+    token: test
+`
+
+var _ = Describe("ClientCache", func() {
+	var cache *ClientCache
+	var secret *corev1.Secret
+
+	BeforeEach(func() {
+		cache = NewClientCache(DefaultHarvesterClientQPS, DefaultHarvesterClientBurst)
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "hv-identity", Namespace: "default", UID: "1234", ResourceVersion: "1"},
+			Data:       map[string][]byte{ConfigSecretDataKey: []byte(testHarvesterKubeconfig)},
+		}
+	})
+
+	It("Should reuse the clients of an unchanged secret", func() {
+		clients, err := cache.Get(secret)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(clients.Harvester).ToNot(BeNil())
+		Expect(clients.Kubernetes).ToNot(BeNil())
+
+		Expect(cache.Get(secret.DeepCopy())).To(BeIdenticalTo(clients))
+	})
+
+	It("Should build new clients when the secret changes", func() {
+		clients, err := cache.Get(secret)
+		Expect(err).ToNot(HaveOccurred())
+
+		secret.ResourceVersion = "2"
+		Expect(cache.Get(secret)).ToNot(BeIdenticalTo(clients))
+	})
+
+	It("Should build new clients once the secret is invalidated", func() {
+		clients, err := cache.Get(secret)
+		Expect(err).ToNot(HaveOccurred())
+
+		cache.Invalidate(secret.UID)
+		Expect(cache.Get(secret)).ToNot(BeIdenticalTo(clients))
+	})
+
+	It("Should build new clients each time without a cache", func() {
+		var nilCache *ClientCache
+
+		clients, err := nilCache.Get(secret)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nilCache.Get(secret)).ToNot(BeIdenticalTo(clients))
+	})
+
+	It("Should fail on a malformed kubeconfig", func() {
+		secret.Data[ConfigSecretDataKey] = []byte("not a kubeconfig")
+
+		_, err := cache.Get(secret)
+		Expect(err).To(HaveOccurred())
+		Expect(cache.entries).To(BeEmpty())
+	})
+})
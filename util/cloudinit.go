@@ -1,7 +1,9 @@
 package util
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
 
 	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v2"
@@ -66,3 +68,91 @@ func MergeCloudInitData(cloudInits ...string) ([]byte, error) {
 
 	return resultCloudInit, nil
 }
+
+// NetworkDataInterface is the configuration of a VM interface in a cloud-init network config.
+type NetworkDataInterface struct {
+	// Name identifies the interface in the network config.
+	Name string
+	// MACAddress matches the interface in the VM.
+	MACAddress string
+	// Addresses are the static addresses of the interface in CIDR notation. The interface uses DHCP if there are none.
+	Addresses []string
+	// Gateway is the default gateway of the VM, if it is reached through this interface.
+	Gateway string
+	// Nameservers are the DNS servers to use on the interface.
+	Nameservers []string
+}
+
+type networkConfig struct {
+	Version   int                              `json:"version"`
+	Ethernets map[string]networkConfigEthernet `json:"ethernets"`
+}
+
+type networkConfigEthernet struct {
+	Match       networkConfigMatch        `json:"match"`
+	DHCP4       bool                      `json:"dhcp4"`
+	Addresses   []string                  `json:"addresses,omitempty"`
+	Routes      []networkConfigRoute      `json:"routes,omitempty"`
+	Nameservers *networkConfigNameservers `json:"nameservers,omitempty"`
+}
+
+type networkConfigMatch struct {
+	MACAddress string `json:"macaddress"`
+}
+
+type networkConfigRoute struct {
+	To  string `json:"to"`
+	Via string `json:"via"`
+}
+
+type networkConfigNameservers struct {
+	Addresses []string `json:"addresses"`
+}
+
+// RenderNetworkData renders a cloud-init network config (version 2) configuring the given interfaces.
+// The config is rendered as JSON, which is valid YAML, so that MAC addresses are never mistaken for numbers by YAML 1.1 parsers.
+func RenderNetworkData(interfaces []NetworkDataInterface) ([]byte, error) {
+	config := networkConfig{
+		Version:   2, //nolint:mnd
+		Ethernets: make(map[string]networkConfigEthernet, len(interfaces)),
+	}
+
+	for _, iface := range interfaces {
+		if iface.MACAddress == "" {
+			return nil, fmt.Errorf("no MAC address set for interface %s", iface.Name)
+		}
+
+		ethernet := networkConfigEthernet{
+			Match:     networkConfigMatch{MACAddress: iface.MACAddress},
+			DHCP4:     len(iface.Addresses) == 0,
+			Addresses: iface.Addresses,
+		}
+
+		if iface.Gateway != "" {
+			gateway := net.ParseIP(iface.Gateway)
+			if gateway == nil {
+				return nil, fmt.Errorf("invalid gateway %s for interface %s", iface.Gateway, iface.Name)
+			}
+
+			defaultRoute := "0.0.0.0/0"
+			if gateway.To4() == nil {
+				defaultRoute = "::/0"
+			}
+
+			ethernet.Routes = []networkConfigRoute{{To: defaultRoute, Via: iface.Gateway}}
+		}
+
+		if len(iface.Nameservers) > 0 {
+			ethernet.Nameservers = &networkConfigNameservers{Addresses: iface.Nameservers}
+		}
+
+		config.Ethernets[iface.Name] = ethernet
+	}
+
+	networkData, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshall network config: %w", err)
+	}
+
+	return networkData, nil
+}
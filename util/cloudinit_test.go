@@ -48,3 +48,58 @@ ssh_authorized_keys:
 `))
 	})
 })
+
+var _ = Describe("RenderNetworkData", func() {
+	It("Should configure static and DHCP interfaces", func() {
+		networkData, err := RenderNetworkData([]NetworkDataInterface{
+			{
+				Name:        "nic-1",
+				MACAddress:  "02:00:00:00:00:01",
+				Addresses:   []string{"10.0.0.10/24"},
+				Gateway:     "10.0.0.1",
+				Nameservers: []string{"10.0.0.2"},
+			},
+			{
+				Name:       "nic-2",
+				MACAddress: "02:00:00:00:00:02",
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(networkData)).To(Equal(`{
+  "version": 2,
+  "ethernets": {
+    "nic-1": {
+      "match": {
+        "macaddress": "02:00:00:00:00:01"
+      },
+      "dhcp4": false,
+      "addresses": [
+        "10.0.0.10/24"
+      ],
+      "routes": [
+        {
+          "to": "0.0.0.0/0",
+          "via": "10.0.0.1"
+        }
+      ],
+      "nameservers": {
+        "addresses": [
+          "10.0.0.2"
+        ]
+      }
+    },
+    "nic-2": {
+      "match": {
+        "macaddress": "02:00:00:00:00:02"
+      },
+      "dhcp4": true
+    }
+  }
+}`))
+	})
+
+	It("Should fail without MAC address", func() {
+		_, err := RenderNetworkData([]NetworkDataInterface{{Name: "nic-1"}})
+		Expect(err).To(HaveOccurred())
+	})
+})
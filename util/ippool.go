@@ -14,7 +14,6 @@ import (
 
 const (
 	initialCapacity = 10
-	p2pMaskStr      = "ffffffff"
 )
 
 // Store implements the backend.Store interface.
@@ -30,31 +29,46 @@ func NewStore(pool *lbv1beta1.IPPool) *Store {
 	}
 }
 
-// Lock locks the store.
+// Lock locks the store. It is a no-op: the store is an in-memory copy of an IP Pool, concurrent allocations
+// are detected when the IP Pool is updated in Harvester, thanks to its resource version.
 func (s *Store) Lock() error {
 	return nil
 }
 
+// Unlock unlocks the store. It is a no-op, like Lock.
 func (s *Store) Unlock() error {
 	return nil
 }
 
+// Close closes the store. It is a no-op, like Lock.
 func (s *Store) Close() error {
 	return nil
 }
 
+// Reserve marks an IP address as allocated to an applicant. It returns false if the address is already allocated.
+// The address is also recorded in the allocation history, so that it is given again to the same applicant in priority.
 func (s *Store) Reserve(id, _ string, ip net.IP, _ string) (bool, error) {
 	ipStr := ip.String()
 
 	// return false if the IP is already reserved
-	if s.IPPool.Status.Allocated != nil {
-		if _, ok := s.IPPool.Status.Allocated[ipStr]; ok {
-			return false, nil
-		}
+	if _, ok := s.IPPool.Status.Allocated[ipStr]; ok {
+		return false, nil
+	}
+
+	if s.IPPool.Status.Allocated == nil {
+		s.IPPool.Status.Allocated = make(map[string]string)
+	}
+
+	if s.IPPool.Status.AllocatedHistory == nil {
+		s.IPPool.Status.AllocatedHistory = make(map[string]string)
 	}
 
-	if s.IPPool.Status.AllocatedHistory != nil {
-		s.IPPool.Status.AllocatedHistory[ipStr] = id
+	s.IPPool.Status.Allocated[ipStr] = id
+	s.IPPool.Status.AllocatedHistory[ipStr] = id
+	s.IPPool.Status.LastAllocated = ipStr
+
+	if s.IPPool.Status.Available > 0 {
+		s.IPPool.Status.Available--
 	}
 
 	return true, nil
@@ -71,11 +85,16 @@ func (s *Store) Release(ip net.IP) error {
 
 	ipStr := ip.String()
 
+	applicant, ok := s.IPPool.Status.Allocated[ipStr]
+	if !ok {
+		return nil
+	}
+
 	if s.IPPool.Status.AllocatedHistory == nil {
 		s.IPPool.Status.AllocatedHistory = make(map[string]string)
 	}
 
-	s.IPPool.Status.AllocatedHistory[ipStr] = s.IPPool.Status.Allocated[ipStr]
+	s.IPPool.Status.AllocatedHistory[ipStr] = applicant
 	delete(s.IPPool.Status.Allocated, ipStr)
 
 	s.IPPool.Status.Available++
@@ -124,9 +143,8 @@ func MakeRange(r *lbv1beta1.Range) (*allocator.Range, error) {
 
 	var defaultStart, defaultEnd, defaultGateway, start, end, gateway net.IP
 
-	mask := ipNet.Mask.String()
 	// If the subnet is a point to point IP
-	if mask == p2pMaskStr {
+	if ones, bits := ipNet.Mask.Size(); ones == bits {
 		defaultStart = ip.To16()
 		defaultEnd = ip.To16()
 		defaultGateway = nil
@@ -246,3 +264,101 @@ func ipToInt(ip net.IP) *big.Int {
 
 	return big.NewInt(0).SetBytes(ip.To16())
 }
+
+// ipInterval is an interval of IP addresses, bounds included.
+type ipInterval struct {
+	start net.IP
+	end   net.IP
+}
+
+// ExcludeFromRanges splits the ranges of an IP Pool around a list of excluded IP addresses or CIDRs, so that they are
+// never allocated. The ranges are resolved with their default bounds first, and the ranges left empty are removed.
+func ExcludeFromRanges(ranges []lbv1beta1.Range, excluded []string) ([]lbv1beta1.Range, error) {
+	if len(excluded) == 0 {
+		return ranges, nil
+	}
+
+	exclusions := make([]ipInterval, 0, len(excluded))
+
+	for _, address := range excluded {
+		exclusion, err := parseExclusion(address)
+		if err != nil {
+			return nil, err
+		}
+
+		exclusions = append(exclusions, exclusion)
+	}
+
+	splitRanges := make([]lbv1beta1.Range, 0, len(ranges))
+
+	for i := range ranges {
+		r, err := MakeRange(&ranges[i])
+		if err != nil {
+			return nil, err
+		}
+
+		intervals := []ipInterval{{start: r.RangeStart, end: r.RangeEnd}}
+
+		for _, exclusion := range exclusions {
+			// Addresses of another IP family cannot be inside the range
+			if (exclusion.start.To4() == nil) != (r.RangeStart.To4() == nil) {
+				continue
+			}
+
+			intervals = subtractInterval(intervals, exclusion)
+		}
+
+		for _, interval := range intervals {
+			splitRanges = append(splitRanges, lbv1beta1.Range{
+				Subnet:     ranges[i].Subnet,
+				Gateway:    ranges[i].Gateway,
+				RangeStart: interval.start.String(),
+				RangeEnd:   interval.end.String(),
+			})
+		}
+	}
+
+	if len(splitRanges) == 0 {
+		return nil, fmt.Errorf("no address is left in the ranges after excluding %v", excluded)
+	}
+
+	return splitRanges, nil
+}
+
+// parseExclusion returns the interval of addresses of an excluded IP address or CIDR.
+func parseExclusion(address string) (ipInterval, error) {
+	if ip := net.ParseIP(address); ip != nil {
+		return ipInterval{start: ip, end: ip}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(address)
+	if err != nil {
+		return ipInterval{}, fmt.Errorf("invalid excluded address %s", address)
+	}
+
+	return ipInterval{start: networkIP(*ipNet), end: broadcastIP(*ipNet)}, nil
+}
+
+// subtractInterval removes the addresses of an interval from a list of intervals.
+func subtractInterval(intervals []ipInterval, exclusion ipInterval) []ipInterval {
+	result := make([]ipInterval, 0, len(intervals)+1)
+
+	for _, interval := range intervals {
+		// The interval does not overlap the exclusion
+		if ipToInt(exclusion.end).Cmp(ipToInt(interval.start)) < 0 || ipToInt(exclusion.start).Cmp(ipToInt(interval.end)) > 0 {
+			result = append(result, interval)
+
+			continue
+		}
+
+		if ipToInt(exclusion.start).Cmp(ipToInt(interval.start)) > 0 {
+			result = append(result, ipInterval{start: interval.start, end: cnip.PrevIP(exclusion.start)})
+		}
+
+		if ipToInt(exclusion.end).Cmp(ipToInt(interval.end)) < 0 {
+			result = append(result, ipInterval{start: cnip.NextIP(exclusion.end), end: interval.end})
+		}
+	}
+
+	return result
+}
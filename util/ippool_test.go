@@ -0,0 +1,88 @@
+package util
+
+import (
+	"net"
+
+	lbv1beta1 "github.com/harvester/harvester-load-balancer/pkg/apis/loadbalancer.harvesterhci.io/v1beta1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExcludeFromRanges", func() {
+	var ranges []lbv1beta1.Range
+
+	BeforeEach(func() {
+		ranges = []lbv1beta1.Range{
+			{
+				Subnet:     "172.19.0.0/16",
+				Gateway:    "172.19.0.1",
+				RangeStart: "172.19.10.1",
+				RangeEnd:   "172.19.10.20",
+			},
+			{
+				Subnet:  "fd00:172:19::/120",
+				Gateway: "fd00:172:19::1",
+			},
+		}
+	})
+
+	It("Should split the ranges around the excluded addresses", func() {
+		splitRanges, err := ExcludeFromRanges(ranges, []string{"172.19.10.5", "172.19.10.16/30", "fd00:172:19::10"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(splitRanges).To(Equal([]lbv1beta1.Range{
+			{Subnet: "172.19.0.0/16", Gateway: "172.19.0.1", RangeStart: "172.19.10.1", RangeEnd: "172.19.10.4"},
+			{Subnet: "172.19.0.0/16", Gateway: "172.19.0.1", RangeStart: "172.19.10.6", RangeEnd: "172.19.10.15"},
+			{Subnet: "172.19.0.0/16", Gateway: "172.19.0.1", RangeStart: "172.19.10.20", RangeEnd: "172.19.10.20"},
+			{Subnet: "fd00:172:19::/120", Gateway: "fd00:172:19::1", RangeStart: "fd00:172:19::1", RangeEnd: "fd00:172:19::f"},
+			{Subnet: "fd00:172:19::/120", Gateway: "fd00:172:19::1", RangeStart: "fd00:172:19::11", RangeEnd: "fd00:172:19::ff"},
+		}))
+	})
+
+	It("Should keep the ranges without exclusions", func() {
+		Expect(ExcludeFromRanges(ranges, nil)).To(Equal(ranges))
+	})
+
+	It("Should fail when every address is excluded", func() {
+		_, err := ExcludeFromRanges(ranges[:1], []string{"172.19.0.0/16"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should fail with an invalid exclusion", func() {
+		_, err := ExcludeFromRanges(ranges, []string{"172.19.10"})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Store", func() {
+	var store *Store
+
+	BeforeEach(func() {
+		store = NewStore(&lbv1beta1.IPPool{
+			Status: lbv1beta1.IPPoolStatus{
+				Available: 10,
+			},
+		})
+	})
+
+	It("Should mark a reserved address as allocated", func() {
+		Expect(store.Reserve("default/test-lb", "", net.ParseIP("172.19.10.1"), "")).To(BeTrue())
+		Expect(store.Status.Allocated).To(HaveKeyWithValue("172.19.10.1", "default/test-lb"))
+		Expect(store.Status.AllocatedHistory).To(HaveKeyWithValue("172.19.10.1", "default/test-lb"))
+		Expect(store.Status.LastAllocated).To(Equal("172.19.10.1"))
+		Expect(store.Status.Available).To(BeEquivalentTo(9))
+
+		Expect(store.Reserve("default/other-lb", "", net.ParseIP("172.19.10.1"), "")).To(BeFalse())
+		Expect(store.Status.Available).To(BeEquivalentTo(9))
+	})
+
+	It("Should release the addresses of an applicant and keep them in the history", func() {
+		Expect(store.Reserve("default/test-lb", "", net.ParseIP("172.19.10.1"), "")).To(BeTrue())
+		Expect(store.ReleaseByID("default/test-lb", "")).To(Succeed())
+		Expect(store.Status.Allocated).To(BeEmpty())
+		Expect(store.Status.AllocatedHistory).To(HaveKeyWithValue("172.19.10.1", "default/test-lb"))
+		Expect(store.Status.Available).To(BeEquivalentTo(10))
+
+		Expect(store.Release(net.ParseIP("172.19.10.1"))).To(Succeed())
+		Expect(store.Status.Available).To(BeEquivalentTo(10))
+	})
+})
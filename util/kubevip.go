@@ -0,0 +1,159 @@
+package util
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	kubeVIPName            = "kube-vip"
+	kubeVIPNamespace       = "kube-system"
+	kubeVIPLeaseName       = "plndr-cp-lock"
+	kubeVIPKubeconfigMount = "/etc/kubernetes/admin.conf"
+)
+
+// KubeVIPConfig is the configuration of the kube-vip static pod announcing the control plane endpoint.
+type KubeVIPConfig struct {
+	// Image is the image of kube-vip.
+	Image string
+	// Address is the virtual IP of the control plane, or the hostname registered with dynamic DNS if DDNS is true.
+	Address string
+	// Port is the port of the API server.
+	Port int32
+	// Interface is the interface the virtual IP is announced on. kube-vip uses the interface of the default route if it is empty.
+	Interface string
+	// DDNS makes kube-vip get the virtual IP from DHCP and register Address with dynamic DNS.
+	DDNS bool
+	// ManifestPath is the path of the static pod manifest on the machine.
+	ManifestPath string
+	// KubeconfigPath is the path of the kubeconfig used by kube-vip on the machine.
+	KubeconfigPath string
+}
+
+type cloudInitWriteFiles struct {
+	WriteFiles []cloudInitFile `json:"write_files"`
+}
+
+type cloudInitFile struct {
+	Path        string `json:"path"`
+	Owner       string `json:"owner"`
+	Permissions string `json:"permissions"`
+	Content     string `json:"content"`
+}
+
+// RenderKubeVIPCloudInit renders a cloud-init section writing the kube-vip static pod manifest on a control plane machine.
+// It is meant to be merged with the other cloud-init sections with MergeCloudInitData.
+func RenderKubeVIPCloudInit(config KubeVIPConfig) (string, error) {
+	if config.Address == "" {
+		return "", fmt.Errorf("no address set for kube-vip")
+	}
+
+	manifest, err := yaml.Marshal(buildKubeVIPPod(config))
+	if err != nil {
+		return "", fmt.Errorf("unable to marshall kube-vip manifest: %w", err)
+	}
+
+	cloudInit, err := yaml.Marshal(cloudInitWriteFiles{
+		WriteFiles: []cloudInitFile{
+			{
+				Path:        config.ManifestPath,
+				Owner:       "root:root",
+				Permissions: "0600",
+				Content:     string(manifest),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to marshall kube-vip cloud-init: %w", err)
+	}
+
+	return string(cloudInit), nil
+}
+
+// buildKubeVIPPod returns the kube-vip static pod, running in ARP mode with leader election between the control plane machines.
+func buildKubeVIPPod(config KubeVIPConfig) *corev1.Pod {
+	vipCIDR := "32"
+	if ip := net.ParseIP(config.Address); ip != nil && ip.To4() == nil {
+		vipCIDR = "128"
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "vip_arp", Value: "true"},
+		{Name: "port", Value: strconv.Itoa(int(config.Port))},
+		{Name: "vip_cidr", Value: vipCIDR},
+		{Name: "cp_enable", Value: "true"},
+		{Name: "cp_namespace", Value: kubeVIPNamespace},
+		{Name: "vip_leaderelection", Value: "true"},
+		{Name: "vip_leasename", Value: kubeVIPLeaseName},
+		{Name: "vip_leaseduration", Value: "5"},
+		{Name: "vip_renewdeadline", Value: "3"},
+		{Name: "vip_retryperiod", Value: "1"},
+		{Name: "address", Value: config.Address},
+	}
+
+	if config.Interface != "" {
+		env = append(env, corev1.EnvVar{Name: "vip_interface", Value: config.Interface})
+	}
+
+	if config.DDNS {
+		env = append(env, corev1.EnvVar{Name: "vip_ddns", Value: "true"})
+	}
+
+	hostPathFile := corev1.HostPathFile
+
+	return &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kubeVIPName,
+			Namespace: kubeVIPNamespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:            kubeVIPName,
+					Image:           config.Image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Args:            []string{"manager"},
+					Env:             env,
+					SecurityContext: &corev1.SecurityContext{
+						Capabilities: &corev1.Capabilities{
+							Add: []corev1.Capability{"NET_ADMIN", "NET_RAW"},
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "kubeconfig",
+							MountPath: kubeVIPKubeconfigMount,
+						},
+					},
+				},
+			},
+			HostAliases: []corev1.HostAlias{
+				{
+					IP:        "127.0.0.1",
+					Hostnames: []string{"kubernetes"},
+				},
+			},
+			HostNetwork: true,
+			Volumes: []corev1.Volume{
+				{
+					Name: "kubeconfig",
+					VolumeSource: corev1.VolumeSource{
+						HostPath: &corev1.HostPathVolumeSource{
+							Path: config.KubeconfigPath,
+							Type: &hostPathFile,
+						},
+					},
+				},
+			},
+		},
+	}
+}
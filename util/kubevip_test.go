@@ -0,0 +1,67 @@
+package util
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("RenderKubeVIPCloudInit", func() {
+	var config KubeVIPConfig
+
+	BeforeEach(func() {
+		config = KubeVIPConfig{
+			Image:          "ghcr.io/kube-vip/kube-vip:v0.8.7",
+			Address:        "172.19.10.1",
+			Port:           6443,
+			Interface:      "enp1s0",
+			ManifestPath:   "/etc/kubernetes/manifests/kube-vip.yaml",
+			KubeconfigPath: "/etc/kubernetes/admin.conf",
+		}
+	})
+
+	It("Should write the kube-vip static pod manifest", func() {
+		cloudInit, err := RenderKubeVIPCloudInit(config)
+		Expect(err).ToNot(HaveOccurred())
+
+		writeFiles := cloudInitWriteFiles{}
+		Expect(yaml.Unmarshal([]byte(cloudInit), &writeFiles)).To(Succeed())
+		Expect(writeFiles.WriteFiles).To(HaveLen(1))
+		Expect(writeFiles.WriteFiles[0].Path).To(Equal("/etc/kubernetes/manifests/kube-vip.yaml"))
+
+		pod := &corev1.Pod{}
+		Expect(yaml.Unmarshal([]byte(writeFiles.WriteFiles[0].Content), pod)).To(Succeed())
+		Expect(pod.Spec.HostNetwork).To(BeTrue())
+		Expect(pod.Spec.Volumes[0].HostPath.Path).To(Equal("/etc/kubernetes/admin.conf"))
+		Expect(pod.Spec.Containers[0].Image).To(Equal("ghcr.io/kube-vip/kube-vip:v0.8.7"))
+		Expect(pod.Spec.Containers[0].Env).To(ContainElements(
+			corev1.EnvVar{Name: "address", Value: "172.19.10.1"},
+			corev1.EnvVar{Name: "port", Value: "6443"},
+			corev1.EnvVar{Name: "vip_interface", Value: "enp1s0"},
+			corev1.EnvVar{Name: "vip_cidr", Value: "32"},
+		))
+		Expect(pod.Spec.Containers[0].Env).ToNot(ContainElement(corev1.EnvVar{Name: "vip_ddns", Value: "true"}))
+	})
+
+	It("Should enable dynamic DNS for a hostname", func() {
+		config.Address = "cp.example.com"
+		config.DDNS = true
+
+		cloudInit, err := RenderKubeVIPCloudInit(config)
+		Expect(err).ToNot(HaveOccurred())
+
+		merged, err := MergeCloudInitData("write_files:\n- path: /etc/motd\n  content: hello\n", cloudInit)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(merged)).To(ContainSubstring("path: /etc/motd"))
+		Expect(string(merged)).To(ContainSubstring("path: /etc/kubernetes/manifests/kube-vip.yaml"))
+		Expect(string(merged)).To(ContainSubstring("vip_ddns"))
+	})
+
+	It("Should fail without an address", func() {
+		config.Address = ""
+
+		_, err := RenderKubeVIPCloudInit(config)
+		Expect(err).To(HaveOccurred())
+	})
+})
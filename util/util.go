@@ -2,11 +2,13 @@ package util
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"regexp"
 	"strings"
@@ -19,7 +21,7 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha1"
+	infrav1 "github.com/rancher-sandbox/cluster-api-provider-harvester/api/v1alpha2"
 	hvclientset "github.com/rancher-sandbox/cluster-api-provider-harvester/pkg/clientset/versioned"
 )
 
@@ -97,13 +99,15 @@ func GetSecretForHarvesterConfig(ctx context.Context, cluster *infrav1.Harvester
 	return secret, err
 }
 
+// GetHarvesterClientFromSecret builds a new Harvester client from an identity secret.
+// The controllers use a ClientCache instead, to share the clients between the reconciliations.
 func GetHarvesterClientFromSecret(secret *corev1.Secret) (*hvclientset.Clientset, error) {
-	hvRESTConfig, err := clientcmd.RESTConfigFromKubeConfig(secret.Data[ConfigSecretDataKey])
+	clients, err := NewHarvesterClients(secret, 0, 0)
 	if err != nil {
 		return &hvclientset.Clientset{}, err
 	}
 
-	return hvclientset.NewForConfig(hvRESTConfig)
+	return clients.Harvester, nil
 }
 
 // RandomID returns a random string used as an ID internally in Harvester.
@@ -116,6 +120,20 @@ func RandomID() string {
 	return res
 }
 
+// RandomMACAddress returns a random locally administered unicast MAC address.
+func RandomMACAddress() (string, error) {
+	mac := make(net.HardwareAddr, 6) //nolint:mnd
+
+	if _, err := rand.Read(mac); err != nil {
+		return "", err
+	}
+
+	// Set the locally administered bit and clear the multicast bit.
+	mac[0] = (mac[0] | 0x02) & 0xfe //nolint:mnd
+
+	return mac.String(), nil
+}
+
 // NewTrue returns a pointer to true.
 func NewTrue() *bool {
 	b := true